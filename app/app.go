@@ -1,23 +1,21 @@
 package app
 
 import (
-	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 
-	"loan-api/config"
-	"loan-api/controllers"
-	"loan-api/database"
+	_ "loan-api/docs"
 	"loan-api/middlewares"
-	"loan-api/repositories"
 	"loan-api/routers"
-	"loan-api/services"
 )
 
 // Validadores personalizados
@@ -82,99 +80,91 @@ var loanAmountValidation validator.Func = func(fl validator.FieldLevel) bool {
 	return false
 }
 
-// SetupRouter configura el router de Gin específicamente para testing
-func SetupRouter(cfg config.Config) *gin.Engine {
-	// Configurar Gin en modo test
-	gin.SetMode(gin.TestMode)
-
-	// Registrar validaciones personalizadas
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		v.RegisterValidation("alpha", alphaValidation)
-		v.RegisterValidation("password", passwordValidation)
-		v.RegisterValidation("credit_score", creditScoreValidation)
-		v.RegisterValidation("loan_amount", loanAmountValidation)
-	}
-
-	// Conectar a la base de datos (usa la configuración pasada)
-	database.Connect(&cfg)
-
-	// Ejecutar migraciones para tests
-	database.Migrate()
-
-	// Inicializar repositorios
-	userRepository := repositories.NewUserRepository(database.DB)
-	loanRepository := repositories.NewLoanRepository(database.DB)
-	tenantRepository := repositories.NewTenantRepository(database.DB)
-	loanTypeRepository := repositories.NewLoanTypeRepository(database.DB)
+// validationsOnce evita registrar las validaciones más de una vez cuando se crean varios routers
+var validationsOnce sync.Once
+
+// registerValidations registra una sola vez las validaciones personalizadas en el validador de Gin
+func registerValidations() {
+	validationsOnce.Do(func() {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			v.RegisterValidation("alpha", alphaValidation)
+			v.RegisterValidation("password", passwordValidation)
+			v.RegisterValidation("credit_score", creditScoreValidation)
+			v.RegisterValidation("loan_amount", loanAmountValidation)
+		}
+	})
+}
 
-	// Inicializar servicios
-	userService := services.NewUserService(userRepository)
-	tenantService := services.NewTenantService(tenantRepository)
-	loanTypeService := services.NewLoanTypeService(loanTypeRepository)
-	loanService := services.NewLoanService(loanRepository, userRepository, loanTypeRepository)
+// NewRouter configura el servidor Gin con los middlewares y las rutas de la aplicación
+func NewRouter(c *Container) *gin.Engine {
+	cfg := c.Config
 
-	// Inicializar controladores
-	userController := controllers.NewUserController(userService, &cfg)
-	tenantController := controllers.NewTenantController(tenantService)
-	loanTypeController := controllers.NewLoanTypeController(loanTypeService, tenantService)
-	loanController := controllers.NewLoanController(loanService, tenantService)
+	// Registrar validaciones personalizadas
+	registerValidations()
 
 	// Configurar servidor Gin
 	router := gin.New()
 	router.MaxMultipartMemory = 8 << 20 // 8 MiB
 
-	// Configurar logger personalizado para testing
-	router.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		// En modo test, logging más simple
-		if cfg.AppEnv == "test" {
-			return fmt.Sprintf("[TEST] %s %s %d\n",
-				param.Method,
-				param.Path,
-				param.StatusCode,
-			)
-		}
-		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\"\n",
-			param.ClientIP,
-			param.TimeStamp.Format(time.RFC1123),
-			param.Method,
-			param.Path,
-			param.Request.Proto,
-			param.StatusCode,
-			param.Latency,
-			param.Request.UserAgent(),
-			param.ErrorMessage,
-		)
-	}))
+	// Identificador de la solicitud y contexto de trazas; va primero para que los logs y las
+	// respuestas de error de los demás middlewares lo incluyan
+	router.Use(middlewares.RequestContext())
+
+	// Log estructurado de cada solicitud
+	router.Use(middlewares.AccessLog("/loan-api/api/v1/health-checker"))
 
 	// Configurar CORS
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = []string{"*"}
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Tenant-ID"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Tenant-ID", "X-Request-ID", "traceparent", "Idempotency-Key"}
+	corsConfig.ExposeHeaders = []string{"X-Request-ID", "Idempotent-Replayed", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"}
 	corsConfig.AllowCredentials = true
 
 	router.Use(cors.New(corsConfig))
 
 	// Middleware de recuperación de errores
-	router.Use(gin.Recovery())
+	router.Use(middlewares.Recovery())
 
-	// Aplicar middleware Tenant de manera global
-	router.Use(middlewares.Tenant())
+	// Configurar Swagger
+	if cfg.AppEnv == "local" || cfg.AppEnv == "dev" {
+		// Configurar Swagger en una ruta separada para evitar conflictos
+		router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	}
 
-	// Configurar rutas
+	// Plazo de cada solicitud de la API; las rutas de larga duración tienen su propio plazo
 	apiGroup := router.Group("/loan-api/api/v1")
+	apiGroup.Use(middlewares.Timeout(cfg.RequestTimeout, map[string]time.Duration{
+		"/loan-api/api/v1/admin/loans/export":   cfg.ExportTimeout,
+		"/loan-api/api/v1/admin/jobs/:name/run": cfg.JobLeaseTTL,
+	}))
+
+	// Aplicar middleware Tenant al grupo de la API
+	apiGroup.Use(middlewares.Tenant(c.Repositories.Tenant))
 
 	// Inicializar y configurar routers
-	userRouter := routers.NewUserRouter(userController)
-	loanRouter := routers.NewLoanRouter(loanController)
-	tenantRouter := routers.NewTenantRouter(tenantController)
-	loanTypeRouter := routers.NewLoanTypeRouter(loanTypeController)
+	authRateLimit := c.RateLimiter.Limit(middlewares.RateLimitGroupAuth)
+	loanRateLimit := c.RateLimiter.Limit(middlewares.RateLimitGroupLoans)
+	idempotency := middlewares.Idempotency(c.Repositories.Idempotency, cfg.IdempotencyKeyTTL, cfg.RequestTimeout)
+	auth := middlewares.AuthMiddleware(cfg.AccessTokenPublicKey)
+	userRouter := routers.NewUserRouter(c.Controllers.User, authRateLimit)
+	loanRouter := routers.NewLoanRouter(c.Controllers.Loan, auth, loanRateLimit, idempotency)
+	loanPartyRouter := routers.NewLoanPartyRouter(c.Controllers.LoanParty, auth, loanRateLimit)
+	reviewRouter := routers.NewReviewRouter(c.Controllers.Review, auth)
+	adminRouter := routers.NewAdminRouter(c.Controllers.Admin, auth)
+	analyticsRouter := routers.NewAnalyticsRouter(c.Controllers.Analytics, auth)
+	tenantRouter := routers.NewTenantRouter(c.Controllers.Tenant)
+	loanTypeRouter := routers.NewLoanTypeRouter(c.Controllers.LoanType, auth)
 
 	// Configurar rutas de los módulos
 	userRouter.Setup(apiGroup)
 	loanRouter.Setup(apiGroup)
+	loanPartyRouter.Setup(apiGroup)
 	tenantRouter.Setup(apiGroup)
 	loanTypeRouter.Setup(apiGroup)
+	reviewRouter.Setup(apiGroup)
+	adminRouter.Setup(apiGroup)
+	analyticsRouter.Setup(apiGroup)
 
 	// Ruta de health check
 	router.GET("/health", func(c *gin.Context) {
@@ -192,5 +182,29 @@ func SetupRouter(cfg config.Config) *gin.Engine {
 		})
 	})
 
+	// Ruta raíz con información de la API
+	router.GET("/", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"message":     "¡Bienvenido a Loan API!",
+			"version":     cfg.AppVersion,
+			"environment": cfg.AppEnv,
+			"docs":        "/docs/index.html",
+			"health":      "/loan-api/api/v1/health-checker",
+		})
+	})
+
 	return router
 }
+
+// testModeOnce evita cambiar el modo global de Gin mientras otras pruebas construyen routers en paralelo
+var testModeOnce sync.Once
+
+// SetupRouter configura el router de Gin específicamente para testing sobre el contenedor indicado
+func SetupRouter(c *Container) *gin.Engine {
+	// Configurar Gin en modo test
+	testModeOnce.Do(func() {
+		gin.SetMode(gin.TestMode)
+	})
+
+	return NewRouter(c)
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"loan-api/config"
+	"loan-api/controllers"
+	"loan-api/middlewares"
+	"loan-api/repositories"
+	"loan-api/scheduler"
+	"loan-api/services"
+)
+
+// Repositories agrupa los repositorios de la aplicación
+type Repositories struct {
+	User        repositories.UserRepository
+	Loan        repositories.LoanRepository
+	Tenant      repositories.TenantRepository
+	LoanType    repositories.LoanTypeRepository
+	LoanReview  repositories.LoanReviewRepository
+	LoanParty   repositories.LoanPartyRepository
+	LoanExport  repositories.LoanExportRepository
+	Analytics   repositories.AnalyticsRepository
+	Job         repositories.JobRepository
+	Idempotency repositories.IdempotencyRepository
+
+	// Transaction agrupa operaciones de varios repositorios en una sola unidad de trabajo
+	Transaction repositories.TransactionManager
+}
+
+// Services agrupa los servicios de la aplicación
+type Services struct {
+	User       services.UserService
+	Tenant     services.TenantService
+	LoanType   services.LoanTypeService
+	Loan       services.LoanService
+	Review     services.ReviewService
+	LoanParty  services.LoanPartyService
+	LoanExport services.LoanExportService
+	Analytics  services.AnalyticsService
+	Expiration services.ExpirationService
+}
+
+// Controllers agrupa los controladores de la aplicación
+type Controllers struct {
+	User      *controllers.UserController
+	Tenant    *controllers.TenantController
+	LoanType  *controllers.LoanTypeController
+	Loan      *controllers.LoanController
+	LoanParty *controllers.LoanPartyController
+	Review    *controllers.ReviewController
+	Analytics *controllers.AnalyticsController
+	Admin     *controllers.AdminController
+}
+
+// Container contiene las dependencias de una instancia de la aplicación, construidas una sola vez.
+// Cada contenedor usa su propia conexión, por lo que varias instancias pueden convivir en el mismo proceso
+type Container struct {
+	Config       config.Config
+	DB           *gorm.DB
+	Repositories Repositories
+	Services     Services
+	Controllers  Controllers
+	Scheduler    *scheduler.Scheduler
+	// RateLimiter es nil cuando el límite de solicitudes está desactivado
+	RateLimiter *middlewares.RateLimiter
+}
+
+// NewContainer construye repositorios, servicios, tareas programadas y controladores sobre la conexión indicada
+func NewContainer(cfg config.Config, db *gorm.DB) (*Container, error) {
+	c := &Container{Config: cfg, DB: db}
+
+	// Inicializar repositorios
+	c.Repositories = Repositories{
+		User:        repositories.NewUserRepository(db),
+		Loan:        repositories.NewLoanRepository(db),
+		Tenant:      repositories.NewTenantRepository(db),
+		LoanType:    repositories.NewLoanTypeRepository(db),
+		LoanReview:  repositories.NewLoanReviewRepository(db),
+		LoanParty:   repositories.NewLoanPartyRepository(db),
+		LoanExport:  repositories.NewLoanExportRepository(db),
+		Analytics:   repositories.NewAnalyticsRepository(db),
+		Job:         repositories.NewJobRepository(db),
+		Idempotency: repositories.NewIdempotencyRepository(db),
+
+		Transaction: repositories.NewTransactionManager(db),
+	}
+	repos := c.Repositories
+
+	// Inicializar servicios
+	disbursement := services.NewSimulatedDisbursementProvider()
+	c.Services = Services{
+		User:       services.NewUserService(repos.User),
+		Tenant:     services.NewTenantService(repos.Tenant),
+		LoanType:   services.NewLoanTypeService(repos.LoanType),
+		Loan:       services.NewLoanService(repos.Loan, repos.User, repos.LoanType, repos.Tenant, repos.Transaction, disbursement),
+		Review:     services.NewReviewService(repos.Loan, repos.User, repos.LoanType, repos.Tenant, repos.LoanReview, repos.Transaction, disbursement, cfg.ReviewClaimTTL),
+		LoanParty:  services.NewLoanPartyService(repos.Loan, repos.User, repos.LoanType, repos.Tenant, repos.LoanParty),
+		LoanExport: services.NewLoanExportService(repos.LoanExport, repos.LoanType),
+		Analytics:  services.NewAnalyticsService(repos.Analytics, repos.LoanType, cfg.AnalyticsCacheTTL),
+		Expiration: services.NewExpirationService(repos.Loan, repos.LoanType),
+	}
+	svcs := c.Services
+
+	// Inicializar tareas programadas
+	c.Scheduler = scheduler.NewScheduler(repos.Job, scheduler.InstanceOwner(), cfg.JobLeaseTTL, time.Now)
+	if err := scheduler.RegisterJobs(c.Scheduler, cfg, svcs.Expiration, repos.Idempotency); err != nil {
+		return nil, fmt.Errorf("no se pudieron registrar las tareas programadas: %w", err)
+	}
+
+	// Inicializar límite de solicitudes
+	if cfg.RateLimitEnabled {
+		rules, err := middlewares.ParseRateLimitRules(map[string]string{
+			middlewares.RateLimitGroupAuth:  cfg.RateLimitAuth,
+			middlewares.RateLimitGroupLoans: cfg.RateLimitLoans,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo configurar el límite de solicitudes: %w", err)
+		}
+		c.RateLimiter = middlewares.NewRateLimiter(middlewares.NewMemoryRateLimitStore(), rules, time.Now)
+	}
+
+	// Inicializar controladores
+	c.Controllers = Controllers{
+		User:      controllers.NewUserController(svcs.User, &c.Config),
+		Tenant:    controllers.NewTenantController(svcs.Tenant),
+		LoanType:  controllers.NewLoanTypeController(svcs.LoanType, svcs.Tenant),
+		Loan:      controllers.NewLoanController(svcs.Loan, svcs.Tenant),
+		LoanParty: controllers.NewLoanPartyController(svcs.LoanParty),
+		Review:    controllers.NewReviewController(svcs.Review),
+		Analytics: controllers.NewAnalyticsController(svcs.Analytics),
+		Admin:     controllers.NewAdminController(svcs.Loan, svcs.LoanExport, c.Scheduler),
+	}
+
+	return c, nil
+}
+
+// Close detiene las tareas programadas y cierra la conexión del contenedor
+func (c *Container) Close() error {
+	c.Scheduler.Stop()
+
+	sqlDB, err := c.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
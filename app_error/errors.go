@@ -1,6 +1,8 @@
 package app_error
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -31,6 +33,9 @@ func NewAppError(code int, message string, details ...string) *AppError {
 	return appErr
 }
 
+// StatusClientClosedRequest es el código no estándar (nginx) para solicitudes que el cliente abandonó
+const StatusClientClosedRequest = 499
+
 // Errores comunes predefinidos
 var (
 	// Errores de validación
@@ -57,6 +62,49 @@ var (
 	ErrCannotUpdateStatus = NewAppError(http.StatusBadRequest, "No se puede actualizar el estado del préstamo")
 	ErrInsufficientCredit = NewAppError(http.StatusBadRequest, "Puntaje crediticio insuficiente")
 	ErrInvalidAmount      = NewAppError(http.StatusBadRequest, "Monto inválido")
+	ErrLoanNotCancellable = NewAppError(http.StatusConflict, "Solo se pueden cancelar préstamos antes de su aprobación")
+	ErrLoanNotDecidable   = NewAppError(http.StatusConflict, "Solo se pueden evaluar préstamos en estado completado")
+	ErrLoanCancelled      = NewAppError(http.StatusConflict, "El préstamo fue cancelado")
+	ErrCancelReasonEmpty  = NewAppError(http.StatusBadRequest, "El motivo de cancelación es obligatorio")
+	ErrFormNotFound       = NewAppError(http.StatusNotFound, "Formulario no encontrado para el tipo de préstamo")
+	ErrLoanTypeNotFound   = NewAppError(http.StatusNotFound, "Tipo de préstamo no encontrado")
+	ErrLoanConflict       = NewAppError(http.StatusConflict, "El préstamo fue modificado por otra operación; consulte su estado e intente de nuevo")
+	ErrDisbursementFailed = NewAppError(http.StatusServiceUnavailable, "El proveedor de desembolso no respondió; el préstamo volvió a estado completado y la decisión puede reintentarse")
+
+	// Errores de contraofertas
+	ErrOfferNotFound   = NewAppError(http.StatusNotFound, "Contraoferta no encontrada")
+	ErrOfferNotPending = NewAppError(http.StatusConflict, "La contraoferta ya fue respondida")
+	ErrOfferExpired    = NewAppError(http.StatusConflict, "La contraoferta está vencida")
+
+	// Errores de codeudores y garantes
+	ErrPartyInviteeNotFound      = NewAppError(http.StatusNotFound, "No existe un usuario registrado con ese email")
+	ErrPartyAlreadyInvited       = NewAppError(http.StatusConflict, "El usuario ya participa o fue invitado al préstamo")
+	ErrPartyInvitationNotFound   = NewAppError(http.StatusNotFound, "Invitación no encontrada")
+	ErrPartyInvitationNotPending = NewAppError(http.StatusConflict, "La invitación ya fue respondida")
+	ErrPartyConsentRequired      = NewAppError(http.StatusBadRequest, "Debe otorgar su consentimiento para participar en el préstamo")
+	ErrPartyLoanNotOpen          = NewAppError(http.StatusConflict, "Solo se pueden gestionar participantes antes de la decisión del préstamo")
+	ErrPartyInvitationsPending   = NewAppError(http.StatusConflict, "Hay invitaciones a codeudores o garantes pendientes de respuesta")
+
+	// Errores de revisión manual
+	ErrReviewClaimConflict = NewAppError(http.StatusConflict, "El préstamo está asignado a otro analista")
+	ErrReviewClaimRequired = NewAppError(http.StatusConflict, "Debe tomar el préstamo antes de registrar una decisión")
+	ErrReviewCommentEmpty  = NewAppError(http.StatusBadRequest, "El comentario es obligatorio")
+
+	// Errores de tareas programadas
+	ErrJobNotFound  = NewAppError(http.StatusNotFound, "Tarea programada no encontrada")
+	ErrJobLeaseHeld = NewAppError(http.StatusConflict, "La tarea se está ejecutando en otra instancia")
+	ErrJobRunning   = NewAppError(http.StatusConflict, "La tarea ya se está ejecutando en esta instancia")
+
+	// Errores de idempotencia
+	ErrIdempotencyKeyInvalid    = NewAppError(http.StatusBadRequest, "El encabezado Idempotency-Key debe tener entre 1 y 255 caracteres")
+	ErrIdempotencyKeyReused     = NewAppError(http.StatusUnprocessableEntity, "La clave de idempotencia ya se usó con una solicitud diferente")
+	ErrIdempotencyKeyInProgress = NewAppError(http.StatusConflict, "Una solicitud con la misma clave de idempotencia se está procesando")
+
+	// Errores de límite de solicitudes
+	ErrTooManyRequests = NewAppError(http.StatusTooManyRequests, "Demasiadas solicitudes; intente de nuevo más tarde")
+
+	// Errores de exportación
+	ErrExportFormatInvalid = NewAppError(http.StatusBadRequest, "El formato de exportación debe ser csv o xlsx")
 
 	// Errores de autenticación
 	ErrUnauthorized = NewAppError(http.StatusUnauthorized, "No autorizado")
@@ -67,6 +115,8 @@ var (
 	// Errores del servidor
 	ErrInternalServer     = NewAppError(http.StatusInternalServerError, "Error interno del servidor")
 	ErrServiceUnavailable = NewAppError(http.StatusServiceUnavailable, "Servicio no disponible")
+	ErrRequestTimeout     = NewAppError(http.StatusGatewayTimeout, "La solicitud excedió el tiempo máximo de procesamiento")
+	ErrRequestCanceled    = NewAppError(StatusClientClosedRequest, "La solicitud fue cancelada por el cliente")
 )
 
 // NewValidationError crea un error de validación con detalles específicos
@@ -88,6 +138,17 @@ func NewBusinessError(message string, details string) *AppError {
 	return NewAppError(http.StatusBadRequest, message, details)
 }
 
+// FromContextError retorna el AppError que corresponde a un error de cancelación o de plazo vencido
+func FromContextError(err error) (*AppError, bool) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrRequestTimeout, true
+	case errors.Is(err, context.Canceled):
+		return ErrRequestCanceled, true
+	}
+	return nil, false
+}
+
 // IsAppError verifica si un error es de tipo AppError
 func IsAppError(err error) (*AppError, bool) {
 	appErr, ok := err.(*AppError)
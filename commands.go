@@ -0,0 +1,164 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
+
+	"loan-api/config"
+	"loan-api/database"
+)
+
+const commandUsage = `Uso:
+  loan-api                      inicia el servidor (falla si hay migraciones pendientes)
+  loan-api migrate up           aplica todas las migraciones pendientes
+  loan-api migrate down [N]     revierte las últimas N migraciones (por defecto 1)
+  loan-api migrate status       muestra el estado de cada migración
+  loan-api migrate create NAME  crea los archivos de una nueva migración para cada motor
+  loan-api seed [--env ENV]     ejecuta los seeders del entorno (por defecto APP_ENV)`
+
+// runCommand ejecuta un subcomando de administración en lugar de iniciar el servidor
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrateCommand(cfg, args[1:])
+	case "seed":
+		return runSeedCommand(cfg, args[1:])
+	case "help", "-h", "--help":
+		fmt.Println(commandUsage)
+		return nil
+	default:
+		return fmt.Errorf("comando desconocido %q\n%s", args[0], commandUsage)
+	}
+}
+
+// runMigrateCommand ejecuta los subcomandos de migración del esquema
+func runMigrateCommand(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("falta el subcomando de migrate\n%s", commandUsage)
+	}
+
+	// create solo genera archivos y no necesita conexión a la base de datos
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return fmt.Errorf("uso: loan-api migrate create NAME")
+		}
+		files, err := database.CreateMigration(database.MigrationsDir, args[1])
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			slog.Info("migración creada", "file", file)
+		}
+		return nil
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			slog.Info("migración aplicada", "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			slog.Info("el esquema ya está al día")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("N debe ser un entero positivo: %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		for _, migration := range reverted {
+			slog.Info("migración revertida", "version", migration.Version, "name", migration.Name)
+		}
+		return err
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSIÓN\tNOMBRE\tESTADO\tAPLICADA")
+		for _, status := range statuses {
+			state, appliedAt := "pendiente", "-"
+			if status.Applied {
+				state, appliedAt = "aplicada", status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(writer, "%06d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		return writer.Flush()
+
+	default:
+		return fmt.Errorf("subcomando de migrate desconocido %q\n%s", args[0], commandUsage)
+	}
+}
+
+// runSeedCommand ejecuta los seeders del entorno indicado sobre un esquema al día
+func runSeedCommand(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	env := flags.String("env", cfg.AppEnv, "entorno cuyos seeders se ejecutan")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	if err := migrator.CheckSchema(); err != nil {
+		return err
+	}
+
+	if err := database.Seed(db, *env); err != nil {
+		return err
+	}
+	slog.Info("seeders ejecutados correctamente", "env", *env)
+	return nil
+}
+
+// openDatabase abre la conexión usada por un subcomando
+func openDatabase(cfg *config.Config) (*gorm.DB, error) {
+	db, err := database.Open(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error al conectar a la base de datos: %w", err)
+	}
+	return db, nil
+}
+
+// closeDatabase cierra la conexión de un subcomando
+func closeDatabase(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
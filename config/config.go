@@ -2,15 +2,33 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
+// Motores de base de datos soportados por DB_DRIVER
+const (
+	DBDriverMySQL    = "mysql"
+	DBDriverPostgres = "postgres"
+	DBDriverSQLite   = "sqlite"
+)
+
+// Formatos de LOG_FORMAT
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// SQLiteInMemory es el valor de DB_NAME que indica una base SQLite en memoria
+const SQLiteInMemory = ":memory:"
+
 // Config contiene toda la configuración de la aplicación
 type Config struct {
-	// Base de datos
+	// Base de datos (DB_DRIVER: mysql, postgres o sqlite; con sqlite DB_NAME es la ruta del archivo o :memory:)
+	DBDriver   string `mapstructure:"DB_DRIVER"`
 	DBHost     string `mapstructure:"DB_HOST"`
 	DBPort     string `mapstructure:"DB_PORT"`
 	DBUser     string `mapstructure:"DB_USER"`
@@ -27,6 +45,34 @@ type Config struct {
 	AccessTokenExpiresIn  time.Duration `mapstructure:"ACCESS_TOKEN_EXPIRED_IN"`
 	AccessTokenMaxAge     int           `mapstructure:"ACCESS_TOKEN_MAXAGE"`
 
+	// Plazos de las solicitudes HTTP (la exportación tiene su propio plazo por su duración)
+	RequestTimeout time.Duration `mapstructure:"REQUEST_TIMEOUT"`
+	ExportTimeout  time.Duration `mapstructure:"EXPORT_TIMEOUT"`
+
+	// Claves de idempotencia (vigencia de la respuesta guardada para los reintentos)
+	IdempotencyKeyTTL time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL"`
+
+	// Límite de solicitudes por grupo de rutas (solicitudes/periodo, por ejemplo 10/1m; 0 desactiva el límite)
+	RateLimitEnabled bool   `mapstructure:"RATE_LIMIT_ENABLED"`
+	RateLimitAuth    string `mapstructure:"RATE_LIMIT_AUTH"`
+	RateLimitLoans   string `mapstructure:"RATE_LIMIT_LOANS"`
+
+	// Revisión manual
+	ReviewClaimTTL time.Duration `mapstructure:"REVIEW_CLAIM_TTL"`
+
+	// Analítica (vigencia de los resultados calculados en caché)
+	AnalyticsCacheTTL time.Duration `mapstructure:"ANALYTICS_CACHE_TTL"`
+
+	// Tareas programadas
+	SchedulerEnabled         bool          `mapstructure:"SCHEDULER_ENABLED"`
+	JobLeaseTTL              time.Duration `mapstructure:"JOB_LEASE_TTL"`
+	ExpireStaleLoansSchedule string        `mapstructure:"EXPIRE_STALE_LOANS_SCHEDULE"`
+	PurgeIdempotencySchedule string        `mapstructure:"PURGE_IDEMPOTENCY_KEYS_SCHEDULE"`
+
+	// Logs (LOG_LEVEL: debug, info, warn o error; LOG_FORMAT: json o text)
+	LogLevel  string `mapstructure:"LOG_LEVEL"`
+	LogFormat string `mapstructure:"LOG_FORMAT"`
+
 	// Aplicación
 	AppEnv     string `mapstructure:"APP_ENV"`
 	AppName    string `mapstructure:"APP_NAME"`
@@ -43,13 +89,19 @@ func LoadConfig(path string) (config Config, err error) {
 	// Permitir que Viper lea variables de entorno
 	viper.AutomaticEnv()
 
+	// Valores por defecto que no pueden inferirse del valor cero
+	viper.SetDefault("SCHEDULER_ENABLED", true)
+	viper.SetDefault("RATE_LIMIT_ENABLED", true)
+	viper.SetDefault("RATE_LIMIT_AUTH", "10/1m")
+	viper.SetDefault("RATE_LIMIT_LOANS", "120/1m")
+	viper.SetDefault("DB_DRIVER", DBDriverMySQL)
+
 	// Leer el archivo de configuración
 	err = viper.ReadInConfig()
 	if err != nil {
-		log.Printf("Warning: No se pudo leer el archivo de configuración desde %s: %v", path, err)
-		log.Println("Usando solo variables de entorno...")
+		slog.Warn("no se pudo leer el archivo de configuración; se usan solo variables de entorno", "path", path, "error", err)
 	} else {
-		log.Printf("Configuración cargada desde: %s", viper.ConfigFileUsed())
+		slog.Info("archivo de configuración cargado", "file", viper.ConfigFileUsed())
 	}
 
 	// Mapear las variables a la estructura Config
@@ -59,11 +111,36 @@ func LoadConfig(path string) (config Config, err error) {
 	}
 
 	// Validar configuración crítica
+	if config.DBDriver == "" {
+		config.DBDriver = DBDriverMySQL
+	}
+	switch config.DBDriver {
+	case DBDriverMySQL, DBDriverPostgres, DBDriverSQLite:
+	default:
+		return config, fmt.Errorf("DB_DRIVER no soportado: %s (valores permitidos: mysql, postgres, sqlite)", config.DBDriver)
+	}
 	if config.DBHost == "" {
 		config.DBHost = "localhost"
 	}
 	if config.DBPort == "" {
-		config.DBPort = "3306"
+		config.DBPort = defaultDBPort(config.DBDriver)
+	}
+	if config.DBDriver == DBDriverSQLite && config.DBName == "" {
+		config.DBName = "loan_api.db"
+	}
+	if config.LogLevel == "" {
+		config.LogLevel = "info"
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.LogLevel)); err != nil {
+		return config, fmt.Errorf("LOG_LEVEL no soportado: %s (valores permitidos: debug, info, warn, error)", config.LogLevel)
+	}
+	switch config.LogFormat {
+	case "":
+		config.LogFormat = LogFormatJSON
+	case LogFormatJSON, LogFormatText:
+	default:
+		return config, fmt.Errorf("LOG_FORMAT no soportado: %s (valores permitidos: json, text)", config.LogFormat)
 	}
 	if config.ServerPort == "" {
 		config.ServerPort = "8080"
@@ -80,15 +157,69 @@ func LoadConfig(path string) (config Config, err error) {
 	if config.AccessTokenMaxAge == 0 {
 		config.AccessTokenMaxAge = 43800
 	}
+	if config.RequestTimeout == 0 {
+		config.RequestTimeout = 30 * time.Second
+	}
+	if config.ExportTimeout == 0 {
+		config.ExportTimeout = 10 * time.Minute
+	}
+	if config.IdempotencyKeyTTL == 0 {
+		config.IdempotencyKeyTTL = 24 * time.Hour
+	}
+	if config.ReviewClaimTTL == 0 {
+		config.ReviewClaimTTL = 30 * time.Minute
+	}
+	if config.AnalyticsCacheTTL == 0 {
+		config.AnalyticsCacheTTL = time.Minute
+	}
+	if config.JobLeaseTTL == 0 {
+		config.JobLeaseTTL = 5 * time.Minute
+	}
+	if config.ExpireStaleLoansSchedule == "" {
+		config.ExpireStaleLoansSchedule = "@every 1h"
+	}
+	if config.PurgeIdempotencySchedule == "" {
+		config.PurgeIdempotencySchedule = "@every 1h"
+	}
 
-	log.Printf("Configuración cargada: DB=%s:%s/%s, AppEnv=%s", config.DBHost, config.DBPort, config.DBName, config.AppEnv)
+	slog.Info("configuración cargada", "db_driver", config.DBDriver, "db_host", config.DBHost, "db_name", config.DBName, "app_env", config.AppEnv)
 
 	return config, nil
 }
 
-// GetDSN retorna la cadena de conexión para MySQL
+// defaultDBPort retorna el puerto por defecto de cada motor de base de datos
+func defaultDBPort(driver string) string {
+	if driver == DBDriverPostgres {
+		return "5432"
+	}
+	return "3306"
+}
+
+// GetDSN retorna la cadena de conexión según el motor configurado en DB_DRIVER
 func (c *Config) GetDSN() string {
-	return c.DBUser + ":" + c.DBPassword + "@tcp(" + c.DBHost + ":" + c.DBPort + ")/" + c.DBName + "?charset=utf8mb4&parseTime=True&loc=Local"
+	switch c.DBDriver {
+	case DBDriverPostgres:
+		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable TimeZone=UTC",
+			quoteDSNValue(c.DBHost), quoteDSNValue(c.DBPort), quoteDSNValue(c.DBUser), quoteDSNValue(c.DBPassword), quoteDSNValue(c.DBName),
+		)
+	case DBDriverSQLite:
+		if c.IsSQLiteInMemory() {
+			return "file::memory:?cache=shared&_foreign_keys=1"
+		}
+		return "file:" + c.DBName + "?_foreign_keys=1&_busy_timeout=5000&_journal_mode=WAL"
+	default:
+		return c.DBUser + ":" + c.DBPassword + "@tcp(" + c.DBHost + ":" + c.DBPort + ")/" + c.DBName + "?charset=utf8mb4&parseTime=True&loc=Local"
+	}
+}
+
+// quoteDSNValue escapa un valor del DSN clave=valor de PostgreSQL (admite vacíos y espacios)
+func quoteDSNValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// IsSQLiteInMemory verifica si la base de datos es SQLite en memoria
+func (c *Config) IsSQLiteInMemory() bool {
+	return c.DBDriver == DBDriverSQLite && c.DBName == SQLiteInMemory
 }
 
 // GetServerAddress retorna la dirección completa del servidor
@@ -0,0 +1,325 @@
+package controllers
+
+import (
+	"loan-api/models"
+	"loan-api/scheduler"
+	"loan-api/services"
+	"loan-api/utils"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// AdminController maneja las operaciones administrativas sobre préstamos del tenant
+type AdminController struct {
+	loanService       services.LoanService
+	loanExportService services.LoanExportService
+	jobScheduler      *scheduler.Scheduler
+}
+
+// NewAdminController crea una nueva instancia del controlador administrativo
+func NewAdminController(loanService services.LoanService, loanExportService services.LoanExportService, jobScheduler *scheduler.Scheduler) *AdminController {
+	return &AdminController{
+		loanService:       loanService,
+		loanExportService: loanExportService,
+		jobScheduler:      jobScheduler,
+	}
+}
+
+// SearchLoans godoc
+// @Summary Buscar préstamos del tenant
+// @Description Busca préstamos del tenant por datos del solicitante, estado, tipo, montos, score y fecha de creación con paginación por cursor
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param document_number query string false "Número de documento del solicitante"
+// @Param email query string false "Email del solicitante"
+// @Param name query string false "Prefijo del nombre del solicitante, sin distinguir mayúsculas"
+// @Param status query string false "Estados separados por coma"
+// @Param loan_type_id query int false "ID del tipo de préstamo"
+// @Param min_amount query string false "Monto aprobado mínimo"
+// @Param max_amount query string false "Monto aprobado máximo"
+// @Param min_score query int false "Score crediticio mínimo"
+// @Param max_score query int false "Score crediticio máximo"
+// @Param created_from query string false "Fecha de creación desde (YYYY-MM-DD)"
+// @Param created_to query string false "Fecha de creación hasta, inclusive (YYYY-MM-DD)"
+// @Param cursor query string false "Cursor de la siguiente página"
+// @Param limit query int false "Resultados por página (por defecto 20, máximo 100)"
+// @Success 200 {object} utils.CursorPaginatedResponse{data=[]models.LoanSearchItem}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /admin/loans [get]
+func (ctrl *AdminController) SearchLoans(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "AdminController::SearchLoans was invoked")
+
+	filter := models.LoanSearchFilter{
+		TenantID:       c.GetUint("tenant_id"),
+		DocumentNumber: strings.TrimSpace(c.Query("document_number")),
+		Email:          strings.TrimSpace(c.Query("email")),
+		Name:           strings.TrimSpace(c.Query("name")),
+		Limit:          defaultLoansPageLimit,
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxLoansPageLimit {
+			utils.BadRequestResponse(c, "limit debe ser un número entre 1 y "+strconv.Itoa(maxLoansPageLimit))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		afterID, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		filter.AfterID = afterID
+	}
+
+	if loanTypeIDStr := c.Query("loan_type_id"); loanTypeIDStr != "" {
+		loanTypeID, err := strconv.ParseUint(loanTypeIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequestResponse(c, "loan_type_id debe ser un número válido")
+			return
+		}
+		filter.LoanTypeID = uint(loanTypeID)
+	}
+
+	for param, target := range map[string]**decimal.Decimal{"min_amount": &filter.MinAmount, "max_amount": &filter.MaxAmount} {
+		if amountStr := c.Query(param); amountStr != "" {
+			amount, err := decimal.NewFromString(amountStr)
+			if err != nil {
+				utils.BadRequestResponse(c, param+" debe ser un monto válido")
+				return
+			}
+			*target = &amount
+		}
+	}
+
+	for param, target := range map[string]**int{"min_score": &filter.MinScore, "max_score": &filter.MaxScore} {
+		if scoreStr := c.Query(param); scoreStr != "" {
+			score, err := strconv.Atoi(scoreStr)
+			if err != nil {
+				utils.BadRequestResponse(c, param+" debe ser un número válido")
+				return
+			}
+			*target = &score
+		}
+	}
+
+	statuses, ok := parseStatusesQuery(c)
+	if !ok {
+		return
+	}
+	filter.Statuses = statuses
+
+	createdFrom, createdTo, ok := parseCreatedRangeQuery(c)
+	if !ok {
+		return
+	}
+	filter.CreatedFrom, filter.CreatedTo = createdFrom, createdTo
+
+	items, nextID, err := ctrl.loanService.SearchLoans(c.Request.Context(), filter)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.CursorPaginatedSuccessResponse(c, "Préstamos encontrados exitosamente", items, utils.NewCursorPagination(filter.Limit, nextID))
+}
+
+// ExportLoans godoc
+// @Summary Exportar préstamos del tenant
+// @Description Descarga los préstamos del tenant en CSV o XLSX con los datos del solicitante y una columna por input de formulario. Cada exportación queda registrada
+// @Tags admin
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param format query string false "Formato: csv (por defecto) o xlsx"
+// @Param status query string false "Estados separados por coma"
+// @Param loan_type_id query int false "ID del tipo de préstamo"
+// @Param created_from query string false "Fecha de creación desde (YYYY-MM-DD)"
+// @Param created_to query string false "Fecha de creación hasta, inclusive (YYYY-MM-DD)"
+// @Success 200 {file} file
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /admin/loans/export [get]
+func (ctrl *AdminController) ExportLoans(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "AdminController::ExportLoans was invoked")
+
+	format := models.ExportFormat(c.DefaultQuery("format", string(models.ExportFormatCSV)))
+	filter := models.LoanExportFilter{TenantID: c.GetUint("tenant_id")}
+
+	if loanTypeIDStr := c.Query("loan_type_id"); loanTypeIDStr != "" {
+		loanTypeID, err := strconv.ParseUint(loanTypeIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequestResponse(c, "loan_type_id debe ser un número válido")
+			return
+		}
+		filter.LoanTypeID = uint(loanTypeID)
+	}
+
+	statuses, ok := parseStatusesQuery(c)
+	if !ok {
+		return
+	}
+	filter.Statuses = statuses
+
+	createdFrom, createdTo, ok := parseCreatedRangeQuery(c)
+	if !ok {
+		return
+	}
+	filter.CreatedFrom, filter.CreatedTo = createdFrom, createdTo
+
+	contentType := "text/csv; charset=utf-8"
+	if format == models.ExportFormatXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	filename := "prestamos_" + time.Now().Format("20060102_150405") + "." + string(format)
+
+	// Los encabezados se envían con la primera fila; si la exportación falla antes se responde con el error
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	if err := ctrl.loanExportService.ExportLoans(c.Request.Context(), c.Writer, c.GetUint("user_id"), format, filter); err != nil {
+		if c.Writer.Written() {
+			slog.WarnContext(c.Request.Context(), "AdminController::ExportLoans - exportación interrumpida", "error", err)
+			return
+		}
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		utils.ErrorResponse(c, err)
+		return
+	}
+}
+
+// CancelLoan godoc
+// @Summary Cancelar un préstamo como administrador
+// @Description Cancela un préstamo del tenant antes de su aprobación, registrando el miembro del staff y el motivo
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Param cancellation body models.CancelLoanRequest true "Motivo de la cancelación"
+// @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Router /admin/loans/{id}/cancel [post]
+func (ctrl *AdminController) CancelLoan(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "AdminController::CancelLoan was invoked")
+
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.CancelLoanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Formato JSON inválido")
+		return
+	}
+
+	loanResponse, err := ctrl.loanService.CancelLoanAsStaff(c.Request.Context(), c.GetUint("tenant_id"), loanID, c.GetUint("user_id"), req.Reason)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Préstamo cancelado exitosamente", loanResponse)
+}
+
+// GetJobs godoc
+// @Summary Listar tareas programadas
+// @Description Lista las tareas programadas registradas con su expresión cron y próxima ejecución
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Success 200 {object} utils.APIResponse{data=[]scheduler.JobInfo}
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /admin/jobs [get]
+func (ctrl *AdminController) GetJobs(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "AdminController::GetJobs was invoked")
+	utils.SuccessResponse(c, 200, "Tareas programadas obtenidas exitosamente", ctrl.jobScheduler.Jobs())
+}
+
+// GetJobRuns godoc
+// @Summary Historial de tareas programadas
+// @Description Obtiene las ejecuciones más recientes de las tareas programadas
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param job query string false "Nombre de la tarea"
+// @Param limit query int false "Cantidad máxima de ejecuciones (por defecto 50)"
+// @Success 200 {object} utils.APIResponse{data=[]models.JobRun}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /admin/jobs/runs [get]
+func (ctrl *AdminController) GetJobRuns(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "AdminController::GetJobRuns was invoked")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		utils.BadRequestResponse(c, "limit debe ser un número entre 1 y 500")
+		return
+	}
+
+	runs, err := ctrl.jobScheduler.GetRuns(c.Request.Context(), c.Query("job"), limit)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Historial de tareas obtenido exitosamente", runs)
+}
+
+// RunJob godoc
+// @Summary Ejecutar una tarea programada
+// @Description Ejecuta de inmediato una tarea programada, respetando el bloqueo entre instancias
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param name path string true "Nombre de la tarea"
+// @Success 200 {object} utils.APIResponse{data=models.JobRun}
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Router /admin/jobs/{name}/run [post]
+func (ctrl *AdminController) RunJob(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "AdminController::RunJob was invoked")
+
+	run, err := ctrl.jobScheduler.RunNow(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Tarea ejecutada exitosamente", run)
+}
@@ -0,0 +1,378 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"loan-api/models"
+	"loan-api/test"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestAdminController_CancelLoan(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería cancelar un préstamo registrando el staff y el motivo", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "admin@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/admin/loans/5/cancel", map[string]interface{}{
+			"reason": "Solicitud duplicada",
+		}, headers)
+		c.Equal(200, w.Code)
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 5).Error)
+		c.Equal("cancelled", loan.Status)
+		c.Equal("Solicitud duplicada", loan.CancellationReason)
+		c.NotNil(loan.CancelledBy)
+		c.Equal(uint(7), *loan.CancelledBy)
+		c.Equal(models.CancellerRoleStaff, loan.CancelledByRole)
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		data := response["data"].(map[string]interface{})
+		c.Equal(float64(7), data["cancelled_by"])
+		c.Equal("staff", data["cancelled_by_role"])
+		c.Equal("Solicitud duplicada", data["cancellation_reason"])
+		c.NotEmpty(data["cancelled_at"])
+	})
+
+	t.Run("Debería fallar sin motivo", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "admin@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/admin/loans/5/cancel", map[string]interface{}{
+			"reason": " ",
+		}, headers)
+		c.Equal(400, w.Code)
+	})
+
+	t.Run("Debería fallar para un usuario sin rol de administrador", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "analyst@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/admin/loans/5/cancel", map[string]interface{}{
+			"reason": "Solicitud duplicada",
+		}, headers)
+		c.Equal(403, w.Code)
+	})
+}
+
+func TestAdminController_Jobs(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería expirar solicitudes inactivas y registrar la ejecución", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		// El préstamo 1 lleva 40 días sin cambios; el préstamo 5 está reciente
+		c.NoError(DB.Model(&models.Loan{}).Where("id = ?", 1).
+			UpdateColumn("updated_at", time.Now().Add(-40*24*time.Hour)).Error)
+
+		token := loginAndGetToken(t, "admin@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/admin/jobs/expire_stale_loans/run", nil, headers)
+		c.Equal(200, w.Code)
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("expired", loan.Status)
+		c.Contains(loan.Observation, "inactividad")
+
+		var recentLoan models.Loan
+		c.NoError(DB.First(&recentLoan, 5).Error)
+		c.Equal("pending", recentLoan.Status)
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/admin/jobs/runs", map[string]interface{}{"job": "expire_stale_loans"}, headers)
+		c.Equal(200, w.Code)
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		runs := response["data"].([]interface{})
+		c.Len(runs, 1)
+		run := runs[0].(map[string]interface{})
+		c.Equal("success", run["status"])
+		c.Equal("1 solicitudes expiradas", run["result"])
+	})
+
+	t.Run("Debería expirar los préstamos cuyas contraofertas vencieron todas", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		// El préstamo 1 tiene sus contraofertas vencidas; el préstamo 5 las tiene vigentes
+		expiredOffers := setLoanWithOffers(t, 1, time.Now().Add(-time.Hour))
+		setLoanWithOffers(t, 5, time.Now().Add(72*time.Hour))
+
+		token := loginAndGetToken(t, "admin@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/admin/jobs/expire_stale_loans/run", nil, headers)
+		c.Equal(200, w.Code)
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("expired", loan.Status)
+		c.Contains(loan.Observation, "contraofertas vencieron")
+
+		var offer models.LoanOffer
+		c.NoError(DB.First(&offer, expiredOffers[0].ID).Error)
+		c.Equal(models.LoanOfferStatusExpired, offer.Status)
+
+		var liveLoan models.Loan
+		c.NoError(DB.First(&liveLoan, 5).Error)
+		c.Equal(string(models.LoanStatusOfferPending), liveLoan.Status)
+	})
+
+	t.Run("Debería fallar si otra instancia tiene el bloqueo de la tarea", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		c.NoError(DB.Create(&models.JobLease{
+			JobName:     "expire_stale_loans",
+			Owner:       "otra-instancia",
+			LockedUntil: time.Now().Add(time.Minute),
+		}).Error)
+
+		token := loginAndGetToken(t, "admin@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/admin/jobs/expire_stale_loans/run", nil, headers)
+		c.Equal(409, w.Code)
+	})
+
+	t.Run("Debería fallar con una tarea inexistente", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "admin@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/admin/jobs/unknown/run", nil, headers)
+		c.Equal(404, w.Code)
+	})
+}
+
+func TestAdminController_SearchLoans(t *testing.T) {
+	c := require.New(t)
+
+	searchIDs := func(t *testing.T, headers map[string]string, params map[string]interface{}) ([]float64, map[string]interface{}) {
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/admin/loans", params, headers)
+		require.Equal(t, 200, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		ids := make([]float64, 0)
+		for _, item := range response["data"].([]interface{}) {
+			ids = append(ids, item.(map[string]interface{})["id"].(float64))
+		}
+		return ids, response["pagination"].(map[string]interface{})
+	}
+
+	t.Run("Debería buscar préstamos por datos del solicitante y filtros", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "analyst@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		ids, _ := searchIDs(t, headers, map[string]interface{}{"document_number": "12345678"})
+		c.Equal([]float64{6, 1}, ids)
+
+		ids, _ = searchIDs(t, headers, map[string]interface{}{"email": "maria@example.com"})
+		c.Equal([]float64{2}, ids)
+
+		ids, _ = searchIDs(t, headers, map[string]interface{}{"status": "approved"})
+		c.Equal([]float64{4, 2}, ids)
+
+		ids, _ = searchIDs(t, headers, map[string]interface{}{"min_amount": "9000000", "max_amount": "12000000"})
+		c.Equal([]float64{6, 1}, ids)
+
+		// La respuesta es un resumen sin los datos del formulario
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/admin/loans", map[string]interface{}{"email": "maria@example.com"}, headers)
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		item := response["data"].([]interface{})[0].(map[string]interface{})
+		c.Equal("maria@example.com", item["applicant_email"])
+		c.Equal("87654321", item["document_number"])
+		c.NotContains(item, "data")
+	})
+
+	t.Run("Debería paginar los resultados con cursor", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "admin@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		ids, pagination := searchIDs(t, headers, map[string]interface{}{"limit": "4"})
+		c.Equal([]float64{6, 5, 4, 3}, ids)
+		c.Equal(true, pagination["has_next"])
+
+		ids, pagination = searchIDs(t, headers, map[string]interface{}{"limit": "4", "cursor": pagination["next_cursor"]})
+		c.Equal([]float64{2, 1}, ids)
+		c.Equal(false, pagination["has_next"])
+		c.Nil(pagination["next_cursor"])
+	})
+
+	t.Run("Debería rechazar solicitantes y parámetros inválidos", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/admin/loans", nil, map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		})
+		c.Equal(403, w.Code)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "analyst@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		for _, params := range []map[string]interface{}{
+			{"cursor": "no-es-un-cursor"},
+			{"limit": "0"},
+			{"min_amount": "mucho"},
+			{"max_score": "alto"},
+			{"status": "unknown"},
+		} {
+			w = test.MakeGetRequest(APP, "/loan-api/api/v1/admin/loans", params, headers)
+			c.Equal(400, w.Code, params)
+		}
+	})
+}
+
+func TestAdminController_ExportLoans(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería exportar en CSV los préstamos filtrados con sus datos pivotados", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		c.NoError(DB.Create(&models.LoanData{LoanID: 2, FormID: 1, Key: "full_name", Value: "María García"}).Error)
+		c.NoError(DB.Create(&models.LoanData{LoanID: 2, FormID: 4, Key: "reference_name", Value: "Pedro", Index: 0}).Error)
+		c.NoError(DB.Create(&models.LoanData{LoanID: 2, FormID: 4, Key: "reference_name", Value: "Lucía", Index: 1}).Error)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "analyst@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/admin/loans/export", map[string]interface{}{"status": "approved"}, headers)
+		c.Equal(200, w.Code)
+		c.Contains(w.Header().Get("Content-Type"), "text/csv")
+		c.Contains(w.Header().Get("Content-Disposition"), ".csv")
+
+		records, err := csv.NewReader(bytes.NewReader(w.Body.Bytes())).ReadAll()
+		c.NoError(err)
+		c.Len(records, 3)
+
+		columns := make(map[string]int)
+		for i, header := range records[0] {
+			columns[header] = i
+		}
+		c.Contains(columns, "document_number")
+		c.Contains(columns, "personal_info.full_name")
+		c.Contains(columns, "references.reference_name")
+
+		c.Equal("2", records[1][columns["loan_id"]])
+		c.Equal("87654321", records[1][columns["document_number"]])
+		c.Equal("approved", records[1][columns["status"]])
+		c.Equal("María García", records[1][columns["personal_info.full_name"]])
+		c.Equal("Pedro; Lucía", records[1][columns["references.reference_name"]])
+		c.Equal("4", records[2][columns["loan_id"]])
+
+		// La exportación queda registrada con quién la solicitó y los filtros
+		var export models.LoanExport
+		c.NoError(DB.Last(&export).Error)
+		c.Equal(uint(6), export.RequestedBy)
+		c.Equal(models.ExportFormatCSV, export.Format)
+		c.Equal(models.LoanExportStatusSuccess, export.Status)
+		c.Equal(2, export.RowCount)
+		c.Contains(export.Filters, "approved")
+		c.NotNil(export.FinishedAt)
+	})
+
+	t.Run("Debería exportar en XLSX", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "admin@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/admin/loans/export", map[string]interface{}{"format": "xlsx"}, headers)
+		c.Equal(200, w.Code)
+		c.Contains(w.Header().Get("Content-Disposition"), ".xlsx")
+
+		file, err := excelize.OpenReader(bytes.NewReader(w.Body.Bytes()))
+		c.NoError(err)
+		defer file.Close()
+
+		rows, err := file.GetRows(file.GetSheetName(0))
+		c.NoError(err)
+		c.Len(rows, 7)
+		c.Equal("loan_id", rows[0][0])
+	})
+
+	t.Run("Debería rechazar formatos, tipos de préstamo y roles inválidos", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "analyst@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/admin/loans/export", map[string]interface{}{"format": "pdf"}, headers)
+		c.Equal(400, w.Code)
+		c.Contains(w.Header().Get("Content-Type"), "application/json")
+		c.Empty(w.Header().Get("Content-Disposition"))
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/admin/loans/export", map[string]interface{}{"loan_type_id": "99"}, headers)
+		c.Equal(404, w.Code)
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/admin/loans/export", nil, map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		})
+		c.Equal(403, w.Code)
+	})
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"loan-api/models"
+	"loan-api/services"
+	"loan-api/utils"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rango de fechas de la analítica del portafolio
+const (
+	defaultAnalyticsRangeDays = 30
+	maxAnalyticsRangeDays     = 366
+)
+
+// AnalyticsController maneja la analítica del portafolio del tenant
+type AnalyticsController struct {
+	analyticsService services.AnalyticsService
+}
+
+// NewAnalyticsController crea una nueva instancia del controlador de analítica
+func NewAnalyticsController(analyticsService services.AnalyticsService) *AnalyticsController {
+	return &AnalyticsController{
+		analyticsService: analyticsService,
+	}
+}
+
+// GetFunnel godoc
+// @Summary Embudo de solicitudes del portafolio
+// @Description Obtiene las solicitudes iniciadas, en progreso, completadas, aprobadas y rechazadas, la conversión entre etapas, el score promedio, el monto aprobado promedio y los motivos de rechazo, en total, por período y por tipo de préstamo
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param from query string false "Fecha inicial (YYYY-MM-DD, por defecto 30 días atrás)"
+// @Param to query string false "Fecha final, inclusive (YYYY-MM-DD, por defecto hoy)"
+// @Param period query string false "Granularidad: day (por defecto), week, month"
+// @Param loan_type_id query int false "ID del tipo de préstamo"
+// @Success 200 {object} utils.APIResponse{data=models.AnalyticsResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /admin/analytics/funnel [get]
+func (ctrl *AnalyticsController) GetFunnel(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "AnalyticsController::GetFunnel was invoked")
+
+	today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	filter := models.AnalyticsFilter{
+		TenantID: c.GetUint("tenant_id"),
+		From:     today.AddDate(0, 0, -(defaultAnalyticsRangeDays - 1)),
+		To:       today,
+		Period:   models.AnalyticsPeriod(c.DefaultQuery("period", string(models.AnalyticsPeriodDay))),
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			utils.BadRequestResponse(c, "from debe tener el formato YYYY-MM-DD")
+			return
+		}
+		filter.From = from
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			utils.BadRequestResponse(c, "to debe tener el formato YYYY-MM-DD")
+			return
+		}
+		filter.To = to
+	}
+
+	if filter.From.After(filter.To) {
+		utils.BadRequestResponse(c, "from no puede ser posterior a to")
+		return
+	}
+
+	// La fecha final es inclusiva: se filtra hasta el inicio del día siguiente
+	filter.To = filter.To.AddDate(0, 0, 1)
+	if filter.To.Sub(filter.From) > maxAnalyticsRangeDays*24*time.Hour {
+		utils.BadRequestResponse(c, "el rango no puede superar "+strconv.Itoa(maxAnalyticsRangeDays)+" días")
+		return
+	}
+
+	if filter.Period != models.AnalyticsPeriodDay && filter.Period != models.AnalyticsPeriodWeek && filter.Period != models.AnalyticsPeriodMonth {
+		utils.BadRequestResponse(c, "period debe ser day, week o month")
+		return
+	}
+
+	if loanTypeIDStr := c.Query("loan_type_id"); loanTypeIDStr != "" {
+		loanTypeID, err := strconv.ParseUint(loanTypeIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequestResponse(c, "loan_type_id debe ser un número válido")
+			return
+		}
+		filter.LoanTypeID = uint(loanTypeID)
+	}
+
+	funnel, err := ctrl.analyticsService.GetFunnel(c.Request.Context(), filter)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Analítica obtenida exitosamente", funnel)
+}
@@ -0,0 +1,119 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"loan-api/models"
+	"loan-api/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyticsController_GetFunnel(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería calcular el embudo, las conversiones y los motivos de rechazo", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		c.NoError(DB.Model(&models.Loan{}).Where("id IN ?", []uint{2, 4}).Update("credit_score", 700).Error)
+		c.NoError(DB.Model(&models.Loan{}).Where("id = ?", 3).Updates(map[string]interface{}{
+			"credit_score": 400,
+			"observation":  "Préstamo rechazado: score crediticio insuficiente",
+		}).Error)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "analyst@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		today := time.Now().Format("2006-01-02")
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/admin/analytics/funnel", map[string]interface{}{
+			"from":   today,
+			"to":     today,
+			"period": "month",
+		}, headers)
+		c.Equal(200, w.Code)
+
+		var response struct {
+			Data models.AnalyticsResponse `json:"data"`
+		}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+
+		totals := response.Data.Totals
+		c.Equal(int64(6), totals.Started)
+		c.Equal(int64(3), totals.OnProgress)
+		c.Equal(int64(3), totals.Completed)
+		c.Equal(int64(2), totals.Approved)
+		c.Equal(int64(1), totals.Rejected)
+		c.Equal(0.5, totals.Conversion.StartedToOnProgress)
+		c.Equal(0.6667, totals.Conversion.CompletedToApproved)
+		c.NotNil(totals.AverageScore)
+		c.Equal(600.0, *totals.AverageScore)
+		c.Equal("6500000", totals.AverageApprovedAmount.String())
+
+		c.Len(response.Data.Series, 1)
+		c.Equal(int64(6), response.Data.Series[0].Started)
+
+		c.Len(response.Data.RejectionReasons, 1)
+		c.Equal("Préstamo rechazado: score crediticio insuficiente", response.Data.RejectionReasons[0].Reason)
+		c.Equal(int64(1), response.Data.RejectionReasons[0].Count)
+
+		c.NotEmpty(response.Data.LoanTypes)
+		c.Equal(uint(1), response.Data.LoanTypes[0].LoanTypeID)
+		c.Equal(int64(6), response.Data.LoanTypes[0].Totals.Started)
+	})
+
+	t.Run("Debería incluir en la serie los períodos sin solicitudes", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "admin@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		now := time.Now()
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/admin/analytics/funnel", map[string]interface{}{
+			"from": now.AddDate(0, 0, -6).Format("2006-01-02"),
+			"to":   now.Format("2006-01-02"),
+		}, headers)
+		c.Equal(200, w.Code)
+
+		var response struct {
+			Data models.AnalyticsResponse `json:"data"`
+		}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Len(response.Data.Series, 7)
+		c.Equal(int64(0), response.Data.Series[0].Started)
+		c.Equal(int64(6), response.Data.Series[6].Started)
+	})
+
+	t.Run("Debería validar los parámetros y el rol", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "analyst@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		for _, params := range []map[string]interface{}{
+			{"period": "year"},
+			{"from": "2026-10-20", "to": "2026-10-01"},
+			{"from": "2024-01-01", "to": "2026-01-01"},
+			{"to": "ayer"},
+		} {
+			w := test.MakeGetRequest(APP, "/loan-api/api/v1/admin/analytics/funnel", params, headers)
+			c.Equal(400, w.Code, params)
+		}
+
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/admin/analytics/funnel", map[string]interface{}{"loan_type_id": "99"}, headers)
+		c.Equal(404, w.Code)
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/admin/analytics/funnel", nil, map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		})
+		c.Equal(403, w.Code)
+	})
+}
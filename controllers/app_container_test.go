@@ -0,0 +1,108 @@
+package controllers_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"loan-api/app"
+	"loan-api/config"
+	"loan-api/models"
+	"loan-api/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppContainer_Isolation(t *testing.T) {
+	t.Run("Debería atender instancias con bases de datos distintas en paralelo", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			name := fmt.Sprintf("instancia-%d", i)
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				c := require.New(t)
+
+				cfg := APP.Config
+				cfg.DBDriver = config.DBDriverSQLite
+				cfg.DBName = filepath.Join(t.TempDir(), "loan_api.db")
+
+				isolated, err := test.NewApp(cfg)
+				c.NoError(err)
+				defer isolated.Close()
+
+				// El mismo correo en cada instancia: solo funciona si las bases no se comparten
+				requestBody := map[string]interface{}{
+					"name":                  "Usuario Aislado",
+					"email":                 "aislado@example.com",
+					"phone":                 "3001234567",
+					"document_type":         models.DocumentTypeCedula,
+					"document_number":       "99887766",
+					"password":              "Password123!",
+					"password_confirmation": "Password123!",
+				}
+				w := test.MakePostRequest(isolated, "/loan-api/api/v1/auth/register", requestBody, map[string]string{"X-Tenant-ID": "1"})
+				c.Equal(201, w.Code)
+
+				var users int64
+				c.NoError(isolated.DB.Model(&models.User{}).Count(&users).Error)
+				c.Equal(int64(1), users)
+
+				var shared int64
+				c.NoError(DB.Model(&models.User{}).Where("email = ?", "aislado@example.com").Count(&shared).Error)
+				c.Zero(shared)
+			})
+		}
+	})
+
+	t.Run("Debería validar los tokens con la clave configurada en el contenedor", func(t *testing.T) {
+		c := require.New(t)
+		test.LoadTestData(DB)
+
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		c.NoError(err)
+		publicKey, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+		c.NoError(err)
+
+		cfg := APP.Config
+		cfg.AccessTokenPrivateKey = base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}))
+		cfg.AccessTokenPublicKey = base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))
+		rotated, err := app.NewContainer(cfg, DB)
+		c.NoError(err)
+
+		w := test.MakePostRequest(rotated, "/loan-api/api/v1/auth/login", map[string]interface{}{
+			"email":    "juan@example.com",
+			"password": "password123!",
+		}, map[string]string{"X-Tenant-ID": "1"})
+		c.Equal(200, w.Code)
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		rotatedToken := response["data"].(map[string]interface{})["token"].(string)
+
+		headers := func(token string) map[string]string {
+			return map[string]string{"Authorization": token, "X-Tenant-ID": "1"}
+		}
+		c.Equal(200, test.MakeGetRequest(rotated, "/loan-api/api/v1/loans/user", nil, headers(rotatedToken)).Code)
+		c.Equal(401, test.MakeGetRequest(APP, "/loan-api/api/v1/loans/user", nil, headers(rotatedToken)).Code)
+
+		appToken := loginAndGetToken(t, "juan@example.com", "password123!")
+		c.Equal(401, test.MakeGetRequest(rotated, "/loan-api/api/v1/loans/user", nil, headers(appToken)).Code)
+	})
+
+	t.Run("Debería rechazar un tenant inexistente usando el repositorio del contenedor", func(t *testing.T) {
+		c := require.New(t)
+
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/health-checker", nil, map[string]string{"X-Tenant-ID": "999"})
+		c.Equal(403, w.Code)
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/health-checker", nil, map[string]string{"X-Tenant-ID": "abc"})
+		c.Equal(403, w.Code)
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/health-checker", nil, map[string]string{"X-Tenant-ID": "1"})
+		c.Equal(200, w.Code)
+	})
+}
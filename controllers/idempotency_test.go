@@ -0,0 +1,162 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"loan-api/app_error"
+	"loan-api/middlewares"
+	"loan-api/models"
+	"loan-api/services"
+	"loan-api/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotency(t *testing.T) {
+	c := require.New(t)
+
+	idempotencyHeaders := func(t *testing.T, key string) map[string]string {
+		return map[string]string{
+			"Authorization":                  loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":                    "1",
+			middlewares.IdempotencyKeyHeader: key,
+		}
+	}
+
+	t.Run("Debería crear el préstamo una sola vez y repetir la respuesta en los reintentos", func(t *testing.T) {
+		test.LoadTestData(DB)
+		loans := test.CountLoans(DB)
+		headers := idempotencyHeaders(t, "crear-prestamo-1")
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, headers)
+		c.Equal(201, w.Code)
+		c.Empty(w.Header().Get(middlewares.IdempotentReplayedHeader))
+
+		replay := test.MakePostRequest(APP, "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, headers)
+		c.Equal(201, replay.Code)
+		c.Equal("true", replay.Header().Get(middlewares.IdempotentReplayedHeader))
+		c.JSONEq(w.Body.String(), replay.Body.String())
+		c.Equal(loans+1, test.CountLoans(DB))
+
+		// Sin la clave cada solicitud crea un préstamo
+		delete(headers, middlewares.IdempotencyKeyHeader)
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, headers)
+		c.Equal(201, w.Code)
+		c.Equal(loans+2, test.CountLoans(DB))
+	})
+
+	t.Run("Debería responder 422 si la clave se reutiliza con otro cuerpo", func(t *testing.T) {
+		test.LoadTestData(DB)
+		loans := test.CountLoans(DB)
+		headers := idempotencyHeaders(t, "crear-prestamo-2")
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, headers)
+		c.Equal(201, w.Code)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 2}, headers)
+		c.Equal(422, w.Code)
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Equal(app_error.ErrIdempotencyKeyReused.Message, response["message"])
+		c.Equal(loans+1, test.CountLoans(DB))
+	})
+
+	t.Run("Debería separar las claves por usuario", func(t *testing.T) {
+		test.LoadTestData(DB)
+		loans := test.CountLoans(DB)
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, idempotencyHeaders(t, "misma-clave"))
+		c.Equal(201, w.Code)
+
+		headers := idempotencyHeaders(t, "misma-clave")
+		headers["Authorization"] = loginAndGetToken(t, "maria@example.com", "password123!")
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, headers)
+		c.Equal(201, w.Code)
+		c.Empty(w.Header().Get(middlewares.IdempotentReplayedHeader))
+		c.Equal(loans+2, test.CountLoans(DB))
+	})
+
+	t.Run("Debería responder 409 mientras la solicitud original sigue en curso", func(t *testing.T) {
+		test.LoadTestData(DB)
+		loans := test.CountLoans(DB)
+		headers := idempotencyHeaders(t, "en-curso")
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, headers)
+		c.Equal(201, w.Code)
+		c.NoError(DB.Model(&models.IdempotencyKey{}).Where("idempotency_key = ?", "en-curso").Updates(map[string]interface{}{
+			"status":       models.IdempotencyKeyStatusInProgress,
+			"locked_until": time.Now().Add(time.Minute),
+		}).Error)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, headers)
+		c.Equal(409, w.Code)
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Equal(app_error.ErrIdempotencyKeyInProgress.Message, response["message"])
+		c.Equal(loans+1, test.CountLoans(DB))
+	})
+
+	t.Run("Debería procesar de nuevo una clave vencida", func(t *testing.T) {
+		test.LoadTestData(DB)
+		loans := test.CountLoans(DB)
+		headers := idempotencyHeaders(t, "vencida")
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, headers)
+		c.Equal(201, w.Code)
+		c.NoError(DB.Model(&models.IdempotencyKey{}).Where("idempotency_key = ?", "vencida").
+			Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, headers)
+		c.Equal(201, w.Code)
+		c.Empty(w.Header().Get(middlewares.IdempotentReplayedHeader))
+		c.Equal(loans+2, test.CountLoans(DB))
+	})
+
+	t.Run("Debería no guardar las respuestas 5xx para permitir el reintento", func(t *testing.T) {
+		test.LoadTestData(DB)
+		headers := idempotencyHeaders(t, "falla")
+
+		w := test.MakePostRequest(newFailingApp(t, "GetByID"), "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, headers)
+		c.Equal(500, w.Code)
+
+		var keys int64
+		c.NoError(DB.Model(&models.IdempotencyKey{}).Where("idempotency_key = ?", "falla").Count(&keys).Error)
+		c.Zero(keys)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, headers)
+		c.Equal(201, w.Code)
+	})
+
+	t.Run("Debería repetir la decisión sin volver a desembolsar", func(t *testing.T) {
+		test.LoadTestData(DB)
+		headers := idempotencyHeaders(t, "decision-1")
+		completeLoanForDecision(t, headers, "5000000", "2000000", "2000000")
+
+		disbursement := &countingDisbursementProvider{DisbursementProvider: services.NewSimulatedDisbursementProvider()}
+		container := newLoanApp(t, nil, disbursement)
+
+		w := test.MakePostRequest(container, "/loan-api/api/v1/loans/1/decision", nil, headers)
+		c.Equal(200, w.Code)
+
+		replay := test.MakePostRequest(container, "/loan-api/api/v1/loans/1/decision", nil, headers)
+		c.Equal(200, replay.Code)
+		c.Equal("true", replay.Header().Get(middlewares.IdempotentReplayedHeader))
+		c.JSONEq(w.Body.String(), replay.Body.String())
+		c.Equal(int64(1), disbursement.calls.Load())
+	})
+
+	t.Run("Debería rechazar claves demasiado largas", func(t *testing.T) {
+		test.LoadTestData(DB)
+		key := make([]byte, 256)
+		for i := range key {
+			key[i] = 'a'
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, idempotencyHeaders(t, string(key)))
+		c.Equal(400, w.Code)
+	})
+}
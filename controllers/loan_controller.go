@@ -1,15 +1,24 @@
 package controllers
 
 import (
+	"context"
 	"loan-api/models"
 	"loan-api/services"
 	"loan-api/utils"
-	"log"
+	"log/slog"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Límites de paginación del listado de préstamos del usuario
+const (
+	defaultLoansPageLimit = 20
+	maxLoansPageLimit     = 100
+)
+
 // LoanController maneja las operaciones relacionadas con préstamos
 type LoanController struct {
 	loanService   services.LoanService
@@ -32,15 +41,19 @@ func NewLoanController(loanService services.LoanService, tenantService services.
 // @Produce json
 // @Security BearerAuth
 // @Param X-Tenant-ID header string true "ID del tenant"
+// @Param Idempotency-Key header string false "Clave para reintentar la solicitud sin crear otro préstamo"
 // @Param loan body models.CreateLoanRequest true "Datos de la solicitud de préstamo"
 // @Success 201 {object} utils.APIResponse{data=models.LoanResponse}
 // @Failure 400 {object} utils.APIResponse
 // @Failure 401 {object} utils.APIResponse
 // @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 422 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
 // @Failure 500 {object} utils.APIResponse
 // @Router /loans [post]
 func (ctrl *LoanController) CreateLoan(c *gin.Context) {
-	log.Println("LoanController::CreateLoan was invoked")
+	slog.DebugContext(c.Request.Context(), "LoanController::CreateLoan was invoked")
 
 	var req models.CreateLoanRequest
 
@@ -64,7 +77,7 @@ func (ctrl *LoanController) CreateLoan(c *gin.Context) {
 	}
 
 	// Crear préstamo
-	loanResponse, err := ctrl.loanService.CreateLoan(userID.(uint), req)
+	loanResponse, err := ctrl.loanService.CreateLoan(c.Request.Context(), userID.(uint), req)
 	if err != nil {
 		utils.InternalServerErrorResponse(c, err.Error())
 		return
@@ -87,10 +100,12 @@ func (ctrl *LoanController) CreateLoan(c *gin.Context) {
 // @Failure 400 {object} utils.APIResponse
 // @Failure 401 {object} utils.APIResponse
 // @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
 // @Failure 500 {object} utils.APIResponse
 // @Router /loans/data [post]
 func (ctrl *LoanController) SaveLoanData(c *gin.Context) {
-	log.Println("LoanController::SaveLoanData was invoked")
+	slog.DebugContext(c.Request.Context(), "LoanController::SaveLoanData was invoked")
 
 	var req models.SaveLoanDataRequest
 
@@ -110,8 +125,8 @@ func (ctrl *LoanController) SaveLoanData(c *gin.Context) {
 	}
 
 	// Guardar datos del préstamo
-	if err := ctrl.loanService.SaveLoanData(req); err != nil {
-		utils.InternalServerErrorResponse(c, err.Error())
+	if err := ctrl.loanService.SaveLoanData(c.Request.Context(), req); err != nil {
+		utils.ErrorResponse(c, err)
 		return
 	}
 
@@ -121,21 +136,23 @@ func (ctrl *LoanController) SaveLoanData(c *gin.Context) {
 
 // GetLoan godoc
 // @Summary Obtener información de un préstamo
-// @Description Obtiene la información completa de un préstamo por ID
+// @Description Obtiene la información completa de un préstamo por ID con sus datos tipados en fields
 // @Tags loans
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param X-Tenant-ID header string true "ID del tenant"
 // @Param id path int true "ID del préstamo"
+// @Param include_raw_data query bool false "Incluir la lista cruda de datos (clave/valor)"
 // @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
 // @Failure 400 {object} utils.APIResponse
 // @Failure 401 {object} utils.APIResponse
 // @Failure 404 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
 // @Failure 500 {object} utils.APIResponse
 // @Router /loans/{id} [get]
 func (ctrl *LoanController) GetLoan(c *gin.Context) {
-	log.Println("LoanController::GetLoan was invoked")
+	slog.DebugContext(c.Request.Context(), "LoanController::GetLoan was invoked")
 
 	// Obtener ID del préstamo desde los parámetros
 	loanIDStr := c.Param("id")
@@ -145,33 +162,57 @@ func (ctrl *LoanController) GetLoan(c *gin.Context) {
 		return
 	}
 
+	includeRawData := false
+	if includeRawDataStr := c.Query("include_raw_data"); includeRawDataStr != "" {
+		parsed, err := strconv.ParseBool(includeRawDataStr)
+		if err != nil {
+			utils.BadRequestResponse(c, "include_raw_data debe ser true o false")
+			return
+		}
+		includeRawData = parsed
+	}
+
 	// Obtener préstamo
-	loanResponse, err := ctrl.loanService.GetLoanByID(uint(loanID))
+	loanResponse, err := ctrl.loanService.GetLoanByID(c.Request.Context(), uint(loanID))
 	if err != nil {
 		utils.NotFoundResponse(c, err.Error())
 		return
 	}
 
+	// La lista cruda de datos solo se incluye si se solicita explícitamente
+	if !includeRawData {
+		loanResponse.Data = nil
+	}
+
 	// Retornar respuesta exitosa
 	utils.SuccessResponse(c, 200, "Préstamo obtenido exitosamente", loanResponse)
 }
 
 // GetUserLoans godoc
 // @Summary Obtener préstamos de un usuario
-// @Description Obtiene todos los préstamos de un usuario autenticado
+// @Description Obtiene una página de los préstamos de un usuario autenticado con filtros y ordenamiento
 // @Tags loans
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param X-Tenant-ID header string true "ID del tenant"
-// @Success 200 {object} utils.APIResponse{data=[]models.LoanResponse}
+// @Param include_cancelled query bool false "Incluir préstamos cancelados"
+// @Param page query int false "Número de página (por defecto 1)"
+// @Param limit query int false "Préstamos por página (por defecto 20, máximo 100)"
+// @Param status query string false "Estados separados por coma"
+// @Param created_from query string false "Fecha de creación desde (YYYY-MM-DD)"
+// @Param created_to query string false "Fecha de creación hasta, inclusive (YYYY-MM-DD)"
+// @Param sort_by query string false "Campo de orden: created_at, updated_at, amount_approved"
+// @Param sort_order query string false "Dirección del orden: asc, desc (por defecto desc)"
+// @Success 200 {object} utils.PaginatedResponse{data=[]models.LoanResponse}
 // @Failure 400 {object} utils.APIResponse
 // @Failure 401 {object} utils.APIResponse
 // @Failure 404 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
 // @Failure 500 {object} utils.APIResponse
 // @Router /loans/user [get]
 func (ctrl *LoanController) GetUserLoans(c *gin.Context) {
-	log.Println("LoanController::GetUserLoans was invoked")
+	slog.DebugContext(c.Request.Context(), "LoanController::GetUserLoans was invoked")
 
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -179,14 +220,71 @@ func (ctrl *LoanController) GetUserLoans(c *gin.Context) {
 		return
 	}
 
-	loansResponse, err := ctrl.loanService.GetLoansByUserID(userID.(uint))
+	opts := models.LoanQueryOptions{
+		UserID:    userID.(uint),
+		SortBy:    c.DefaultQuery("sort_by", "created_at"),
+		SortOrder: c.DefaultQuery("sort_order", "desc"),
+		Page:      1,
+		Limit:     defaultLoansPageLimit,
+	}
+
+	if includeCancelledStr := c.Query("include_cancelled"); includeCancelledStr != "" {
+		parsed, err := strconv.ParseBool(includeCancelledStr)
+		if err != nil {
+			utils.BadRequestResponse(c, "include_cancelled debe ser true o false")
+			return
+		}
+		opts.IncludeCancelled = parsed
+	}
+
+	if pageStr := c.Query("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			utils.BadRequestResponse(c, "page debe ser un número mayor o igual a 1")
+			return
+		}
+		opts.Page = page
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxLoansPageLimit {
+			utils.BadRequestResponse(c, "limit debe ser un número entre 1 y "+strconv.Itoa(maxLoansPageLimit))
+			return
+		}
+		opts.Limit = limit
+	}
+
+	statuses, ok := parseStatusesQuery(c)
+	if !ok {
+		return
+	}
+	opts.Statuses = statuses
+
+	createdFrom, createdTo, ok := parseCreatedRangeQuery(c)
+	if !ok {
+		return
+	}
+	opts.CreatedFrom, opts.CreatedTo = createdFrom, createdTo
+
+	if opts.SortBy != "created_at" && opts.SortBy != "updated_at" && opts.SortBy != "amount_approved" {
+		utils.BadRequestResponse(c, "sort_by debe ser created_at, updated_at o amount_approved")
+		return
+	}
+
+	if opts.SortOrder != "asc" && opts.SortOrder != "desc" {
+		utils.BadRequestResponse(c, "sort_order debe ser asc o desc")
+		return
+	}
+
+	loansResponse, total, err := ctrl.loanService.GetLoansByUserID(c.Request.Context(), opts)
 	if err != nil {
 		utils.InternalServerErrorResponse(c, err.Error())
 		return
 	}
 
 	// Retornar respuesta exitosa
-	utils.SuccessResponse(c, 200, "Préstamos obtenidos exitosamente", loansResponse)
+	utils.PaginatedSuccessResponse(c, "Préstamos obtenidos exitosamente", loansResponse, utils.NewPagination(opts.Page, opts.Limit, total))
 }
 
 // ProcessLoanDecision godoc
@@ -197,15 +295,19 @@ func (ctrl *LoanController) GetUserLoans(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param X-Tenant-ID header string true "ID del tenant"
+// @Param Idempotency-Key header string false "Clave para reintentar la decisión sin repetir el desembolso"
 // @Param id path int true "ID del préstamo"
 // @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
 // @Failure 400 {object} utils.APIResponse
 // @Failure 401 {object} utils.APIResponse
 // @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 422 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
 // @Failure 500 {object} utils.APIResponse
 // @Router /loans/{id}/decision [post]
 func (ctrl *LoanController) ProcessLoanDecision(c *gin.Context) {
-	log.Println("LoanController::ProcessLoanDecision was invoked")
+	slog.DebugContext(c.Request.Context(), "LoanController::ProcessLoanDecision was invoked")
 
 	loanIDStr := c.Param("id")
 	loanID, err := strconv.ParseUint(loanIDStr, 10, 32)
@@ -214,11 +316,288 @@ func (ctrl *LoanController) ProcessLoanDecision(c *gin.Context) {
 		return
 	}
 
-	loanResponse, err := ctrl.loanService.ProcessLoanDecision(uint(loanID))
+	loanResponse, err := ctrl.loanService.ProcessLoanDecision(c.Request.Context(), uint(loanID))
 	if err != nil {
-		utils.InternalServerErrorResponse(c, err.Error())
+		utils.ErrorResponse(c, err)
 		return
 	}
 
 	utils.SuccessResponse(c, 200, "Decisión del préstamo procesada exitosamente", loanResponse)
 }
+
+// AcceptOffer godoc
+// @Summary Aceptar una contraoferta
+// @Description Acepta una contraoferta vigente del préstamo, descarta las demás y realiza el desembolso por el monto ofrecido
+// @Tags loans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Param offerId path int true "ID de la contraoferta"
+// @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
+// @Router /loans/{id}/offers/{offerId}/accept [post]
+func (ctrl *LoanController) AcceptOffer(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "LoanController::AcceptOffer was invoked")
+	ctrl.handleOfferResponse(c, ctrl.loanService.AcceptOffer, "Contraoferta aceptada exitosamente")
+}
+
+// DeclineOffer godoc
+// @Summary Rechazar una contraoferta
+// @Description Rechaza una contraoferta vigente del préstamo. Si no quedan contraofertas vigentes el préstamo se rechaza
+// @Tags loans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Param offerId path int true "ID de la contraoferta"
+// @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
+// @Router /loans/{id}/offers/{offerId}/decline [post]
+func (ctrl *LoanController) DeclineOffer(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "LoanController::DeclineOffer was invoked")
+	ctrl.handleOfferResponse(c, ctrl.loanService.DeclineOffer, "Contraoferta rechazada exitosamente")
+}
+
+// handleOfferResponse procesa la respuesta del solicitante a una contraoferta
+func (ctrl *LoanController) handleOfferResponse(c *gin.Context, respond func(ctx context.Context, userID, loanID, offerID uint) (*models.LoanResponse, error), message string) {
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	offerID, err := strconv.ParseUint(c.Param("offerId"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "ID de la contraoferta debe ser un número válido")
+		return
+	}
+
+	loanResponse, err := respond(c.Request.Context(), c.GetUint("user_id"), loanID, uint(offerID))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, message, loanResponse)
+}
+
+// CancelLoan godoc
+// @Summary Cancelar un préstamo
+// @Description Cancela un préstamo del solicitante autenticado antes de su aprobación. El motivo es obligatorio
+// @Tags loans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Param cancellation body models.CancelLoanRequest true "Motivo de la cancelación"
+// @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
+// @Router /loans/{id}/cancel [post]
+func (ctrl *LoanController) CancelLoan(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "LoanController::CancelLoan was invoked")
+
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.CancelLoanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Formato JSON inválido")
+		return
+	}
+
+	loanResponse, err := ctrl.loanService.CancelLoan(c.Request.Context(), c.GetUint("user_id"), loanID, req.Reason)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Préstamo cancelado exitosamente", loanResponse)
+}
+
+// GetLoanForms godoc
+// @Summary Obtener los formularios de un préstamo
+// @Description Obtiene los formularios del préstamo indicando en cada input si es visible y requerido según las respuestas guardadas
+// @Tags loans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Success 200 {object} utils.APIResponse{data=[]models.LoanTypeFormResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
+// @Router /loans/{id}/forms [get]
+func (ctrl *LoanController) GetLoanForms(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "LoanController::GetLoanForms was invoked")
+
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	forms, err := ctrl.loanService.GetLoanForms(c.Request.Context(), c.GetUint("user_id"), loanID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Formularios del préstamo obtenidos exitosamente", forms)
+}
+
+// ReplaceFormData godoc
+// @Summary Reemplazar los datos de un formulario
+// @Description Reemplaza todos los datos de un formulario del préstamo. Los valores que no se envían se eliminan y los demás formularios no se modifican
+// @Tags loans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Param formCode path string true "Código del formulario"
+// @Param data body models.ReplaceFormDataRequest true "Datos del formulario"
+// @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
+// @Router /loans/{id}/forms/{formCode} [put]
+func (ctrl *LoanController) ReplaceFormData(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "LoanController::ReplaceFormData was invoked")
+
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReplaceFormDataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Formato JSON inválido")
+		return
+	}
+
+	loanResponse, err := ctrl.loanService.ReplaceFormData(c.Request.Context(), c.GetUint("user_id"), loanID, c.Param("formCode"), req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Datos del formulario guardados exitosamente", loanResponse)
+}
+
+// PatchFormData godoc
+// @Summary Modificar valores de un formulario
+// @Description Actualiza o inserta los valores enviados y elimina solo los indicados en delete; el resto de datos del préstamo no se modifica
+// @Tags loans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Param formCode path string true "Código del formulario"
+// @Param data body models.PatchFormDataRequest true "Valores a actualizar y a eliminar"
+// @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
+// @Router /loans/{id}/forms/{formCode} [patch]
+func (ctrl *LoanController) PatchFormData(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "LoanController::PatchFormData was invoked")
+
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.PatchFormDataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Formato JSON inválido")
+		return
+	}
+
+	loanResponse, err := ctrl.loanService.PatchFormData(c.Request.Context(), c.GetUint("user_id"), loanID, c.Param("formCode"), req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Datos del formulario actualizados exitosamente", loanResponse)
+}
+
+// parseStatusesQuery obtiene los estados separados por coma del parámetro status; responde 400 si alguno es inválido
+func parseStatusesQuery(c *gin.Context) ([]models.LoanStatus, bool) {
+	statusStr := c.Query("status")
+	if statusStr == "" {
+		return nil, true
+	}
+
+	var statuses []models.LoanStatus
+	for _, value := range strings.Split(statusStr, ",") {
+		status := models.LoanStatus(strings.TrimSpace(value))
+		if !status.IsValid() {
+			utils.BadRequestResponse(c, "status contiene un estado inválido: "+string(status))
+			return nil, false
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, true
+}
+
+// parseCreatedRangeQuery obtiene el rango de creación de los parámetros created_from y created_to (YYYY-MM-DD).
+// La fecha final es inclusiva, por lo que se devuelve el inicio del día siguiente
+func parseCreatedRangeQuery(c *gin.Context) (*time.Time, *time.Time, bool) {
+	var createdFrom, createdTo *time.Time
+
+	if createdFromStr := c.Query("created_from"); createdFromStr != "" {
+		parsed, err := time.Parse("2006-01-02", createdFromStr)
+		if err != nil {
+			utils.BadRequestResponse(c, "created_from debe tener el formato YYYY-MM-DD")
+			return nil, nil, false
+		}
+		createdFrom = &parsed
+	}
+
+	if createdToStr := c.Query("created_to"); createdToStr != "" {
+		parsed, err := time.Parse("2006-01-02", createdToStr)
+		if err != nil {
+			utils.BadRequestResponse(c, "created_to debe tener el formato YYYY-MM-DD")
+			return nil, nil, false
+		}
+		parsed = parsed.AddDate(0, 0, 1)
+		createdTo = &parsed
+	}
+
+	if createdFrom != nil && createdTo != nil && !createdFrom.Before(*createdTo) {
+		utils.BadRequestResponse(c, "created_from no puede ser posterior a created_to")
+		return nil, nil, false
+	}
+
+	return createdFrom, createdTo, true
+}
@@ -2,11 +2,16 @@ package controllers_test
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
 	"testing"
+	"time"
 
+	"loan-api/app_error"
 	"loan-api/models"
 	"loan-api/test"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
@@ -26,7 +31,7 @@ func loginAndGetToken(t *testing.T, email, password string) string {
 	}
 
 	// Hacer login
-	w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/auth/login", loginBody, headers)
+	w := test.MakePostRequest(APP, "/loan-api/api/v1/auth/login", loginBody, headers)
 	c.Equal(200, w.Code)
 
 	// Extraer token de la respuesta
@@ -64,7 +69,7 @@ func TestLoanController_CreateLoan(t *testing.T) {
 		}
 
 		// Realizar la petición POST
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans", requestBody, headers)
 
 		// Verificar código de respuesta exitoso
 		c.Equal(201, w.Code)
@@ -113,7 +118,7 @@ func TestLoanController_CreateLoan(t *testing.T) {
 			"loan_type_id": 1,
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans", requestBody, headers)
 
 		c.Equal(401, w.Code)
 		var response map[string]interface{}
@@ -135,7 +140,7 @@ func TestLoanController_CreateLoan(t *testing.T) {
 			"loan_type_id": 1,
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans", requestBody, headers)
 		c.Equal(403, w.Code)
 
 		var response map[string]interface{}
@@ -160,7 +165,7 @@ func TestLoanController_CreateLoan(t *testing.T) {
 			"loan_type_id": 999,
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans", requestBody, headers)
 
 		c.Equal(500, w.Code)
 
@@ -183,7 +188,7 @@ func TestLoanController_CreateLoan(t *testing.T) {
 
 		requestBody := map[string]interface{}{}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans", requestBody, headers)
 
 		c.Equal(400, w.Code)
 
@@ -208,7 +213,7 @@ func TestLoanController_CreateLoan(t *testing.T) {
 		// JSON inválido
 		invalidJSON := "invalid_json_string"
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans", invalidJSON, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans", invalidJSON, headers)
 
 		c.Equal(400, w.Code)
 
@@ -259,7 +264,7 @@ func TestLoanController_SaveLoanData(t *testing.T) {
 			},
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/data", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/data", requestBody, headers)
 
 		c.Equal(200, w.Code)
 
@@ -288,7 +293,7 @@ func TestLoanController_SaveLoanData(t *testing.T) {
 			},
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/data", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/data", requestBody, headers)
 
 		c.Equal(401, w.Code)
 		var response map[string]interface{}
@@ -318,7 +323,7 @@ func TestLoanController_SaveLoanData(t *testing.T) {
 			},
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/data", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/data", requestBody, headers)
 
 		c.Equal(403, w.Code)
 
@@ -351,7 +356,7 @@ func TestLoanController_SaveLoanData(t *testing.T) {
 			},
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/data", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/data", requestBody, headers)
 
 		c.Equal(400, w.Code)
 
@@ -376,7 +381,7 @@ func TestLoanController_SaveLoanData(t *testing.T) {
 			"loan_id": 1,
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/data", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/data", requestBody, headers)
 
 		c.Equal(400, w.Code)
 
@@ -402,7 +407,7 @@ func TestLoanController_SaveLoanData(t *testing.T) {
 			"data":    []map[string]interface{}{},
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/data", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/data", requestBody, headers)
 
 		c.Equal(400, w.Code)
 
@@ -435,7 +440,7 @@ func TestLoanController_SaveLoanData(t *testing.T) {
 			},
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/data", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/data", requestBody, headers)
 
 		c.Equal(500, w.Code)
 
@@ -458,7 +463,7 @@ func TestLoanController_SaveLoanData(t *testing.T) {
 
 		invalidJSON := "invalid_json_string"
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/data", invalidJSON, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/data", invalidJSON, headers)
 
 		c.Equal(400, w.Code)
 
@@ -537,7 +542,7 @@ func TestLoanController_SaveLoanData(t *testing.T) {
 			},
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/data", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/data", requestBody, headers)
 
 		c.Equal(200, w.Code)
 
@@ -594,7 +599,7 @@ func TestLoanController_ProcessLoanDecision(t *testing.T) {
 		}
 
 		// Guardar datos para completar el préstamo
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/data", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/data", requestBody, headers)
 		c.Equal(200, w.Code)
 
 		// Verificar que el préstamo esté en estado completed
@@ -604,7 +609,7 @@ func TestLoanController_ProcessLoanDecision(t *testing.T) {
 		c.Equal("completed", loan.Status)
 
 		// Ahora procesar la decisión
-		w = test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/1/decision", nil, headers)
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/decision", nil, headers)
 
 		c.Equal(200, w.Code)
 
@@ -614,11 +619,27 @@ func TestLoanController_ProcessLoanDecision(t *testing.T) {
 		c.Equal("Decisión del préstamo procesada exitosamente", response["message"])
 		c.Contains(response, "data")
 
+		// Verificar el desglose de capacidad de pago en la respuesta
+		data := response["data"].(map[string]interface{})
+		c.Contains(data, "affordability")
+		affordability := data["affordability"].(map[string]interface{})
+		c.Equal("3000000", affordability["disposable_income"])
+		c.Equal("0.4", affordability["debt_to_income_ratio"])
+		c.Equal("2000000", affordability["max_monthly_payment"])
+		c.NotEmpty(affordability["max_principal"])
+
 		// Verificar que el préstamo cambió a approved o rejected
 		err = DB.First(&loan, 1).Error
 		c.NoError(err)
 		c.True(loan.Status == "approved" || loan.Status == "rejected")
 		c.NotEmpty(loan.Observation)
+		c.NotEmpty(loan.Affordability)
+
+		// Los préstamos aprobados exponen la tarifa congelada
+		if loan.Status == "approved" {
+			c.Contains(data, "pricing")
+			c.NotNil(loan.GetPricing())
+		}
 	})
 
 	t.Run("Debería fallar sin token de autorización", func(t *testing.T) {
@@ -628,7 +649,7 @@ func TestLoanController_ProcessLoanDecision(t *testing.T) {
 			"X-Tenant-ID": "1",
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/1/decision", nil, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/decision", nil, headers)
 
 		c.Equal(401, w.Code)
 		var response map[string]interface{}
@@ -646,7 +667,7 @@ func TestLoanController_ProcessLoanDecision(t *testing.T) {
 			"X-Tenant-ID":   "1",
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/invalid/decision", nil, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/invalid/decision", nil, headers)
 
 		c.Equal(400, w.Code)
 
@@ -670,7 +691,7 @@ func TestLoanController_ProcessLoanDecision(t *testing.T) {
 			"X-Tenant-ID":   "1",
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/999/decision", nil, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/999/decision", nil, headers)
 
 		c.Equal(500, w.Code)
 
@@ -701,9 +722,9 @@ func TestLoanController_ProcessLoanDecision(t *testing.T) {
 		c.Equal("pending", loan.Status)
 
 		// Intentar procesar decisión sin completar el préstamo
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/1/decision", nil, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/decision", nil, headers)
 
-		c.Equal(500, w.Code)
+		c.Equal(409, w.Code)
 
 		var response map[string]interface{}
 		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
@@ -712,7 +733,7 @@ func TestLoanController_ProcessLoanDecision(t *testing.T) {
 		c.Contains(response, "message")
 		errorData := response["error"].(map[string]interface{})
 		c.Contains(errorData, "message")
-		c.Contains(errorData["message"], "solo se pueden evaluar préstamos en estado completado")
+		c.Equal(app_error.ErrLoanNotDecidable.Message, errorData["message"])
 	})
 
 	t.Run("Debería fallar con préstamo en estado on_progress", func(t *testing.T) {
@@ -736,7 +757,7 @@ func TestLoanController_ProcessLoanDecision(t *testing.T) {
 		}
 
 		// Guardar datos parciales
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/data", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/data", requestBody, headers)
 		c.Equal(200, w.Code)
 
 		// Verificar que el préstamo esté en estado on_progress
@@ -746,9 +767,9 @@ func TestLoanController_ProcessLoanDecision(t *testing.T) {
 		c.Equal("on_progress", loan.Status)
 
 		// Intentar procesar decisión con préstamo incompleto
-		w = test.MakePostRequest(CONFIG, "/loan-api/api/v1/loans/1/decision", nil, headers)
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/decision", nil, headers)
 
-		c.Equal(500, w.Code)
+		c.Equal(409, w.Code)
 
 		var response map[string]interface{}
 		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
@@ -757,6 +778,908 @@ func TestLoanController_ProcessLoanDecision(t *testing.T) {
 		c.Contains(response, "message")
 		errorData := response["error"].(map[string]interface{})
 		c.Contains(errorData, "message")
-		c.Contains(errorData["message"], "solo se pueden evaluar préstamos en estado completado")
+		c.Equal(app_error.ErrLoanNotDecidable.Message, errorData["message"])
+	})
+}
+
+// setLoanWithOffers deja un préstamo de prueba con contraofertas pendientes
+func setLoanWithOffers(t *testing.T, loanID uint, expiresAt time.Time) []models.LoanOffer {
+	c := require.New(t)
+
+	err := DB.Model(&models.Loan{}).Where("id = ?", loanID).Updates(map[string]interface{}{
+		"status":       string(models.LoanStatusOfferPending),
+		"credit_score": 720,
+		"observation":  "Monto solicitado excede la capacidad de pago",
+	}).Error
+	c.NoError(err)
+
+	offers := []models.LoanOffer{
+		{LoanID: loanID, Amount: decimal.NewFromInt(3000000), TermMonths: 24, MonthlyPayment: decimal.NewFromInt(158000), Status: models.LoanOfferStatusPending, ExpiresAt: expiresAt},
+		{LoanID: loanID, Amount: decimal.NewFromInt(4000000), TermMonths: 36, MonthlyPayment: decimal.NewFromInt(157000), Status: models.LoanOfferStatusPending, ExpiresAt: expiresAt},
+	}
+	c.NoError(DB.Create(&offers).Error)
+	return offers
+}
+
+func TestLoanController_Offers(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería aceptar una contraoferta y desembolsar el monto ofrecido", func(t *testing.T) {
+		test.LoadTestData(DB)
+		offers := setLoanWithOffers(t, 1, time.Now().Add(72*time.Hour))
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, fmt.Sprintf("/loan-api/api/v1/loans/1/offers/%d/accept", offers[1].ID), nil, headers)
+		c.Equal(200, w.Code)
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("approved", loan.Status)
+		c.True(loan.AmountApproved.Equal(decimal.NewFromInt(4000000)))
+
+		// La tarifa queda congelada con el plazo de la contraoferta aceptada
+		pricing := loan.GetPricing()
+		c.NotNil(pricing)
+		c.Equal("A", pricing.Tier)
+		c.Equal(36, pricing.TermMonths)
+		c.True(pricing.EffectiveAnnualRate.GreaterThan(pricing.NominalAnnualRate))
+		c.True(pricing.APR.GreaterThan(pricing.NominalAnnualRate))
+		c.False(pricing.CappedByUsury)
+
+		var accepted, declined models.LoanOffer
+		c.NoError(DB.First(&accepted, offers[1].ID).Error)
+		c.NoError(DB.First(&declined, offers[0].ID).Error)
+		c.Equal(models.LoanOfferStatusAccepted, accepted.Status)
+		c.Equal(models.LoanOfferStatusDeclined, declined.Status)
+	})
+
+	t.Run("Debería ofrecer la cuota que se cobra al aceptar la contraoferta", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		// Un monto mayor a la capacidad de pago, pero menor al umbral de revisión manual, genera contraofertas
+		completeLoanForDecision(t, headers, "1500000", "1200000", "7000000")
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/decision", nil, headers)
+		c.Equal(200, w.Code)
+
+		var offers []models.LoanOffer
+		c.NoError(DB.Where("loan_id = ?", 1).Order("term_months").Find(&offers).Error)
+		c.NotEmpty(offers)
+		offer := offers[len(offers)-1]
+
+		w = test.MakePostRequest(APP, fmt.Sprintf("/loan-api/api/v1/loans/1/offers/%d/accept", offer.ID), nil, headers)
+		c.Equal(200, w.Code)
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		pricing := loan.GetPricing()
+		c.NotNil(pricing)
+		c.Equal(offer.TermMonths, pricing.TermMonths)
+		c.True(offer.MonthlyPayment.Equal(pricing.TotalMonthlyPayment), "ofrecida %s, cobrada %s", offer.MonthlyPayment, pricing.TotalMonthlyPayment)
+	})
+
+	t.Run("Debería limitar a la usura el costo efectivo con comisión y seguro", func(t *testing.T) {
+		// Tramo D: su tasa nominal ya supera la usura (27,62% EA). Tramo C: solo la supera con comisión y seguro
+		for _, tc := range []struct {
+			score       int
+			tier        string
+			nominalRate float64
+		}{
+			{score: 450, tier: "D", nominalRate: 0.27},
+			{score: 550, tier: "C", nominalRate: 0.235},
+		} {
+			test.LoadTestData(DB)
+			offers := setLoanWithOffers(t, 1, time.Now().Add(72*time.Hour))
+			c.NoError(DB.Model(&models.Loan{}).Where("id = ?", 1).Update("credit_score", tc.score).Error)
+
+			headers := map[string]string{
+				"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+				"X-Tenant-ID":   "1",
+			}
+			w := test.MakePostRequest(APP, fmt.Sprintf("/loan-api/api/v1/loans/1/offers/%d/accept", offers[1].ID), nil, headers)
+			c.Equal(200, w.Code)
+
+			var loan models.Loan
+			c.NoError(DB.First(&loan, 1).Error)
+			pricing := loan.GetPricing()
+			c.NotNil(pricing)
+			c.Equal(tc.tier, pricing.Tier)
+			c.True(pricing.CappedByUsury, tc.tier)
+			c.True(pricing.NominalAnnualRate.LessThan(decimal.NewFromFloat(tc.nominalRate)), tc.tier)
+
+			// La APR es la tasa mensual con comisión y seguro por 12: su equivalente efectivo anual no supera la usura
+			monthlyAllIn, _ := pricing.APR.Div(decimal.NewFromInt(12)).Float64()
+			c.LessOrEqual(math.Pow(1+monthlyAllIn, 12)-1, 0.2762+1e-6, tc.tier)
+		}
+	})
+
+	t.Run("Debería rechazar el préstamo al declinar todas las contraofertas", func(t *testing.T) {
+		test.LoadTestData(DB)
+		offers := setLoanWithOffers(t, 1, time.Now().Add(72*time.Hour))
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, fmt.Sprintf("/loan-api/api/v1/loans/1/offers/%d/decline", offers[0].ID), nil, headers)
+		c.Equal(200, w.Code)
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("offer_pending", loan.Status)
+
+		w = test.MakePostRequest(APP, fmt.Sprintf("/loan-api/api/v1/loans/1/offers/%d/decline", offers[1].ID), nil, headers)
+		c.Equal(200, w.Code)
+
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("rejected", loan.Status)
+	})
+
+	t.Run("Debería fallar al aceptar una contraoferta vencida", func(t *testing.T) {
+		test.LoadTestData(DB)
+		offers := setLoanWithOffers(t, 1, time.Now().Add(-time.Hour))
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, fmt.Sprintf("/loan-api/api/v1/loans/1/offers/%d/accept", offers[0].ID), nil, headers)
+		c.Equal(409, w.Code)
+	})
+
+	t.Run("Debería fallar al aceptar una contraoferta de otro usuario", func(t *testing.T) {
+		test.LoadTestData(DB)
+		offers := setLoanWithOffers(t, 1, time.Now().Add(72*time.Hour))
+
+		token := loginAndGetToken(t, "maria@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, fmt.Sprintf("/loan-api/api/v1/loans/1/offers/%d/accept", offers[0].ID), nil, headers)
+		c.Equal(403, w.Code)
+	})
+}
+
+func TestLoanController_CancelLoan(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería cancelar un préstamo pendiente y excluirlo del listado", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/cancel", map[string]interface{}{
+			"reason": "Ya no necesito el crédito",
+		}, headers)
+		c.Equal(200, w.Code)
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("cancelled", loan.Status)
+		c.Equal("Ya no necesito el crédito", loan.CancellationReason)
+		c.NotNil(loan.CancelledBy)
+		c.Equal(uint(1), *loan.CancelledBy)
+		c.Equal(models.CancellerRoleApplicant, loan.CancelledByRole)
+
+		// La respuesta y el detalle del préstamo exponen los datos de la cancelación
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		data := response["data"].(map[string]interface{})
+		c.Equal(float64(1), data["cancelled_by"])
+		c.Equal("applicant", data["cancelled_by_role"])
+		c.Equal("Ya no necesito el crédito", data["cancellation_reason"])
+		c.NotEmpty(data["cancelled_at"])
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/loans/1", nil, headers)
+		c.Equal(200, w.Code)
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		data = response["data"].(map[string]interface{})
+		c.Equal(float64(1), data["cancelled_by"])
+		c.Equal("applicant", data["cancelled_by_role"])
+		c.Equal("Ya no necesito el crédito", data["cancellation_reason"])
+		c.NotEmpty(data["cancelled_at"])
+
+		// Por defecto el listado excluye los préstamos cancelados
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/loans/user", nil, headers)
+		c.Equal(200, w.Code)
+
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		for _, item := range response["data"].([]interface{}) {
+			c.NotEqual(float64(1), item.(map[string]interface{})["id"])
+		}
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/loans/user", map[string]interface{}{"include_cancelled": "true"}, headers)
+		c.Equal(200, w.Code)
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Len(response["data"], 2)
+	})
+
+	t.Run("Debería impedir reabrir un préstamo cancelado", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/cancel", map[string]interface{}{
+			"reason": "Cambio de planes",
+		}, headers)
+		c.Equal(200, w.Code)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/data", map[string]interface{}{
+			"loan_id": 1,
+			"data": []map[string]interface{}{
+				{"form_id": 1, "key": "full_name", "value": "Juan Pérez", "index": 0},
+			},
+		}, headers)
+		c.Equal(409, w.Code)
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("cancelled", loan.Status)
+	})
+
+	t.Run("Debería fallar al cancelar un préstamo aprobado", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "maria@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/2/cancel", map[string]interface{}{
+			"reason": "Ya no lo necesito",
+		}, headers)
+		c.Equal(409, w.Code)
+	})
+
+	t.Run("Debería fallar al cancelar un préstamo de otro usuario", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "maria@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/cancel", map[string]interface{}{
+			"reason": "Cancelación no autorizada",
+		}, headers)
+		c.Equal(403, w.Code)
+	})
+}
+
+func TestLoanController_FormData(t *testing.T) {
+	c := require.New(t)
+
+	personalInfo := map[string]interface{}{
+		"data": []map[string]interface{}{
+			{"key": "full_name", "value": "Juan Pérez", "index": 0},
+			{"key": "document_type", "value": "cedula", "index": 0},
+			{"key": "document_number", "value": "12345678", "index": 0},
+			{"key": "age", "value": "30", "index": 0},
+		},
+	}
+
+	t.Run("Debería reemplazar un formulario sin afectar los demás", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/personal_info", personalInfo, headers)
+		c.Equal(200, w.Code)
+
+		var fullName models.LoanData
+		c.NoError(DB.Where(map[string]interface{}{"loan_id": 1, "key": "full_name"}).First(&fullName).Error)
+
+		w = test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/financial_info", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "monthly_income", "value": "5000000", "index": 0},
+				{"key": "monthly_expenses", "value": "2000000", "index": 0},
+			},
+		}, headers)
+		c.Equal(200, w.Code)
+
+		// Los datos del otro formulario se conservan con el mismo ID
+		var count int64
+		DB.Model(&models.LoanData{}).Where("loan_id = ?", 1).Count(&count)
+		c.Equal(int64(6), count)
+
+		var sameFullName models.LoanData
+		c.NoError(DB.Where(map[string]interface{}{"loan_id": 1, "key": "full_name"}).First(&sameFullName).Error)
+		c.Equal(fullName.ID, sameFullName.ID)
+
+		// Reemplazar el formulario elimina los valores que no se envían
+		w = test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/financial_info", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "monthly_income", "value": "6000000", "index": 0},
+			},
+		}, headers)
+		c.Equal(200, w.Code)
+
+		DB.Model(&models.LoanData{}).Where("loan_id = ?", 1).Count(&count)
+		c.Equal(int64(5), count)
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("on_progress", loan.Status)
+	})
+
+	t.Run("Debería combinar valores y eliminar solo los indicados", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/personal_info", personalInfo, headers)
+		c.Equal(200, w.Code)
+
+		w = test.MakeRequest("PATCH", APP, "/loan-api/api/v1/loans/1/forms/personal_info", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "age", "value": "31", "index": 0},
+			},
+			"delete": []map[string]interface{}{
+				{"key": "full_name", "index": 0},
+			},
+		}, headers)
+		c.Equal(200, w.Code)
+
+		var age models.LoanData
+		c.NoError(DB.Where(map[string]interface{}{"loan_id": 1, "key": "age"}).First(&age).Error)
+		c.Equal("31", age.Value)
+
+		var count int64
+		DB.Model(&models.LoanData{}).Where(map[string]interface{}{"loan_id": 1, "key": "full_name"}).Count(&count)
+		c.Equal(int64(0), count)
+
+		DB.Model(&models.LoanData{}).Where("loan_id = ?", 1).Count(&count)
+		c.Equal(int64(3), count)
+	})
+
+	t.Run("Debería pasar a completed al completar todos los formularios", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/personal_info", personalInfo, headers)
+		c.Equal(200, w.Code)
+
+		w = test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/financial_info", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "monthly_income", "value": "5000000", "index": 0},
+				{"key": "monthly_expenses", "value": "2000000", "index": 0},
+			},
+		}, headers)
+		c.Equal(200, w.Code)
+
+		w = test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/loan_details", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "requested_amount", "value": "2000000", "index": 0},
+				{"key": "purpose", "value": "Educación", "index": 0},
+			},
+		}, headers)
+		c.Equal(200, w.Code)
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("completed", loan.Status)
+		c.NotNil(loan.CreditScore)
+		c.NotEmpty(loan.IdentityMatch)
+	})
+
+	t.Run("Debería fallar con formulario inexistente o campo ajeno al formulario", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/unknown_form", personalInfo, headers)
+		c.Equal(404, w.Code)
+
+		w = test.MakeRequest("PATCH", APP, "/loan-api/api/v1/loans/1/forms/financial_info", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "full_name", "value": "Juan Pérez", "index": 0},
+			},
+		}, headers)
+		c.Equal(400, w.Code)
+	})
+}
+
+func TestLoanController_RepeatingGroups(t *testing.T) {
+	c := require.New(t)
+
+	reference := func(index int, name, phone string) []map[string]interface{} {
+		return []map[string]interface{}{
+			{"key": "reference_name", "value": name, "index": index},
+			{"key": "reference_phone", "value": phone, "index": index},
+		}
+	}
+
+	t.Run("Debería guardar varios elementos y devolverlos agrupados", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		items := append(reference(0, "Pedro Gómez", "3001234567"), reference(1, "Lucía Ruiz", "3109876543")...)
+		w := test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/references", map[string]interface{}{"data": items}, headers)
+		c.Equal(200, w.Code)
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+
+		data := response["data"].(map[string]interface{})
+		groups := data["groups"].(map[string]interface{})
+		references := groups["references"].([]interface{})
+		c.Len(references, 2)
+		c.Equal("Pedro Gómez", references[0].(map[string]interface{})["reference_name"])
+		c.Equal("3109876543", references[1].(map[string]interface{})["reference_phone"])
+		c.Empty(data["data"])
+	})
+
+	t.Run("Debería fallar con índices no consecutivos o fuera de límite", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		items := append(reference(0, "Pedro Gómez", "3001234567"), reference(2, "Lucía Ruiz", "3109876543")...)
+		w := test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/references", map[string]interface{}{"data": items}, headers)
+		c.Equal(400, w.Code)
+
+		items = nil
+		for i := 0; i < 4; i++ {
+			items = append(items, reference(i, "Referencia", "3001234567")...)
+		}
+		w = test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/references", map[string]interface{}{"data": items}, headers)
+		c.Equal(400, w.Code)
+
+		// Un formulario no repetible solo admite el índice 0
+		w = test.MakeRequest("PATCH", APP, "/loan-api/api/v1/loans/1/forms/personal_info", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "full_name", "value": "Juan Pérez", "index": 1},
+			},
+		}, headers)
+		c.Equal(400, w.Code)
+	})
+
+	t.Run("Debería exigir los campos requeridos de cada elemento", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		forms := map[string][]map[string]interface{}{
+			"personal_info": {
+				{"key": "full_name", "value": "Juan Pérez", "index": 0},
+				{"key": "document_type", "value": "cedula", "index": 0},
+				{"key": "document_number", "value": "12345678", "index": 0},
+				{"key": "age", "value": "30", "index": 0},
+			},
+			"financial_info": {
+				{"key": "monthly_income", "value": "5000000", "index": 0},
+				{"key": "monthly_expenses", "value": "2000000", "index": 0},
+			},
+			"loan_details": {
+				{"key": "requested_amount", "value": "2000000", "index": 0},
+				{"key": "purpose", "value": "Educación", "index": 0},
+			},
+			"references": append(reference(0, "Pedro Gómez", "3001234567"), map[string]interface{}{
+				"key": "reference_name", "value": "Lucía Ruiz", "index": 1,
+			}),
+		}
+		// Las referencias van antes de loan_details para que la solicitud siga editable
+		for _, formCode := range []string{"personal_info", "financial_info", "references", "loan_details"} {
+			w := test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/"+formCode, map[string]interface{}{"data": forms[formCode]}, headers)
+			c.Equal(200, w.Code)
+		}
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("on_progress", loan.Status)
+
+		// Completar el teléfono del segundo elemento deja la solicitud completa
+		w := test.MakeRequest("PATCH", APP, "/loan-api/api/v1/loans/1/forms/references", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "reference_phone", "value": "3109876543", "index": 1},
+			},
+		}, headers)
+		c.Equal(200, w.Code)
+
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("completed", loan.Status)
+	})
+}
+
+func TestLoanController_GetLoan(t *testing.T) {
+	c := require.New(t)
+
+	saveForms := func(t *testing.T, headers map[string]string) {
+		forms := map[string][]map[string]interface{}{
+			"personal_info": {
+				{"key": "full_name", "value": "Juan Pérez", "index": 0},
+				{"key": "document_type", "value": "cedula", "index": 0},
+				{"key": "document_number", "value": "12345678", "index": 0},
+				{"key": "age", "value": "30", "index": 0},
+			},
+			"loan_details": {
+				{"key": "requested_amount", "value": "2000000.50", "index": 0},
+				{"key": "purpose", "value": "Educación", "index": 0},
+			},
+			"references": {
+				{"key": "reference_name", "value": "Pedro Gómez", "index": 0},
+				{"key": "reference_phone", "value": "3001234567", "index": 0},
+			},
+		}
+		for formCode, items := range forms {
+			w := test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/"+formCode, map[string]interface{}{"data": items}, headers)
+			require.Equal(t, 200, w.Code)
+		}
+	}
+
+	t.Run("Debería devolver los datos tipados por formulario e input", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+		saveForms(t, headers)
+
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/loans/1", nil, headers)
+		c.Equal(200, w.Code)
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+
+		data := response["data"].(map[string]interface{})
+		_, hasRawData := data["data"]
+		c.False(hasRawData)
+
+		fields := data["fields"].(map[string]interface{})
+		personalInfo := fields["personal_info"].(map[string]interface{})
+		c.Equal("30", personalInfo["age"])
+		c.Equal("Juan Pérez", personalInfo["full_name"])
+
+		documentType := personalInfo["document_type"].(map[string]interface{})
+		c.Equal("cedula", documentType["value"])
+		c.Equal("cedula", documentType["label"])
+
+		loanDetails := fields["loan_details"].(map[string]interface{})
+		c.Equal("2000000.5", loanDetails["requested_amount"])
+
+		references := fields["references"].([]interface{})
+		c.Len(references, 1)
+		c.Equal("Pedro Gómez", references[0].(map[string]interface{})["reference_name"])
+	})
+
+	t.Run("Debería incluir la lista cruda cuando se solicita", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+		saveForms(t, headers)
+
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/loans/1", map[string]interface{}{"include_raw_data": "true"}, headers)
+		c.Equal(200, w.Code)
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+
+		data := response["data"].(map[string]interface{})
+		c.Len(data["data"], 6)
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/loans/1", map[string]interface{}{"include_raw_data": "quizas"}, headers)
+		c.Equal(400, w.Code)
+	})
+}
+
+func TestLoanController_ConditionalInputs(t *testing.T) {
+	c := require.New(t)
+
+	completeForms := func(t *testing.T, headers map[string]string) {
+		forms := map[string][]map[string]interface{}{
+			"personal_info": {
+				{"key": "full_name", "value": "Juan Pérez", "index": 0},
+				{"key": "document_type", "value": "cedula", "index": 0},
+				{"key": "document_number", "value": "12345678", "index": 0},
+				{"key": "age", "value": "30", "index": 0},
+			},
+			"financial_info": {
+				{"key": "monthly_income", "value": "5000000", "index": 0},
+				{"key": "monthly_expenses", "value": "2000000", "index": 0},
+			},
+		}
+		for formCode, items := range forms {
+			w := test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/"+formCode, map[string]interface{}{"data": items}, headers)
+			require.Equal(t, 200, w.Code)
+		}
+	}
+
+	t.Run("Debería rechazar valores de inputs ocultos", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/loan_details", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "requested_amount", "value": "2000000", "index": 0},
+				{"key": "purpose", "value": "Educación", "index": 0},
+				{"key": "vehicle_plate", "value": "ABC123", "index": 0},
+			},
+		}, headers)
+		c.Equal(400, w.Code)
+	})
+
+	t.Run("Debería exigir el input condicional solo cuando aplica", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+		w := test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/1/forms/loan_details", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "requested_amount", "value": "2000000", "index": 0},
+				{"key": "purpose", "value": "Vehículo", "index": 0},
+				{"key": "vehicle_plate", "value": "ABC123", "index": 0},
+			},
+		}, headers)
+		c.Equal(200, w.Code)
+
+		// Al cambiar el propósito la placa deja de aplicar y se elimina
+		w = test.MakeRequest("PATCH", APP, "/loan-api/api/v1/loans/1/forms/loan_details", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "purpose", "value": "Educación", "index": 0},
+			},
+		}, headers)
+		c.Equal(200, w.Code)
+
+		var count int64
+		DB.Model(&models.LoanData{}).Where(map[string]interface{}{"loan_id": 1, "key": "vehicle_plate"}).Count(&count)
+		c.Equal(int64(0), count)
+
+		// Con propósito vehículo la placa es requerida para completar la solicitud
+		w = test.MakeRequest("PATCH", APP, "/loan-api/api/v1/loans/1/forms/loan_details", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "purpose", "value": "Vehículo", "index": 0},
+			},
+		}, headers)
+		c.Equal(200, w.Code)
+		completeForms(t, headers)
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("on_progress", loan.Status)
+
+		w = test.MakeRequest("PATCH", APP, "/loan-api/api/v1/loans/1/forms/loan_details", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "vehicle_plate", "value": "ABC123", "index": 0},
+			},
+		}, headers)
+		c.Equal(200, w.Code)
+
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("completed", loan.Status)
+	})
+
+	t.Run("Debería evaluar las condiciones al servir los formularios del préstamo", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		findInput := func(formCode, inputCode string) map[string]interface{} {
+			w := test.MakeGetRequest(APP, "/loan-api/api/v1/loans/1/forms", nil, headers)
+			c.Equal(200, w.Code)
+
+			var response map[string]interface{}
+			c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+			for _, form := range response["data"].([]interface{}) {
+				form := form.(map[string]interface{})
+				if form["code"] != formCode {
+					continue
+				}
+				for _, input := range form["form_inputs"].([]interface{}) {
+					input := input.(map[string]interface{})
+					if input["code"] == inputCode {
+						return input
+					}
+				}
+			}
+			return nil
+		}
+
+		employerName := findInput("financial_info", "employer_name")
+		c.NotNil(employerName)
+		c.Equal(false, employerName["visible"])
+		c.NotNil(employerName["visible_when"])
+
+		w := test.MakeRequest("PATCH", APP, "/loan-api/api/v1/loans/1/forms/financial_info", map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "employment_type", "value": "employee", "index": 0},
+			},
+		}, headers)
+		c.Equal(200, w.Code)
+
+		employerName = findInput("financial_info", "employer_name")
+		c.Equal(true, employerName["visible"])
+		c.Equal(true, employerName["required"])
+	})
+}
+
+func TestLoanController_GetUserLoans(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería paginar y ordenar los préstamos del usuario", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/loans/user", nil, headers)
+		c.Equal(200, w.Code)
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Len(response["data"], 2)
+		pagination := response["pagination"].(map[string]interface{})
+		c.Equal(float64(1), pagination["page"])
+		c.Equal(float64(20), pagination["limit"])
+		c.Equal(float64(2), pagination["total"])
+		c.Equal(false, pagination["has_next"])
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/loans/user", map[string]interface{}{
+			"page":       "2",
+			"limit":      "1",
+			"sort_by":    "amount_approved",
+			"sort_order": "asc",
+		}, headers)
+		c.Equal(200, w.Code)
+
+		response = map[string]interface{}{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		data := response["data"].([]interface{})
+		c.Len(data, 1)
+		c.Equal(float64(6), data[0].(map[string]interface{})["id"])
+		pagination = response["pagination"].(map[string]interface{})
+		c.Equal(float64(2), pagination["total_pages"])
+		c.Equal(true, pagination["has_prev"])
+		c.Equal(false, pagination["has_next"])
+	})
+
+	t.Run("Debería filtrar por estado y fecha de creación", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		var response map[string]interface{}
+
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/loans/user", map[string]interface{}{"status": "approved"}, headers)
+		c.Equal(200, w.Code)
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Len(response["data"], 0)
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/loans/user", map[string]interface{}{"status": "pending,approved"}, headers)
+		c.Equal(200, w.Code)
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Len(response["data"], 2)
+
+		today := time.Now().Format("2006-01-02")
+		tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/loans/user", map[string]interface{}{"created_to": today}, headers)
+		c.Equal(200, w.Code)
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Len(response["data"], 2)
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/loans/user", map[string]interface{}{"created_from": tomorrow}, headers)
+		c.Equal(200, w.Code)
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Len(response["data"], 0)
+	})
+
+	t.Run("Debería rechazar parámetros de listado inválidos", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		for _, params := range []map[string]interface{}{
+			{"page": "0"},
+			{"limit": "101"},
+			{"status": "unknown"},
+			{"sort_by": "status"},
+			{"sort_order": "up"},
+			{"created_from": "18/10/2026"},
+			{"created_from": "2026-10-20", "created_to": "2026-10-01"},
+		} {
+			w := test.MakeGetRequest(APP, "/loan-api/api/v1/loans/user", params, headers)
+			c.Equal(400, w.Code, params)
+		}
 	})
 }
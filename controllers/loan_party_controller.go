@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"loan-api/models"
+	"loan-api/services"
+	"loan-api/utils"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoanPartyController maneja las operaciones de codeudores y garantes de un préstamo
+type LoanPartyController struct {
+	loanPartyService services.LoanPartyService
+}
+
+// NewLoanPartyController crea una nueva instancia del controlador de codeudores y garantes
+func NewLoanPartyController(loanPartyService services.LoanPartyService) *LoanPartyController {
+	return &LoanPartyController{
+		loanPartyService: loanPartyService,
+	}
+}
+
+// GetParties godoc
+// @Summary Obtener los participantes de un préstamo
+// @Description Lista el solicitante principal, codeudores y garantes del préstamo con su estado, score e identidad
+// @Tags loan-parties
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Success 200 {object} utils.APIResponse{data=[]models.LoanPartyResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
+// @Router /loans/{id}/parties [get]
+func (ctrl *LoanPartyController) GetParties(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "LoanPartyController::GetParties was invoked")
+
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	parties, err := ctrl.loanPartyService.GetParties(c.Request.Context(), c.GetUint("user_id"), loanID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Participantes obtenidos exitosamente", parties)
+}
+
+// InviteParty godoc
+// @Summary Invitar un codeudor o garante
+// @Description El solicitante principal invita a otro usuario registrado del tenant a participar en el préstamo
+// @Tags loan-parties
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Param invitation body models.InviteLoanPartyRequest true "Email del invitado y rol"
+// @Success 201 {object} utils.APIResponse{data=models.LoanPartyResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
+// @Router /loans/{id}/parties [post]
+func (ctrl *LoanPartyController) InviteParty(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "LoanPartyController::InviteParty was invoked")
+
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.InviteLoanPartyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Formato JSON inválido")
+		return
+	}
+
+	party, err := ctrl.loanPartyService.InviteParty(c.Request.Context(), c.GetUint("user_id"), loanID, req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 201, "Invitación enviada exitosamente", party)
+}
+
+// AcceptInvitation godoc
+// @Summary Aceptar una invitación a un préstamo
+// @Description El invitado otorga su consentimiento y declara sus datos; se consulta su score y se verifica su identidad
+// @Tags loan-parties
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Param acceptance body models.AcceptLoanPartyRequest true "Consentimiento y datos del invitado"
+// @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
+// @Router /loans/{id}/parties/accept [post]
+func (ctrl *LoanPartyController) AcceptInvitation(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "LoanPartyController::AcceptInvitation was invoked")
+
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AcceptLoanPartyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Formato JSON inválido")
+		return
+	}
+
+	loanResponse, err := ctrl.loanPartyService.AcceptInvitation(c.Request.Context(), c.GetUint("user_id"), loanID, req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Invitación aceptada exitosamente", loanResponse)
+}
+
+// DeclineInvitation godoc
+// @Summary Rechazar una invitación a un préstamo
+// @Description El invitado rechaza participar en el préstamo
+// @Tags loan-parties
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
+// @Router /loans/{id}/parties/decline [post]
+func (ctrl *LoanPartyController) DeclineInvitation(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "LoanPartyController::DeclineInvitation was invoked")
+
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	loanResponse, err := ctrl.loanPartyService.DeclineInvitation(c.Request.Context(), c.GetUint("user_id"), loanID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Invitación rechazada exitosamente", loanResponse)
+}
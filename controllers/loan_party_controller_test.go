@@ -0,0 +1,239 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"loan-api/models"
+	"loan-api/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+// completeLoanForms completa los formularios requeridos de un préstamo de prueba
+func completeLoanForms(t *testing.T, loanID string, headers map[string]string) {
+	forms := map[string][]map[string]interface{}{
+		"personal_info": {
+			{"key": "full_name", "value": "Juan Pérez", "index": 0},
+			{"key": "document_type", "value": "cedula", "index": 0},
+			{"key": "document_number", "value": "12345678", "index": 0},
+			{"key": "age", "value": "30", "index": 0},
+		},
+		"financial_info": {
+			{"key": "monthly_income", "value": "5000000", "index": 0},
+			{"key": "monthly_expenses", "value": "2000000", "index": 0},
+		},
+		"loan_details": {
+			{"key": "requested_amount", "value": "2000000", "index": 0},
+			{"key": "purpose", "value": "Educación", "index": 0},
+		},
+	}
+	for formCode, items := range forms {
+		w := test.MakeRequest("PUT", APP, "/loan-api/api/v1/loans/"+loanID+"/forms/"+formCode, map[string]interface{}{"data": items}, headers)
+		require.Equal(t, 200, w.Code)
+	}
+}
+
+// acceptInvitationRequest construye la aceptación de una invitación con los datos registrados del usuario
+func acceptInvitationRequest(t *testing.T, userID uint) map[string]interface{} {
+	var user models.User
+	require.NoError(t, DB.First(&user, userID).Error)
+
+	return map[string]interface{}{
+		"consent":          true,
+		"full_name":        user.Name,
+		"document_type":    string(user.DocumentType),
+		"document_number":  user.DocumentNumber,
+		"monthly_income":   "3000000",
+		"monthly_expenses": "1000000",
+	}
+}
+
+func TestLoanPartyController_Invitations(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería invitar y aceptar un codeudor que ve el préstamo en su listado", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		juanHeaders := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		carlosHeaders := map[string]string{
+			"Authorization": loginAndGetToken(t, "carlos@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties", map[string]interface{}{
+			"email": "carlos@example.com",
+			"role":  "co_applicant",
+		}, juanHeaders)
+		c.Equal(201, w.Code)
+
+		// El invitado ve el préstamo con su rol
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/loans/user", nil, carlosHeaders)
+		c.Equal(200, w.Code)
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+
+		var partyLoan map[string]interface{}
+		for _, item := range response["data"].([]interface{}) {
+			loan := item.(map[string]interface{})
+			if loan["id"] == float64(1) {
+				partyLoan = loan
+			}
+		}
+		c.NotNil(partyLoan)
+		c.Equal("co_applicant", partyLoan["role"])
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties/accept", acceptInvitationRequest(t, 3), carlosHeaders)
+		c.Equal(200, w.Code)
+
+		var party models.LoanParty
+		c.NoError(DB.Where("loan_id = ? AND user_id = ?", 1, 3).First(&party).Error)
+		c.Equal(models.LoanPartyStatusAccepted, party.Status)
+		c.NotNil(party.CreditScore)
+		c.NotNil(party.ConsentedAt)
+		c.Equal(models.IdentityMatchFull, party.IdentityMatch)
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/loans/1/parties", nil, juanHeaders)
+		c.Equal(200, w.Code)
+	})
+
+	t.Run("Debería exigir consentimiento y permitir rechazar la invitación", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		juanHeaders := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		carlosHeaders := map[string]string{
+			"Authorization": loginAndGetToken(t, "carlos@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties", map[string]interface{}{
+			"email": "carlos@example.com",
+			"role":  "guarantor",
+		}, juanHeaders)
+		c.Equal(201, w.Code)
+
+		request := acceptInvitationRequest(t, 3)
+		request["consent"] = false
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties/accept", request, carlosHeaders)
+		c.Equal(400, w.Code)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties/decline", nil, carlosHeaders)
+		c.Equal(200, w.Code)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties/decline", nil, carlosHeaders)
+		c.Equal(409, w.Code)
+	})
+
+	t.Run("Debería validar a quién se invita", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		juanHeaders := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		mariaHeaders := map[string]string{
+			"Authorization": loginAndGetToken(t, "maria@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties", map[string]interface{}{
+			"email": "juan@example.com",
+			"role":  "co_applicant",
+		}, juanHeaders)
+		c.Equal(400, w.Code)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties", map[string]interface{}{
+			"email": "nadie@example.com",
+			"role":  "co_applicant",
+		}, juanHeaders)
+		c.Equal(404, w.Code)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties", map[string]interface{}{
+			"email": "carlos@example.com",
+			"role":  "primary",
+		}, juanHeaders)
+		c.Equal(400, w.Code)
+
+		// Solo el solicitante principal puede invitar
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties", map[string]interface{}{
+			"email": "carlos@example.com",
+			"role":  "co_applicant",
+		}, mariaHeaders)
+		c.Equal(403, w.Code)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties", map[string]interface{}{
+			"email": "carlos@example.com",
+			"role":  "co_applicant",
+		}, juanHeaders)
+		c.Equal(201, w.Code)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties", map[string]interface{}{
+			"email": "carlos@example.com",
+			"role":  "guarantor",
+		}, juanHeaders)
+		c.Equal(409, w.Code)
+	})
+}
+
+func TestLoanPartyController_Decision(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería bloquear la decisión con invitaciones pendientes", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		juanHeaders := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		completeLoanForms(t, "1", juanHeaders)
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties", map[string]interface{}{
+			"email": "carlos@example.com",
+			"role":  "co_applicant",
+		}, juanHeaders)
+		c.Equal(201, w.Code)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/decision", nil, juanHeaders)
+		c.Equal(409, w.Code)
+	})
+
+	t.Run("Debería sumar los ingresos del codeudor a la capacidad de pago", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		juanHeaders := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		carlosHeaders := map[string]string{
+			"Authorization": loginAndGetToken(t, "carlos@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		completeLoanForms(t, "1", juanHeaders)
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties", map[string]interface{}{
+			"email": "carlos@example.com",
+			"role":  "co_applicant",
+		}, juanHeaders)
+		c.Equal(201, w.Code)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/parties/accept", acceptInvitationRequest(t, 3), carlosHeaders)
+		c.Equal(200, w.Code)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/decision", nil, juanHeaders)
+		c.Equal(200, w.Code)
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		affordability := loan.GetAffordability()
+		c.NotNil(affordability)
+		c.Equal("8000000", affordability.MonthlyIncome.String())
+		c.Equal("3000000", affordability.MonthlyExpenses.String())
+	})
+}
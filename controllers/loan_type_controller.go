@@ -3,7 +3,7 @@ package controllers
 import (
 	"loan-api/services"
 	"loan-api/utils"
-	"log"
+	"log/slog"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -38,7 +38,7 @@ func NewLoanTypeController(loanTypeService services.LoanTypeService, tenantServi
 // @Failure 500 {object} utils.APIResponse
 // @Router /loan-types [get]
 func (ctrl *LoanTypeController) GetLoanTypesWithForms(c *gin.Context) {
-	log.Println("LoanTypeController::GetLoanTypesWithForms was invoked")
+	slog.DebugContext(c.Request.Context(), "LoanTypeController::GetLoanTypesWithForms was invoked")
 
 	// Validar header X-Tenant-ID
 	tenantIDStr := c.GetHeader("X-Tenant-ID")
@@ -54,14 +54,14 @@ func (ctrl *LoanTypeController) GetLoanTypesWithForms(c *gin.Context) {
 	}
 
 	// Validar que el tenant existe
-	_, err = ctrl.tenantService.ValidateTenantID(uint(tenantID))
+	_, err = ctrl.tenantService.ValidateTenantID(c.Request.Context(), uint(tenantID))
 	if err != nil {
 		utils.NotFoundResponse(c, "Tenant no encontrado")
 		return
 	}
 
 	// Obtener tipos de préstamo con formularios
-	loanTypes, err := ctrl.loanTypeService.GetLoanTypesWithForms(uint(tenantID))
+	loanTypes, err := ctrl.loanTypeService.GetLoanTypesWithForms(c.Request.Context(), uint(tenantID))
 	if err != nil {
 		utils.InternalServerErrorResponse(c, "Error al obtener tipos de préstamo: "+err.Error())
 		return
@@ -87,7 +87,7 @@ func (ctrl *LoanTypeController) GetLoanTypesWithForms(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse
 // @Router /loan-types/{code} [get]
 func (ctrl *LoanTypeController) GetLoanTypeByCode(c *gin.Context) {
-	log.Println("LoanTypeController::GetLoanTypeByCode was invoked")
+	slog.DebugContext(c.Request.Context(), "LoanTypeController::GetLoanTypeByCode was invoked")
 
 	// Validar header X-Tenant-ID
 	tenantIDStr := c.GetHeader("X-Tenant-ID")
@@ -103,7 +103,7 @@ func (ctrl *LoanTypeController) GetLoanTypeByCode(c *gin.Context) {
 	}
 
 	// Validar que el tenant existe
-	_, err = ctrl.tenantService.ValidateTenantID(uint(tenantID))
+	_, err = ctrl.tenantService.ValidateTenantID(c.Request.Context(), uint(tenantID))
 	if err != nil {
 		utils.NotFoundResponse(c, "Tenant no encontrado")
 		return
@@ -117,7 +117,7 @@ func (ctrl *LoanTypeController) GetLoanTypeByCode(c *gin.Context) {
 	}
 
 	// Obtener tipo de préstamo por código
-	loanType, err := ctrl.loanTypeService.GetLoanTypeByCode(uint(tenantID), code)
+	loanType, err := ctrl.loanTypeService.GetLoanTypeByCode(c.Request.Context(), uint(tenantID), code)
 	if err != nil {
 		utils.NotFoundResponse(c, "Tipo de préstamo no encontrado: "+err.Error())
 		return
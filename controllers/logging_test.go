@@ -0,0 +1,130 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"loan-api/logging"
+	"loan-api/middlewares"
+	"loan-api/models"
+	"loan-api/test"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// captureLogs reemplaza el logger por defecto por uno que escribe en el buffer retornado
+func captureLogs(t *testing.T) *bytes.Buffer {
+	cfg := APP.Config
+	cfg.LogLevel = "debug"
+	cfg.LogFormat = "json"
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(cfg, &buf))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestLogging(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería incluir el identificador de la solicitud en las respuestas de error", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		// Error de un middleware
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/loans/user", nil, map[string]string{
+			"X-Tenant-ID":               "1",
+			middlewares.RequestIDHeader: "req-middleware",
+		})
+		c.Equal(401, w.Code)
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Equal("req-middleware", response["request_id"])
+
+		// Error de un controlador
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/loans/999", nil, map[string]string{
+			"Authorization":             loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":               "1",
+			middlewares.RequestIDHeader: "req-controller",
+		})
+		c.Equal(404, w.Code)
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Equal("req-controller", response["request_id"])
+	})
+
+	t.Run("Debería descartar identificadores de solicitud con caracteres no permitidos", func(t *testing.T) {
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/health-checker", nil, map[string]string{
+			"X-Tenant-ID":               "1",
+			middlewares.RequestIDHeader: "req 1\nfalso",
+		})
+		c.Equal(200, w.Code)
+		c.Len(w.Header().Get(middlewares.RequestIDHeader), 32)
+	})
+
+	t.Run("Debería registrar cada solicitud con su identificador y sin datos personales", func(t *testing.T) {
+		test.LoadTestData(DB)
+		buf := captureLogs(t)
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/auth/login", map[string]interface{}{
+			"email":    "juan@example.com",
+			"password": "password123!",
+		}, map[string]string{
+			"X-Tenant-ID":               "1",
+			middlewares.RequestIDHeader: "req-login",
+		})
+		c.Equal(200, w.Code)
+
+		output := buf.String()
+		c.NotContains(output, "juan@example.com")
+		c.NotContains(output, "password123!")
+
+		var access map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			var entry map[string]interface{}
+			c.NoError(json.Unmarshal([]byte(line), &entry))
+			if entry["msg"] == "request" {
+				access = entry
+			}
+			// Toda línea emitida durante la solicitud con su contexto lleva el identificador
+			if entry["msg"] == "UserController::Login was invoked" {
+				c.Equal("req-login", entry["request_id"])
+			}
+		}
+		c.NotNil(access)
+		c.Equal("req-login", access["request_id"])
+		c.Equal("POST", access["method"])
+		c.Equal("/loan-api/api/v1/auth/login", access["path"])
+		c.Equal(float64(200), access["status"])
+		c.Equal(float64(1), access["tenant_id"])
+	})
+
+	t.Run("Debería registrar las consultas de GORM sin los valores de las columnas", func(t *testing.T) {
+		test.LoadTestData(DB)
+		buf := captureLogs(t)
+
+		// Mismo logger de la conexión, con el nivel que se usa en desarrollo
+		session := DB.Session(&gorm.Session{Logger: DB.Logger.LogMode(logger.Info)})
+		user := models.User{
+			TenantID:       1,
+			Name:           "Laura Gómez",
+			Email:          "laura.trace@example.com",
+			Phone:          "3157654321",
+			DocumentType:   models.DocumentTypeCedula,
+			DocumentNumber: "55443322",
+			Password:       "$2a$10$abcdefghijklmnopqrstuv",
+		}
+		c.NoError(session.Create(&user).Error)
+
+		output := buf.String()
+		c.Contains(output, "INSERT INTO")
+		c.Contains(output, `"component":"gorm"`)
+		for _, secret := range []string{"laura.trace@example.com", "3157654321", "55443322", "Laura Gómez", "$2a$10$"} {
+			c.NotContains(output, secret)
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"loan-api/app"
+	"loan-api/app_error"
+	"loan-api/middlewares"
+	"loan-api/models"
+	"loan-api/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock reloj controlado por la prueba
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// newRateLimitedApp construye una aplicación con el límite de solicitudes activado y el reloj indicado
+func newRateLimitedApp(t *testing.T, clock *fakeClock, auth, loans string) *app.Container {
+	container, err := app.NewContainer(APP.Config, DB)
+	require.NoError(t, err)
+
+	rules, err := middlewares.ParseRateLimitRules(map[string]string{
+		middlewares.RateLimitGroupAuth:  auth,
+		middlewares.RateLimitGroupLoans: loans,
+	})
+	require.NoError(t, err)
+	container.RateLimiter = middlewares.NewRateLimiter(middlewares.NewMemoryRateLimitStore(), rules, clock.Now)
+	return container
+}
+
+// loginFrom inicia sesión desde la IP indicada
+func loginFrom(container *app.Container, remoteAddr string) *httptest.ResponseRecorder {
+	body := `{"email": "juan@example.com", "password": "password123!"}`
+	req := httptest.NewRequest(http.MethodPost, "/loan-api/api/v1/auth/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant-ID", "1")
+	req.RemoteAddr = remoteAddr
+
+	w := httptest.NewRecorder()
+	app.SetupRouter(container).ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería limitar el login por IP y recuperar las solicitudes con el tiempo", func(t *testing.T) {
+		test.LoadTestData(DB)
+		clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+		container := newRateLimitedApp(t, clock, "3/1m", "0")
+
+		for i := 0; i < 3; i++ {
+			w := loginFrom(container, "10.0.0.1:1234")
+			c.Equal(200, w.Code)
+			c.Equal("3", w.Header().Get("RateLimit-Limit"))
+			c.Equal(string(rune('2'-i)), w.Header().Get("RateLimit-Remaining"))
+			c.Equal("3;w=60", w.Header().Get("RateLimit-Policy"))
+		}
+
+		w := loginFrom(container, "10.0.0.1:1234")
+		c.Equal(429, w.Code)
+		c.Equal("20", w.Header().Get("Retry-After"))
+		c.Equal("0", w.Header().Get("RateLimit-Remaining"))
+		c.Equal("60", w.Header().Get("RateLimit-Reset"))
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Equal(app_error.ErrTooManyRequests.Message, response["message"])
+
+		// Otra IP tiene su propio límite
+		w = loginFrom(container, "10.0.0.2:1234")
+		c.Equal(200, w.Code)
+
+		// Cada 20 segundos se recupera una solicitud
+		clock.Advance(19 * time.Second)
+		w = loginFrom(container, "10.0.0.1:1234")
+		c.Equal(429, w.Code)
+		c.Equal("1", w.Header().Get("Retry-After"))
+
+		clock.Advance(time.Second)
+		w = loginFrom(container, "10.0.0.1:1234")
+		c.Equal(200, w.Code)
+		c.Equal("0", w.Header().Get("RateLimit-Remaining"))
+
+		// Sin solicitudes durante el periodo completo se recupera toda la ráfaga, no más
+		clock.Advance(10 * time.Minute)
+		w = loginFrom(container, "10.0.0.1:1234")
+		c.Equal(200, w.Code)
+		c.Equal("2", w.Header().Get("RateLimit-Remaining"))
+	})
+
+	t.Run("Debería limitar las rutas de préstamos por usuario", func(t *testing.T) {
+		test.LoadTestData(DB)
+		clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+		container := newRateLimitedApp(t, clock, "0", "2/1m")
+
+		juan := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		maria := map[string]string{
+			"Authorization": loginAndGetToken(t, "maria@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		c.Equal(200, test.MakeGetRequest(container, "/loan-api/api/v1/loans/user", nil, juan).Code)
+		c.Equal(200, test.MakeGetRequest(container, "/loan-api/api/v1/loans/1", nil, juan).Code)
+		w := test.MakeGetRequest(container, "/loan-api/api/v1/loans/user", nil, juan)
+		c.Equal(429, w.Code)
+		c.Equal("30", w.Header().Get("Retry-After"))
+
+		// Los codeudores y garantes comparten el límite de préstamos
+		c.Equal(429, test.MakeGetRequest(container, "/loan-api/api/v1/loans/1/parties", nil, juan).Code)
+
+		w = test.MakeGetRequest(container, "/loan-api/api/v1/loans/user", nil, maria)
+		c.Equal(200, w.Code)
+		c.Equal("1", w.Header().Get("RateLimit-Remaining"))
+
+		// Sin límite configurado para el grupo no se agregan cabeceras
+		w = loginFrom(container, "10.0.0.1:1234")
+		c.Equal(200, w.Code)
+		c.Empty(w.Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("Debería usar el límite configurado por el tenant", func(t *testing.T) {
+		test.LoadTestData(DB)
+		clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+		container := newRateLimitedApp(t, clock, "0", "5/1m")
+
+		var tenant models.Tenant
+		c.NoError(DB.First(&tenant, 1).Error)
+		original := tenant.Config
+		t.Cleanup(func() {
+			DB.Model(&models.Tenant{}).Where("id = ?", 1).Update("config", original)
+		})
+		c.NoError(DB.Model(&models.Tenant{}).Where("id = ?", 1).
+			Update("config", models.JSON(`{"max_loan_amount": 50000000, "rate_limits": {"loans": "1/10s"}}`)).Error)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		w := test.MakeGetRequest(container, "/loan-api/api/v1/loans/user", nil, headers)
+		c.Equal(200, w.Code)
+		c.Equal("1", w.Header().Get("RateLimit-Limit"))
+
+		w = test.MakeGetRequest(container, "/loan-api/api/v1/loans/user", nil, headers)
+		c.Equal(429, w.Code)
+		c.Equal("10", w.Header().Get("Retry-After"))
+
+		clock.Advance(10 * time.Second)
+		c.Equal(200, test.MakeGetRequest(container, "/loan-api/api/v1/loans/user", nil, headers).Code)
+	})
+}
@@ -0,0 +1,67 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"loan-api/app"
+	"loan-api/middlewares"
+	"loan-api/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestContext(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería conservar el X-Request-ID recibido y generar uno si no existe", func(t *testing.T) {
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/health-checker", nil, map[string]string{
+			"X-Tenant-ID":               "1",
+			middlewares.RequestIDHeader: "req-123",
+		})
+		c.Equal(200, w.Code)
+		c.Equal("req-123", w.Header().Get(middlewares.RequestIDHeader))
+
+		w = test.MakeGetRequest(APP, "/loan-api/api/v1/health-checker", nil, map[string]string{"X-Tenant-ID": "1"})
+		c.Equal(200, w.Code)
+		c.Len(w.Header().Get(middlewares.RequestIDHeader), 32)
+	})
+
+	t.Run("Debería responder 504 cuando vence el plazo de la solicitud", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		cfg := APP.Config
+		cfg.RequestTimeout = time.Nanosecond
+		container, err := app.NewContainer(cfg, DB)
+		c.NoError(err)
+
+		w := test.MakeGetRequest(container, "/loan-api/api/v1/loans/user", nil, map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		})
+		c.Equal(504, w.Code)
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Equal("La solicitud excedió el tiempo máximo de procesamiento", response["message"])
+	})
+
+	t.Run("Debería aplicar el plazo propio de la ruta sobre el plazo general", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		cfg := APP.Config
+		cfg.RequestTimeout = time.Nanosecond
+		cfg.ExportTimeout = time.Minute
+		container, err := app.NewContainer(cfg, DB)
+		c.NoError(err)
+
+		// El login también vence con el plazo general, por lo que el token se obtiene de APP
+		token := loginAndGetToken(t, "admin@example.com", "password123!")
+		w := test.MakeGetRequest(container, "/loan-api/api/v1/admin/loans/export", map[string]interface{}{"format": "csv"}, map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		})
+		c.Equal(200, w.Code)
+	})
+}
@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"context"
+	"loan-api/models"
+	"loan-api/services"
+	"loan-api/utils"
+	"log/slog"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewController maneja las operaciones de la cola de revisión manual
+type ReviewController struct {
+	reviewService services.ReviewService
+}
+
+// NewReviewController crea una nueva instancia del controlador de revisión manual
+func NewReviewController(reviewService services.ReviewService) *ReviewController {
+	return &ReviewController{
+		reviewService: reviewService,
+	}
+}
+
+// GetQueue godoc
+// @Summary Obtener la cola de revisión manual
+// @Description Lista los préstamos en revisión manual del tenant con filtros y ordenamiento
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param status query string false "Estado de los préstamos (por defecto manual_review)"
+// @Param assignment query string false "Asignación: all, mine, unclaimed"
+// @Param loan_type_id query int false "ID del tipo de préstamo"
+// @Param min_score query int false "Score crediticio mínimo"
+// @Param max_score query int false "Score crediticio máximo"
+// @Param sort_by query string false "Campo de orden: created_at, updated_at, credit_score"
+// @Param sort_order query string false "Dirección del orden: asc, desc"
+// @Success 200 {object} utils.APIResponse{data=[]models.ReviewQueueItemResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /review/loans [get]
+func (ctrl *ReviewController) GetQueue(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "ReviewController::GetQueue was invoked")
+
+	filter := models.ReviewQueueFilter{
+		TenantID:   c.GetUint("tenant_id"),
+		AnalystID:  c.GetUint("user_id"),
+		Status:     c.Query("status"),
+		Assignment: c.DefaultQuery("assignment", "all"),
+		SortBy:     c.DefaultQuery("sort_by", "created_at"),
+		SortOrder:  c.DefaultQuery("sort_order", "asc"),
+	}
+
+	if loanTypeIDStr := c.Query("loan_type_id"); loanTypeIDStr != "" {
+		loanTypeID, err := strconv.ParseUint(loanTypeIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequestResponse(c, "loan_type_id debe ser un número válido")
+			return
+		}
+		filter.LoanTypeID = uint(loanTypeID)
+	}
+
+	if minScoreStr := c.Query("min_score"); minScoreStr != "" {
+		minScore, err := strconv.Atoi(minScoreStr)
+		if err != nil {
+			utils.BadRequestResponse(c, "min_score debe ser un número válido")
+			return
+		}
+		filter.MinScore = &minScore
+	}
+
+	if maxScoreStr := c.Query("max_score"); maxScoreStr != "" {
+		maxScore, err := strconv.Atoi(maxScoreStr)
+		if err != nil {
+			utils.BadRequestResponse(c, "max_score debe ser un número válido")
+			return
+		}
+		filter.MaxScore = &maxScore
+	}
+
+	if filter.Assignment != "all" && filter.Assignment != "mine" && filter.Assignment != "unclaimed" {
+		utils.BadRequestResponse(c, "assignment debe ser all, mine o unclaimed")
+		return
+	}
+
+	queue, err := ctrl.reviewService.GetQueue(c.Request.Context(), filter)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Cola de revisión obtenida exitosamente", queue)
+}
+
+// ClaimLoan godoc
+// @Summary Tomar un préstamo en revisión
+// @Description Asigna el préstamo al analista autenticado por un tiempo limitado
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Router /review/loans/{id}/claim [post]
+func (ctrl *ReviewController) ClaimLoan(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "ReviewController::ClaimLoan was invoked")
+
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	loanResponse, err := ctrl.reviewService.ClaimLoan(c.Request.Context(), c.GetUint("tenant_id"), loanID, c.GetUint("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Préstamo asignado exitosamente", loanResponse)
+}
+
+// ReleaseLoan godoc
+// @Summary Liberar un préstamo en revisión
+// @Description Libera la asignación del préstamo para que otro analista pueda tomarlo
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Success 200 {object} utils.APIResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Router /review/loans/{id}/release [post]
+func (ctrl *ReviewController) ReleaseLoan(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "ReviewController::ReleaseLoan was invoked")
+
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.reviewService.ReleaseLoan(c.Request.Context(), c.GetUint("tenant_id"), loanID, c.GetUint("user_id")); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Préstamo liberado exitosamente", nil)
+}
+
+// ApproveLoan godoc
+// @Summary Aprobar un préstamo en revisión
+// @Description Aprueba y desembolsa un préstamo asignado al analista. El comentario es obligatorio
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Param decision body models.ReviewDecisionRequest true "Comentario del analista"
+// @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Router /review/loans/{id}/approve [post]
+func (ctrl *ReviewController) ApproveLoan(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "ReviewController::ApproveLoan was invoked")
+	ctrl.handleDecision(c, ctrl.reviewService.ApproveLoan, "Préstamo aprobado exitosamente")
+}
+
+// RejectLoan godoc
+// @Summary Rechazar un préstamo en revisión
+// @Description Rechaza un préstamo asignado al analista. El comentario es obligatorio
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Param decision body models.ReviewDecisionRequest true "Comentario del analista"
+// @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Router /review/loans/{id}/reject [post]
+func (ctrl *ReviewController) RejectLoan(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "ReviewController::RejectLoan was invoked")
+	ctrl.handleDecision(c, ctrl.reviewService.RejectLoan, "Préstamo rechazado exitosamente")
+}
+
+// RequestMoreInfo godoc
+// @Summary Solicitar más información
+// @Description Devuelve el préstamo al solicitante para completar información. El comentario es obligatorio
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Param decision body models.ReviewDecisionRequest true "Comentario del analista"
+// @Success 200 {object} utils.APIResponse{data=models.LoanResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Router /review/loans/{id}/request-info [post]
+func (ctrl *ReviewController) RequestMoreInfo(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "ReviewController::RequestMoreInfo was invoked")
+	ctrl.handleDecision(c, ctrl.reviewService.RequestMoreInfo, "Información adicional solicitada exitosamente")
+}
+
+// GetLoanActions godoc
+// @Summary Obtener historial de revisión
+// @Description Obtiene las acciones registradas por los analistas sobre un préstamo
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Tenant-ID header string true "ID del tenant"
+// @Param id path int true "ID del préstamo"
+// @Success 200 {object} utils.APIResponse{data=[]models.LoanReviewAction}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /review/loans/{id}/actions [get]
+func (ctrl *ReviewController) GetLoanActions(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "ReviewController::GetLoanActions was invoked")
+
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	actions, err := ctrl.reviewService.GetLoanActions(c.Request.Context(), c.GetUint("tenant_id"), loanID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Historial de revisión obtenido exitosamente", actions)
+}
+
+// handleDecision procesa una decisión del analista con comentario obligatorio
+func (ctrl *ReviewController) handleDecision(c *gin.Context, decide func(ctx context.Context, tenantID, loanID, analystID uint, comment string) (*models.LoanResponse, error), message string) {
+	loanID, ok := parseLoanIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReviewDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Formato JSON inválido")
+		return
+	}
+
+	loanResponse, err := decide(c.Request.Context(), c.GetUint("tenant_id"), loanID, c.GetUint("user_id"), req.Comment)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, message, loanResponse)
+}
+
+// parseLoanIDParam obtiene el ID del préstamo desde los parámetros de la ruta
+func parseLoanIDParam(c *gin.Context) (uint, bool) {
+	loanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "ID del préstamo debe ser un número válido")
+		return 0, false
+	}
+	return uint(loanID), true
+}
@@ -0,0 +1,253 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"loan-api/app_error"
+	"loan-api/models"
+	"loan-api/services"
+	"loan-api/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setLoanInManualReview deja un préstamo de prueba en estado de revisión manual
+func setLoanInManualReview(t *testing.T, loanID uint) {
+	c := require.New(t)
+
+	err := DB.Model(&models.Loan{}).Where("id = ?", loanID).Updates(map[string]interface{}{
+		"status":       string(models.LoanStatusManualReview),
+		"credit_score": 450,
+		"observation":  "Solicitud enviada a revisión manual",
+	}).Error
+	c.NoError(err)
+}
+
+func TestReviewController_GetQueue(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería listar los préstamos en revisión manual para un analista", func(t *testing.T) {
+		test.LoadTestData(DB)
+		setLoanInManualReview(t, 1)
+
+		token := loginAndGetToken(t, "analyst@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/review/loans", map[string]interface{}{"assignment": "unclaimed"}, headers)
+		c.Equal(200, w.Code)
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+
+		data := response["data"].([]interface{})
+		c.Len(data, 1)
+		item := data[0].(map[string]interface{})
+		c.Equal(float64(1), item["id"])
+		c.Equal("manual_review", item["status"])
+	})
+
+	t.Run("Debería fallar para un usuario sin rol de analista", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		token := loginAndGetToken(t, "juan@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakeGetRequest(APP, "/loan-api/api/v1/review/loans", nil, headers)
+		c.Equal(403, w.Code)
+	})
+}
+
+func TestReviewController_Decisions(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería tomar y rechazar un préstamo con comentario", func(t *testing.T) {
+		test.LoadTestData(DB)
+		setLoanInManualReview(t, 1)
+
+		token := loginAndGetToken(t, "analyst@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/review/loans/1/claim", nil, headers)
+		c.Equal(200, w.Code)
+
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		c.NotNil(loan.ReviewClaimedBy)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/review/loans/1/reject", map[string]interface{}{
+			"comment": "Ingresos no soportados",
+		}, headers)
+		c.Equal(200, w.Code)
+
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal("rejected", loan.Status)
+		c.Nil(loan.ReviewClaimedBy)
+		c.Contains(loan.Observation, "Ingresos no soportados")
+
+		var actions int64
+		DB.Model(&models.LoanReviewAction{}).Where("loan_id = ?", 1).Count(&actions)
+		c.Equal(int64(2), actions)
+	})
+
+	t.Run("Debería fallar al decidir sin tomar el préstamo", func(t *testing.T) {
+		test.LoadTestData(DB)
+		setLoanInManualReview(t, 1)
+
+		token := loginAndGetToken(t, "analyst@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/review/loans/1/approve", map[string]interface{}{
+			"comment": "Aprobado",
+		}, headers)
+		c.Equal(409, w.Code)
+	})
+
+	t.Run("Debería fallar sin comentario", func(t *testing.T) {
+		test.LoadTestData(DB)
+		setLoanInManualReview(t, 1)
+
+		token := loginAndGetToken(t, "analyst@example.com", "password123!")
+
+		headers := map[string]string{
+			"Authorization": token,
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/review/loans/1/claim", nil, headers)
+		c.Equal(200, w.Code)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/review/loans/1/request-info", map[string]interface{}{
+			"comment": "  ",
+		}, headers)
+		c.Equal(400, w.Code)
+	})
+}
+
+func TestReviewController_ManualReviewRouting(t *testing.T) {
+	c := require.New(t)
+
+	// Banda de revisión (score 400-499), umbral de monto (8.000.000) y coincidencia parcial de identidad del seed
+	cases := []struct {
+		name        string
+		amount      string
+		validations map[string]interface{}
+		reason      string
+	}{
+		{
+			name:        "Debería enviar a revisión manual un score dentro de la banda configurada",
+			amount:      "2000000",
+			validations: map[string]interface{}{"credit_score": 450},
+			reason:      "score crediticio en banda de revisión (450)",
+		},
+		{
+			name:   "Debería enviar a revisión manual un monto igual o superior al umbral",
+			amount: "9000000",
+			reason: "monto solicitado superior al umbral de revisión",
+		},
+		{
+			name:        "Debería enviar a revisión manual una coincidencia parcial de identidad",
+			amount:      "2000000",
+			validations: map[string]interface{}{"identity_match": models.IdentityMatchPartial},
+			reason:      "coincidencia parcial de identidad",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			test.LoadTestData(DB)
+
+			headers := map[string]string{
+				"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+				"X-Tenant-ID":   "1",
+			}
+			completeLoanForDecision(t, headers, "5000000", "2000000", tc.amount)
+			if tc.validations != nil {
+				c.NoError(DB.Model(&models.Loan{}).Where("id = ?", 1).Updates(tc.validations).Error)
+			}
+
+			disbursement := &countingDisbursementProvider{DisbursementProvider: services.NewSimulatedDisbursementProvider()}
+			w := test.MakePostRequest(newLoanApp(t, nil, disbursement), "/loan-api/api/v1/loans/1/decision", nil, headers)
+			c.Equal(200, w.Code)
+
+			loan, err := test.GetTestLoan(DB, 1)
+			c.NoError(err)
+			c.Equal(string(models.LoanStatusManualReview), loan.Status)
+			c.Contains(loan.Observation, tc.reason)
+			c.Zero(disbursement.calls.Load())
+		})
+	}
+}
+
+func TestReviewController_ClaimExpiration(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería permitir que otro analista tome una asignación vencida", func(t *testing.T) {
+		test.LoadTestData(DB)
+		setLoanInManualReview(t, 1)
+
+		analystHeaders := map[string]string{
+			"Authorization": loginAndGetToken(t, "analyst@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		adminHeaders := map[string]string{
+			"Authorization": loginAndGetToken(t, "admin@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/review/loans/1/claim", nil, analystHeaders)
+		c.Equal(200, w.Code)
+
+		// La asignación vence después de REVIEW_CLAIM_TTL
+		var loan models.Loan
+		c.NoError(DB.First(&loan, 1).Error)
+		c.NotNil(loan.ReviewClaimedAt)
+		c.NotNil(loan.ReviewClaimExpiresAt)
+		c.WithinDuration(loan.ReviewClaimedAt.Add(APP.Config.ReviewClaimTTL), *loan.ReviewClaimExpiresAt, time.Second)
+
+		// Mientras está vigente, otro analista no puede tomarla
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/review/loans/1/claim", nil, adminHeaders)
+		c.Equal(409, w.Code)
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		c.Equal(app_error.ErrReviewClaimConflict.Message, response["error"].(map[string]interface{})["message"])
+
+		// Vencida, otro analista la toma y el primero ya no puede decidir
+		expired := time.Now().Add(-time.Minute)
+		c.NoError(DB.Model(&models.Loan{}).Where("id = ?", 1).Updates(map[string]interface{}{
+			"review_claimed_at":       expired.Add(-APP.Config.ReviewClaimTTL),
+			"review_claim_expires_at": expired,
+		}).Error)
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/review/loans/1/claim", nil, adminHeaders)
+		c.Equal(200, w.Code)
+
+		var admin models.User
+		c.NoError(DB.Where("email = ?", "admin@example.com").First(&admin).Error)
+		c.NoError(DB.First(&loan, 1).Error)
+		c.Equal(admin.ID, *loan.ReviewClaimedBy)
+		c.True(loan.ReviewClaimExpiresAt.After(time.Now()))
+
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/review/loans/1/reject", map[string]interface{}{
+			"comment": "Ingresos no soportados",
+		}, analystHeaders)
+		c.Equal(409, w.Code)
+	})
+}
@@ -0,0 +1,133 @@
+package controllers_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"loan-api/app_error"
+	"loan-api/scheduler"
+	"loan-api/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_Lease(t *testing.T) {
+	c := require.New(t)
+	ctx := context.Background()
+
+	t.Run("Debería ejecutar la tarea una sola vez por periodo en réplicas con relojes desfasados", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		// La réplica B va dos segundos adelantada, o arranca la tarea más tarde que A
+		base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.Local)
+		clockA := &fakeClock{now: base}
+		clockB := &fakeClock{now: base.Add(2 * time.Second)}
+		replicaA := scheduler.NewScheduler(APP.Repositories.Job, "replica-a", time.Minute, clockA.Now)
+		replicaB := scheduler.NewScheduler(APP.Repositories.Job, "replica-b", time.Minute, clockB.Now)
+
+		var runs atomic.Int64
+		for _, replica := range []*scheduler.Scheduler{replicaA, replicaB} {
+			c.NoError(replica.Register("hourly_test", "0 * * * *", func(ctx context.Context) (string, error) {
+				runs.Add(1)
+				return "ok", nil
+			}))
+		}
+
+		for period := 0; period < 3; period++ {
+			// En cada periodo alterna la réplica que llega primero
+			first, second := replicaA, replicaB
+			if period%2 == 1 {
+				first, second = replicaB, replicaA
+			}
+
+			_, err := first.RunNow(ctx, "hourly_test")
+			c.NoError(err)
+			_, err = second.RunNow(ctx, "hourly_test")
+			c.ErrorIs(err, app_error.ErrJobLeaseHeld)
+
+			clockA.Advance(time.Hour)
+			clockB.Advance(time.Hour)
+		}
+		c.Equal(int64(3), runs.Load())
+	})
+
+	t.Run("Debería renovar el bloqueo mientras la tarea dure más que su vigencia", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		leaseTTL := 100 * time.Millisecond
+		replicaA := scheduler.NewScheduler(APP.Repositories.Job, "replica-a", leaseTTL, time.Now)
+		replicaB := scheduler.NewScheduler(APP.Repositories.Job, "replica-b", leaseTTL, time.Now)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		c.NoError(replicaA.Register("long_test", "@every 1h", func(ctx context.Context) (string, error) {
+			close(started)
+			<-release
+			return "ok", nil
+		}))
+		c.NoError(replicaB.Register("long_test", "@every 1h", func(ctx context.Context) (string, error) {
+			return "ok", nil
+		}))
+
+		done := make(chan error)
+		go func() {
+			_, err := replicaA.RunNow(ctx, "long_test")
+			done <- err
+		}()
+		<-started
+
+		// La ejecución ya duró más que leaseTTL: sin renovación, B tomaría el bloqueo
+		time.Sleep(3 * leaseTTL)
+		_, err := replicaB.RunNow(ctx, "long_test")
+		c.ErrorIs(err, app_error.ErrJobLeaseHeld)
+
+		close(release)
+		c.NoError(<-done)
+
+		// Al terminar, el bloqueo se conserva hasta la siguiente ejecución programada
+		time.Sleep(2 * leaseTTL)
+		_, err = replicaB.RunNow(ctx, "long_test")
+		c.ErrorIs(err, app_error.ErrJobLeaseHeld)
+	})
+
+	t.Run("Debería impedir una ejecución manual mientras corre la programada en la misma réplica", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		replica := scheduler.NewScheduler(APP.Repositories.Job, "replica-a", time.Minute, time.Now)
+
+		var runs atomic.Int64
+		started := make(chan struct{})
+		release := make(chan struct{})
+		c.NoError(replica.Register("tick_test", "@every 1h", func(ctx context.Context) (string, error) {
+			// Solo la primera ejecución queda en curso hasta que la prueba la libera
+			if runs.Add(1) == 1 {
+				close(started)
+				<-release
+			}
+			return "ok", nil
+		}))
+
+		// La ejecución en curso hace las veces del tick programado
+		done := make(chan error)
+		go func() {
+			_, err := replica.RunNow(ctx, "tick_test")
+			done <- err
+		}()
+		<-started
+
+		// La réplica es dueña del bloqueo, pero no puede ejecutar la tarea dos veces a la vez
+		_, err := replica.RunNow(ctx, "tick_test")
+		c.ErrorIs(err, app_error.ErrJobRunning)
+
+		close(release)
+		c.NoError(<-done)
+		c.Equal(int64(1), runs.Load())
+
+		// Terminada la ejecución, la réplica dueña del bloqueo puede volver a ejecutarla manualmente
+		_, err = replica.RunNow(ctx, "tick_test")
+		c.NoError(err)
+		c.Equal(int64(2), runs.Load())
+	})
+}
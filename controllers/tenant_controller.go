@@ -3,7 +3,7 @@ package controllers
 import (
 	"loan-api/services"
 	"loan-api/utils"
-	"log"
+	"log/slog"
 
 	"github.com/gin-gonic/gin"
 )
@@ -31,10 +31,10 @@ func NewTenantController(tenantService services.TenantService) *TenantController
 // @Failure 500 {object} utils.APIResponse
 // @Router /tenants [get]
 func (ctrl *TenantController) GetAvailableTenants(c *gin.Context) {
-	log.Println("TenantController::GetAvailableTenants was invoked")
+	slog.DebugContext(c.Request.Context(), "TenantController::GetAvailableTenants was invoked")
 
 	// Obtener tenants disponibles
-	tenants, err := ctrl.tenantService.GetAvailableTenants()
+	tenants, err := ctrl.tenantService.GetAvailableTenants(c.Request.Context())
 	if err != nil {
 		utils.InternalServerErrorResponse(c, "Error al obtener tenants: "+err.Error())
 		return
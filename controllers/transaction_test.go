@@ -0,0 +1,500 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"loan-api/app"
+	"loan-api/app_error"
+	"loan-api/controllers"
+	"loan-api/models"
+	"loan-api/repositories"
+	"loan-api/services"
+	"loan-api/test"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// errInjected falla simulada de la base de datos
+var errInjected = errors.New("falla simulada de la base de datos")
+
+// failingLoanRepository repositorio de préstamos que falla en la operación indicada
+type failingLoanRepository struct {
+	repositories.LoanRepository
+	failOn            string
+	concurrentUpdated bool
+}
+
+func (r *failingLoanRepository) GetByID(ctx context.Context, id uint) (*models.Loan, error) {
+	switch r.failOn {
+	case "GetByID":
+		return nil, errInjected
+	case "ConcurrentUpdate", "ConcurrentExpiration":
+		// Simula que otra operación (o la tarea de expiración) modifica el préstamo justo después de la
+		// primera lectura, que ocurre antes de abrir la transacción
+		if r.concurrentUpdated {
+			break
+		}
+		r.concurrentUpdated = true
+		loan, err := r.LoanRepository.GetByID(ctx, id)
+		if err == nil {
+			columns := map[string]interface{}{"version": loan.Version + 1}
+			if r.failOn == "ConcurrentExpiration" {
+				columns["status"] = string(models.LoanStatusExpired)
+			}
+			err = DB.Model(&models.Loan{}).Where("id = ?", id).UpdateColumns(columns).Error
+		}
+		return loan, err
+	}
+	return r.LoanRepository.GetByID(ctx, id)
+}
+
+func (r *failingLoanRepository) Update(ctx context.Context, loan *models.Loan) error {
+	switch r.failOn {
+	case "Update":
+		return errInjected
+	case "DisbursementUpdate":
+		// Falla solo al registrar el resultado, cuando el proveedor ya desembolsó
+		if loan.Status == string(models.LoanStatusApproved) {
+			return errInjected
+		}
+	}
+	return r.LoanRepository.Update(ctx, loan)
+}
+
+// countingDisbursementProvider cuenta los desembolsos realizados con el proveedor simulado
+type countingDisbursementProvider struct {
+	services.DisbursementProvider
+	calls atomic.Int64
+}
+
+func (p *countingDisbursementProvider) Disburse(ctx context.Context, userID uint, amount decimal.Decimal) (bool, error) {
+	p.calls.Add(1)
+	return p.DisbursementProvider.Disburse(ctx, userID, amount)
+}
+
+// unavailableDisbursementProvider simula un proveedor de desembolsos que no responde
+type unavailableDisbursementProvider struct {
+	calls atomic.Int64
+}
+
+func (p *unavailableDisbursementProvider) Disburse(ctx context.Context, userID uint, amount decimal.Decimal) (bool, error) {
+	p.calls.Add(1)
+	return false, errors.New("proveedor de desembolso no disponible")
+}
+
+// failingLoanReviewRepository repositorio de revisión que falla al registrar la acción indicada
+type failingLoanReviewRepository struct {
+	repositories.LoanReviewRepository
+	failOn models.ReviewAction
+}
+
+func (r *failingLoanReviewRepository) CreateAction(ctx context.Context, action *models.LoanReviewAction) error {
+	if action.Action == r.failOn {
+		return errInjected
+	}
+	return r.LoanReviewRepository.CreateAction(ctx, action)
+}
+
+// newReviewApp construye una aplicación sobre la base de pruebas cuyo servicio de revisión usa el
+// repositorio de revisión y el proveedor de desembolsos indicados
+func newReviewApp(t *testing.T, reviewRepo repositories.LoanReviewRepository, disbursement services.DisbursementProvider) *app.Container {
+	container, err := app.NewContainer(APP.Config, DB)
+	require.NoError(t, err)
+
+	repos := container.Repositories
+	reviewService := services.NewReviewService(repos.Loan, repos.User, repos.LoanType, repos.Tenant, reviewRepo, repos.Transaction, disbursement, container.Config.ReviewClaimTTL)
+	container.Controllers.Review = controllers.NewReviewController(reviewService)
+	return container
+}
+
+// newLoanApp construye una aplicación sobre la base de pruebas con el repositorio de préstamos y el
+// proveedor de desembolsos indicados
+func newLoanApp(t *testing.T, loanRepo repositories.LoanRepository, disbursement services.DisbursementProvider) *app.Container {
+	container, err := app.NewContainer(APP.Config, DB)
+	require.NoError(t, err)
+
+	repos := container.Repositories
+	if loanRepo == nil {
+		loanRepo = repos.Loan
+	}
+	loanService := services.NewLoanService(loanRepo, repos.User, repos.LoanType, repos.Tenant, repos.Transaction, disbursement)
+	container.Controllers.Loan = controllers.NewLoanController(loanService, container.Services.Tenant)
+	return container
+}
+
+// newFailingApp construye una aplicación sobre la base de pruebas cuyo servicio de préstamos
+// usa un repositorio que falla en la operación indicada
+func newFailingApp(t *testing.T, failOn string) *app.Container {
+	loanRepo := &failingLoanRepository{LoanRepository: APP.Repositories.Loan, failOn: failOn}
+	return newLoanApp(t, loanRepo, services.NewSimulatedDisbursementProvider())
+}
+
+// completeLoanForDecision deja el préstamo 1 completo y con validaciones que llevan a la aprobación
+// (o a contraofertas si el monto excede la capacidad de pago)
+func completeLoanForDecision(t *testing.T, headers map[string]string, income, expenses, amount string) {
+	c := require.New(t)
+
+	w := test.MakePostRequest(APP, "/loan-api/api/v1/loans/data", completeLoanData(income, expenses, amount), headers)
+	c.Equal(200, w.Code)
+	c.NoError(DB.Model(&models.Loan{}).Where("id = ?", 1).Updates(map[string]interface{}{
+		"credit_score":      720,
+		"identity_verified": true,
+		"identity_match":    models.IdentityMatchFull,
+	}).Error)
+}
+
+// completeLoanData datos que dejan el préstamo 1 en estado completed
+func completeLoanData(income, expenses, amount string) map[string]interface{} {
+	return map[string]interface{}{
+		"loan_id": 1,
+		"data": []map[string]interface{}{
+			{"form_id": 1, "key": "full_name", "value": "Juan Pérez", "index": 0},
+			{"form_id": 1, "key": "document_type", "value": "cedula", "index": 0},
+			{"form_id": 1, "key": "document_number", "value": "12345678", "index": 0},
+			{"form_id": 1, "key": "age", "value": "30", "index": 0},
+			{"form_id": 2, "key": "monthly_income", "value": income, "index": 0},
+			{"form_id": 2, "key": "monthly_expenses", "value": expenses, "index": 0},
+			{"form_id": 3, "key": "requested_amount", "value": amount, "index": 0},
+			{"form_id": 3, "key": "purpose", "value": "Educación", "index": 0},
+		},
+	}
+}
+
+func TestLoanService_Transactions(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería no crear el préstamo si falla la lectura posterior a la creación", func(t *testing.T) {
+		test.LoadTestData(DB)
+		loans := test.CountLoans(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		w := test.MakePostRequest(newFailingApp(t, "GetByID"), "/loan-api/api/v1/loans", map[string]interface{}{"loan_type_id": 1}, headers)
+		c.Equal(500, w.Code)
+
+		c.Equal(loans, test.CountLoans(DB))
+		var parties int64
+		c.NoError(DB.Model(&models.LoanParty{}).Count(&parties).Error)
+		c.Zero(parties)
+	})
+
+	t.Run("Debería no guardar los datos si falla la actualización del estado", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		w := test.MakePostRequest(newFailingApp(t, "Update"), "/loan-api/api/v1/loans/data", completeLoanData("5000000", "2000000", "2000000"), headers)
+		c.Equal(500, w.Code)
+
+		var data int64
+		c.NoError(DB.Model(&models.LoanData{}).Where("loan_id = ?", 1).Count(&data).Error)
+		c.Zero(data)
+
+		loan, err := test.GetTestLoan(DB, 1)
+		c.NoError(err)
+		c.Equal("pending", loan.Status)
+	})
+
+	t.Run("Debería no guardar las contraofertas si falla la actualización de la decisión", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		// Un monto mayor a la capacidad de pago, pero menor al umbral de revisión manual, genera contraofertas
+		completeLoanForDecision(t, headers, "1500000", "1200000", "7000000")
+
+		w := test.MakePostRequest(newFailingApp(t, "Update"), "/loan-api/api/v1/loans/1/decision", nil, headers)
+		c.Equal(500, w.Code)
+
+		var offers int64
+		c.NoError(DB.Model(&models.LoanOffer{}).Where("loan_id = ?", 1).Count(&offers).Error)
+		c.Zero(offers)
+		loan, err := test.GetTestLoan(DB, 1)
+		c.NoError(err)
+		c.Equal("completed", loan.Status)
+		c.Empty(loan.Affordability)
+
+		// Sin la falla la misma decisión sí confirma las contraofertas
+		w = test.MakePostRequest(APP, "/loan-api/api/v1/loans/1/decision", nil, headers)
+		c.Equal(200, w.Code)
+		c.NoError(DB.Model(&models.LoanOffer{}).Where("loan_id = ?", 1).Count(&offers).Error)
+		c.NotZero(offers)
+		loan, err = test.GetTestLoan(DB, 1)
+		c.NoError(err)
+		c.Equal(string(models.LoanStatusOfferPending), loan.Status)
+	})
+
+	t.Run("Debería no desembolsar de nuevo si falla el registro del resultado del desembolso", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		completeLoanForDecision(t, headers, "5000000", "2000000", "2000000")
+
+		disbursement := &countingDisbursementProvider{DisbursementProvider: services.NewSimulatedDisbursementProvider()}
+		loanRepo := &failingLoanRepository{LoanRepository: APP.Repositories.Loan, failOn: "DisbursementUpdate"}
+		w := test.MakePostRequest(newLoanApp(t, loanRepo, disbursement), "/loan-api/api/v1/loans/1/decision", nil, headers)
+		c.Equal(500, w.Code)
+		c.Equal(int64(1), disbursement.calls.Load())
+
+		// La aprobación quedó confirmada antes de llamar al proveedor
+		loan, err := test.GetTestLoan(DB, 1)
+		c.NoError(err)
+		c.Equal(string(models.LoanStatusDisbursing), loan.Status)
+		c.True(loan.AmountApproved.Equal(decimal.NewFromInt(2000000)))
+
+		// Reintentar la decisión no vuelve a desembolsar
+		w = test.MakePostRequest(newLoanApp(t, nil, disbursement), "/loan-api/api/v1/loans/1/decision", nil, headers)
+		c.Equal(409, w.Code)
+		c.Equal(int64(1), disbursement.calls.Load())
+
+		loan, err = test.GetTestLoan(DB, 1)
+		c.NoError(err)
+		c.Equal(string(models.LoanStatusDisbursing), loan.Status)
+	})
+
+	t.Run("Debería devolver el préstamo a completado si el proveedor de desembolso no responde", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		completeLoanForDecision(t, headers, "5000000", "2000000", "2000000")
+
+		unavailable := &unavailableDisbursementProvider{}
+		w := test.MakePostRequest(newLoanApp(t, nil, unavailable), "/loan-api/api/v1/loans/1/decision", nil, headers)
+		c.Equal(503, w.Code)
+		c.Equal(int64(1), unavailable.calls.Load())
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		errorData := response["error"].(map[string]interface{})
+		c.Equal(app_error.ErrDisbursementFailed.Message, errorData["message"])
+
+		// El préstamo no queda bloqueado en desembolso
+		loan, err := test.GetTestLoan(DB, 1)
+		c.NoError(err)
+		c.Equal(string(models.LoanStatusCompleted), loan.Status)
+		c.True(loan.AmountApproved.IsZero())
+
+		// Con el proveedor disponible la decisión se reintenta y desembolsa
+		disbursement := &countingDisbursementProvider{DisbursementProvider: services.NewSimulatedDisbursementProvider()}
+		w = test.MakePostRequest(newLoanApp(t, nil, disbursement), "/loan-api/api/v1/loans/1/decision", nil, headers)
+		c.Equal(200, w.Code)
+		c.Equal(int64(1), disbursement.calls.Load())
+
+		loan, err = test.GetTestLoan(DB, 1)
+		c.NoError(err)
+		c.Equal(string(models.LoanStatusApproved), loan.Status)
+		c.True(loan.AmountApproved.Equal(decimal.NewFromInt(2000000)))
+	})
+}
+
+func TestReviewService_Transactions(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería no aprobar ni desembolsar si falla el registro de la acción del analista", func(t *testing.T) {
+		test.LoadTestData(DB)
+		completeLoanForDecision(t, map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}, "5000000", "2000000", "2000000")
+		setLoanInManualReview(t, 1)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "analyst@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/review/loans/1/claim", nil, headers)
+		c.Equal(200, w.Code)
+
+		disbursement := &countingDisbursementProvider{DisbursementProvider: services.NewSimulatedDisbursementProvider()}
+		reviewRepo := &failingLoanReviewRepository{LoanReviewRepository: APP.Repositories.LoanReview, failOn: models.ReviewActionApprove}
+		w = test.MakePostRequest(newReviewApp(t, reviewRepo, disbursement), "/loan-api/api/v1/review/loans/1/approve", map[string]interface{}{
+			"comment": "Ingresos verificados",
+		}, headers)
+		c.Equal(500, w.Code)
+		c.Zero(disbursement.calls.Load())
+
+		// El préstamo sigue en revisión y asignado al analista
+		loan, err := test.GetTestLoan(DB, 1)
+		c.NoError(err)
+		c.Equal(string(models.LoanStatusManualReview), loan.Status)
+		c.NotNil(loan.ReviewClaimedBy)
+
+		var actions int64
+		c.NoError(DB.Model(&models.LoanReviewAction{}).Where("loan_id = ?", 1).Count(&actions).Error)
+		c.Equal(int64(1), actions)
+
+		// Sin la falla la misma decisión se confirma y desembolsa
+		w = test.MakePostRequest(newReviewApp(t, APP.Repositories.LoanReview, disbursement), "/loan-api/api/v1/review/loans/1/approve", map[string]interface{}{
+			"comment": "Ingresos verificados",
+		}, headers)
+		c.Equal(200, w.Code)
+		c.Equal(int64(1), disbursement.calls.Load())
+
+		loan, err = test.GetTestLoan(DB, 1)
+		c.NoError(err)
+		c.Equal(string(models.LoanStatusApproved), loan.Status)
+		c.True(loan.AmountApproved.Equal(decimal.NewFromInt(2000000)))
+		c.NoError(DB.Model(&models.LoanReviewAction{}).Where("loan_id = ?", 1).Count(&actions).Error)
+		c.Equal(int64(2), actions)
+	})
+}
+
+func TestLoanService_Concurrency(t *testing.T) {
+	c := require.New(t)
+
+	t.Run("Debería no aceptar la contraoferta si el préstamo expiró durante la aceptación", func(t *testing.T) {
+		test.LoadTestData(DB)
+		offers := setLoanWithOffers(t, 1, time.Now().Add(72*time.Hour))
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		disbursement := &countingDisbursementProvider{DisbursementProvider: services.NewSimulatedDisbursementProvider()}
+		loanRepo := &failingLoanRepository{LoanRepository: APP.Repositories.Loan, failOn: "ConcurrentExpiration"}
+		w := test.MakePostRequest(newLoanApp(t, loanRepo, disbursement), fmt.Sprintf("/loan-api/api/v1/loans/1/offers/%d/accept", offers[0].ID), nil, headers)
+		c.Equal(409, w.Code)
+		c.Zero(disbursement.calls.Load())
+
+		// Ninguna contraoferta queda respondida sobre el préstamo expirado
+		var stored []models.LoanOffer
+		c.NoError(DB.Where("loan_id = ?", 1).Find(&stored).Error)
+		c.Len(stored, 2)
+		for _, offer := range stored {
+			c.Equal(models.LoanOfferStatusPending, offer.Status)
+			c.Nil(offer.RespondedAt)
+		}
+
+		loan, err := test.GetTestLoan(DB, 1)
+		c.NoError(err)
+		c.Equal(string(models.LoanStatusExpired), loan.Status)
+	})
+
+	t.Run("Debería no rechazar la contraoferta si el préstamo expiró durante el rechazo", func(t *testing.T) {
+		test.LoadTestData(DB)
+		offers := setLoanWithOffers(t, 1, time.Now().Add(72*time.Hour))
+		// Con la otra contraoferta respondida, rechazar esta rechaza el préstamo
+		c.NoError(DB.Model(&offers[1]).Update("status", models.LoanOfferStatusDeclined).Error)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+
+		loanRepo := &failingLoanRepository{LoanRepository: APP.Repositories.Loan, failOn: "ConcurrentExpiration"}
+		w := test.MakePostRequest(newLoanApp(t, loanRepo, services.NewSimulatedDisbursementProvider()), fmt.Sprintf("/loan-api/api/v1/loans/1/offers/%d/decline", offers[0].ID), nil, headers)
+		c.Equal(409, w.Code)
+
+		var offer models.LoanOffer
+		c.NoError(DB.First(&offer, offers[0].ID).Error)
+		c.Equal(models.LoanOfferStatusPending, offer.Status)
+
+		loan, err := test.GetTestLoan(DB, 1)
+		c.NoError(err)
+		c.Equal(string(models.LoanStatusExpired), loan.Status)
+	})
+
+	t.Run("Debería responder 409 sin desembolsar si otra operación modificó el préstamo", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		completeLoanForDecision(t, headers, "5000000", "2000000", "2000000")
+
+		disbursement := &countingDisbursementProvider{DisbursementProvider: services.NewSimulatedDisbursementProvider()}
+		loanRepo := &failingLoanRepository{LoanRepository: APP.Repositories.Loan, failOn: "ConcurrentUpdate"}
+		w := test.MakePostRequest(newLoanApp(t, loanRepo, disbursement), "/loan-api/api/v1/loans/1/decision", nil, headers)
+		c.Equal(409, w.Code)
+
+		var response map[string]interface{}
+		c.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		errorData := response["error"].(map[string]interface{})
+		c.Equal(app_error.ErrLoanConflict.Message, errorData["message"])
+		c.Zero(disbursement.calls.Load())
+
+		loan, err := test.GetTestLoan(DB, 1)
+		c.NoError(err)
+		c.Equal("completed", loan.Status)
+	})
+
+	t.Run("Debería responder 409 al guardar datos si otra operación modificó el préstamo", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		w := test.MakePostRequest(newFailingApp(t, "ConcurrentUpdate"), "/loan-api/api/v1/loans/data", completeLoanData("5000000", "2000000", "2000000"), headers)
+		c.Equal(409, w.Code)
+
+		var data int64
+		c.NoError(DB.Model(&models.LoanData{}).Where("loan_id = ?", 1).Count(&data).Error)
+		c.Zero(data)
+	})
+
+	t.Run("Debería desembolsar una sola vez ante decisiones simultáneas", func(t *testing.T) {
+		test.LoadTestData(DB)
+
+		headers := map[string]string{
+			"Authorization": loginAndGetToken(t, "juan@example.com", "password123!"),
+			"X-Tenant-ID":   "1",
+		}
+		completeLoanForDecision(t, headers, "5000000", "2000000", "2000000")
+
+		disbursement := &countingDisbursementProvider{DisbursementProvider: services.NewSimulatedDisbursementProvider()}
+		container := newLoanApp(t, nil, disbursement)
+
+		const requests = 8
+		codes := make([]int, requests)
+		var wg sync.WaitGroup
+		for i := 0; i < requests; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				codes[i] = test.MakePostRequest(container, "/loan-api/api/v1/loans/1/decision", nil, headers).Code
+			}(i)
+		}
+		wg.Wait()
+
+		succeeded := 0
+		for _, code := range codes {
+			if code == 200 {
+				succeeded++
+				continue
+			}
+			// Pierden por conflicto de versión o porque leen el préstamo ya decidido
+			c.Equal(409, code)
+		}
+		c.Equal(1, succeeded, codes)
+		c.Equal(int64(1), disbursement.calls.Load())
+
+		loan, err := test.GetTestLoan(DB, 1)
+		c.NoError(err)
+		c.Equal("approved", loan.Status)
+		c.Contains(loan.Observation, "Desembolso realizado exitosamente")
+	})
+}
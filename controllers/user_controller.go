@@ -5,7 +5,7 @@ import (
 	"loan-api/models"
 	"loan-api/services"
 	"loan-api/utils"
-	"log"
+	"log/slog"
 
 	"github.com/gin-gonic/gin"
 )
@@ -35,10 +35,11 @@ func NewUserController(userService services.UserService, cfg *config.Config) *Us
 // @Success 201 {object} utils.APIResponse{data=models.UserResponse}
 // @Failure 400 {object} utils.APIResponse
 // @Failure 409 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
 // @Failure 500 {object} utils.APIResponse
 // @Router /auth/register [post]
 func (ctrl *UserController) RegisterUser(c *gin.Context) {
-	log.Println("UserController::RegisterUser was invoke")
+	slog.DebugContext(c.Request.Context(), "UserController::RegisterUser was invoked")
 
 	var req models.RegisterRequest
 
@@ -60,7 +61,7 @@ func (ctrl *UserController) RegisterUser(c *gin.Context) {
 	}
 
 	// Registrar usuario con tenant_id
-	user, err := ctrl.userService.RegisterUser(&req, tenantIDUint)
+	user, err := ctrl.userService.RegisterUser(c.Request.Context(), &req, tenantIDUint)
 	if err != nil {
 		utils.ErrorResponse(c, err)
 		return
@@ -81,10 +82,11 @@ func (ctrl *UserController) RegisterUser(c *gin.Context) {
 // @Success 200 {object} utils.APIResponse{data=models.LoginResponse}
 // @Failure 400 {object} utils.APIResponse
 // @Failure 401 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
 // @Failure 500 {object} utils.APIResponse
 // @Router /auth/login [post]
 func (ctrl *UserController) Login(c *gin.Context) {
-	log.Println("UserController::Login was invoke")
+	slog.DebugContext(c.Request.Context(), "UserController::Login was invoked")
 
 	var req models.LoginRequest
 
@@ -106,7 +108,7 @@ func (ctrl *UserController) Login(c *gin.Context) {
 	}
 
 	// Autenticar usuario con validación de tenant
-	response, err := ctrl.userService.Login(&req, tenantIDUint, ctrl.config)
+	response, err := ctrl.userService.Login(c.Request.Context(), &req, tenantIDUint, ctrl.config)
 	if err != nil {
 		utils.ErrorResponse(c, err)
 		return
@@ -6,8 +6,8 @@ import (
 	"os"
 	"testing"
 
+	"loan-api/app"
 	"loan-api/config"
-	"loan-api/database"
 	"loan-api/models"
 	"loan-api/test"
 
@@ -16,23 +16,34 @@ import (
 )
 
 var (
-	DB     *gorm.DB
-	CONFIG config.Config
+	DB  *gorm.DB
+	APP *app.Container
 )
 
 func TestMain(m *testing.M) {
+	// Sin DB_DRIVER explícito las pruebas corren en proceso contra SQLite en memoria
+	if os.Getenv("DB_DRIVER") == "" {
+		os.Setenv("DB_DRIVER", config.DBDriverSQLite)
+		os.Setenv("DB_NAME", config.SQLiteInMemory)
+	}
+	// Las pruebas comparten la aplicación y el cliente; las de límite de solicitudes lo activan en su contenedor
+	os.Setenv("RATE_LIMIT_ENABLED", "false")
+
 	cfg, err := config.LoadConfig("../")
 	if err != nil {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
-	CONFIG = cfg
-	database.Connect(&cfg)
-	DB = database.DB
+	APP, err = test.NewApp(cfg)
+	if err != nil {
+		log.Fatalf("Error creating app: %v", err)
+	}
+	DB = APP.DB
 
 	code := m.Run()
 
 	test.ClearDatabase(DB)
+	APP.Close()
 
 	os.Exit(code)
 }
@@ -46,6 +57,8 @@ func TestUserController_RegisterUser(t *testing.T) {
 	}
 
 	t.Run("Debería registrar usuario exitosamente con datos válidos", func(t *testing.T) {
+		// Partir sin los usuarios que otras pruebas dejan cargados
+		test.ClearTestData(DB)
 
 		// Datos de prueba
 		email := "test@example.com"
@@ -73,7 +86,7 @@ func TestUserController_RegisterUser(t *testing.T) {
 		}
 
 		// Realizar la petición POST
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/auth/register", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/auth/register", requestBody, headers)
 
 		// Verificar código de respuesta exitoso
 		c.Equal(201, w.Code)
@@ -120,7 +133,7 @@ func TestUserController_RegisterUser(t *testing.T) {
 			"password_confirmation": "Password123!",
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/auth/register", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/auth/register", requestBody, headers)
 
 		c.Equal(409, w.Code)
 
@@ -145,7 +158,7 @@ func TestUserController_RegisterUser(t *testing.T) {
 			"password_confirmation": "Password123!",
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/auth/register", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/auth/register", requestBody, headers)
 
 		c.Equal(400, w.Code)
 
@@ -169,7 +182,7 @@ func TestUserController_RegisterUser(t *testing.T) {
 			"password_confirmation": "DifferentPassword123!", // Contraseñas diferentes
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/auth/register", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/auth/register", requestBody, headers)
 
 		c.Equal(400, w.Code)
 
@@ -184,7 +197,7 @@ func TestUserController_RegisterUser(t *testing.T) {
 	t.Run("Debería fallar con formato JSON inválido", func(t *testing.T) {
 		invalidJSON := "invalid_json_string"
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/auth/register", invalidJSON, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/auth/register", invalidJSON, headers)
 
 		c.Equal(400, w.Code)
 
@@ -217,7 +230,7 @@ func TestUserController_Login(t *testing.T) {
 		}
 
 		// Realizar la petición POST
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/auth/login", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/auth/login", requestBody, headers)
 
 		// Verificar código de respuesta exitoso
 		c.Equal(200, w.Code)
@@ -246,7 +259,7 @@ func TestUserController_Login(t *testing.T) {
 			"password": "wrongpassword",
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/auth/login", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/auth/login", requestBody, headers)
 
 		c.Equal(400, w.Code)
 
@@ -260,8 +273,8 @@ func TestUserController_Login(t *testing.T) {
 	})
 
 	t.Run("Debería fallar con usuario inexistente", func(t *testing.T) {
-		// Limpiar base de datos
-		test.ClearDatabase(DB)
+		// Limpiar los usuarios conservando el tenant del seed
+		test.ClearTestData(DB)
 
 		// Datos de login con email inexistente
 		requestBody := map[string]interface{}{
@@ -269,7 +282,7 @@ func TestUserController_Login(t *testing.T) {
 			"password": "password123!",
 		}
 
-		w := test.MakePostRequest(CONFIG, "/loan-api/api/v1/auth/login", requestBody, headers)
+		w := test.MakePostRequest(APP, "/loan-api/api/v1/auth/login", requestBody, headers)
 
 		c.Equal(400, w.Code)
 
@@ -279,6 +292,6 @@ func TestUserController_Login(t *testing.T) {
 		c.Contains(response, "error")
 
 		errorData := response["error"].(map[string]interface{})
-		c.Contains(errorData["message"], "Email o contraseña incorrectos")
+		c.Contains(errorData["details"], "Email o contraseña incorrectos")
 	})
 }
@@ -3,57 +3,61 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"loan-api/config"
+	"loan-api/logging"
 
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
-
-// Connect inicializa la conexión a la base de datos con configuración dinámica
-func Connect(cfg *config.Config) {
-	if DB != nil {
-		return
-	}
-
-	dsn := buildDSN(cfg)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(getLogLevel(cfg)),
+// Open abre una conexión según DB_DRIVER y configura el pool. Cada llamada retorna una conexión
+// independiente que el llamador debe cerrar. No modifica el esquema: las migraciones se aplican
+// con el subcomando "migrate"
+func Open(cfg *config.Config) (*gorm.DB, error) {
+	db, err := gorm.Open(buildDialector(cfg), &gorm.Config{
+		Logger: logger.New(logging.GormWriter{}, logger.Config{
+			SlowThreshold: 200 * time.Millisecond,
+			LogLevel:      getLogLevel(cfg),
+			// Las consultas se registran sin los valores, que pueden contener datos personales
+			ParameterizedQueries: true,
+		}),
 	})
 	if err != nil {
-		log.Fatal("❌ Error al conectar a la base de datos:", err)
+		return nil, err
 	}
 
-	DB = db
-
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatal("❌ Error al obtener DB SQL:", err)
+		return nil, fmt.Errorf("error al obtener DB SQL: %w", err)
 	}
 
 	configureConnectionPool(sqlDB, cfg)
 
 	if err := sqlDB.Ping(); err != nil {
-		log.Fatal("❌ Error al hacer ping a la base de datos:", err)
+		return nil, fmt.Errorf("error al hacer ping a la base de datos: %w", err)
 	}
 
-	log.Println("✅ Conexión a la base de datos establecida")
+	slog.Info("conexión a la base de datos establecida", "db_driver", cfg.DBDriver)
 
-	if err := Migrate(); err != nil {
-		log.Fatal("❌ Error al ejecutar migraciones:", err)
-	}
+	return db, nil
 }
 
-// buildDSN genera el Data Source Name para MySQL
-func buildDSN(cfg *config.Config) string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName,
-	)
+// buildDialector retorna el dialecto de GORM correspondiente a DB_DRIVER
+func buildDialector(cfg *config.Config) gorm.Dialector {
+	switch cfg.DBDriver {
+	case config.DBDriverPostgres:
+		return postgres.Open(cfg.GetDSN())
+	case config.DBDriverSQLite:
+		return sqlite.Open(cfg.GetDSN())
+	default:
+		return mysql.Open(cfg.GetDSN())
+	}
 }
 
 // getLogLevel retorna el nivel de logging según el entorno
@@ -66,6 +70,15 @@ func getLogLevel(cfg *config.Config) logger.LogLevel {
 
 // configureConnectionPool configura los parámetros del pool de conexiones
 func configureConnectionPool(sqlDB *sql.DB, cfg *config.Config) {
+	// SQLite admite un único escritor: una sola conexión evita errores de bloqueo,
+	// y en memoria mantiene viva la base mientras el proceso esté activo
+	if cfg.DBDriver == config.DBDriverSQLite {
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		return
+	}
+
 	if cfg.IsDevelopment() {
 		sqlDB.SetMaxIdleConns(5)
 		sqlDB.SetMaxOpenConns(10)
@@ -76,20 +89,3 @@ func configureConnectionPool(sqlDB *sql.DB, cfg *config.Config) {
 		sqlDB.SetConnMaxLifetime(2 * time.Hour)
 	}
 }
-
-// GetDB retorna la instancia GORM de la base de datos
-func GetDB() *gorm.DB {
-	return DB
-}
-
-// CloseDB cierra la conexión activa
-func CloseDB() error {
-	if DB != nil {
-		sqlDB, err := DB.DB()
-		if err != nil {
-			return err
-		}
-		return sqlDB.Close()
-	}
-	return nil
-}
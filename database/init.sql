@@ -12,5 +12,5 @@ USE loan_api;
 -- Verificar que la base de datos fue creada correctamente
 SELECT 'Base de datos loan_api creada correctamente' AS mensaje;
 
--- Las tablas se crearán automáticamente cuando ejecutes la aplicación
--- gracias a la función AutoMigrate de GORM 
\ No newline at end of file
+-- Las tablas se crean con las migraciones versionadas:
+--   go run . migrate up 
\ No newline at end of file
@@ -0,0 +1,263 @@
+-- Esquema inicial: tablas, índices y llaves foráneas de la aplicación.
+-- Es idempotente para que las bases creadas antes con AutoMigrate adopten esta versión.
+
+CREATE TABLE IF NOT EXISTS `tenants` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `name` varchar(255) NOT NULL,
+    `code` varchar(50) NOT NULL,
+    `description` text,
+    `is_active` boolean DEFAULT true,
+    `config` json,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_tenants_code` (`code`),
+    INDEX `idx_tenants_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `users` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `tenant_id` bigint unsigned NOT NULL,
+    `name` varchar(100) NOT NULL,
+    `email` varchar(100) NOT NULL,
+    `phone` varchar(20) NOT NULL,
+    `document_type` varchar(20) NOT NULL,
+    `document_number` varchar(20) NOT NULL,
+    `password` varchar(255) NOT NULL,
+    `role` varchar(20) NOT NULL DEFAULT 'applicant',
+    `income` decimal(15, 2) DEFAULT 0,
+    `ip` varchar(45),
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_users_tenant_id` (`tenant_id`),
+    INDEX `idx_users_name` (`name`),
+    UNIQUE INDEX `idx_users_email` (`email`),
+    UNIQUE INDEX `idx_users_document_number` (`document_number`),
+    INDEX `idx_users_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_users_tenant` FOREIGN KEY (`tenant_id`) REFERENCES `tenants`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `loan_types` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `tenant_id` bigint unsigned NOT NULL,
+    `name` varchar(255) NOT NULL,
+    `code` varchar(50) NOT NULL,
+    `description` text,
+    `is_active` boolean DEFAULT true,
+    `min_amount` decimal(15, 2) DEFAULT 0,
+    `max_amount` decimal(15, 2) DEFAULT 0,
+    `application_ttl_hours` bigint DEFAULT 0,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_loan_types_tenant_id` (`tenant_id`),
+    INDEX `idx_loan_types_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_tenants_loan_types` FOREIGN KEY (`tenant_id`) REFERENCES `tenants`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `loan_type_versions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `loan_type_id` bigint unsigned NOT NULL,
+    `version` varchar(50) NOT NULL,
+    `description` text,
+    `is_active` boolean DEFAULT true,
+    `is_default` boolean DEFAULT false,
+    `config` json,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_loan_type_versions_loan_type_id` (`loan_type_id`),
+    INDEX `idx_loan_type_versions_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_loan_types_versions` FOREIGN KEY (`loan_type_id`) REFERENCES `loan_types`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `loan_type_forms` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `loan_type_version_id` bigint unsigned NOT NULL,
+    `label` varchar(255) NOT NULL,
+    `code` varchar(50) NOT NULL,
+    `description` text,
+    `order` bigint DEFAULT 0,
+    `is_required` boolean DEFAULT false,
+    `is_active` boolean DEFAULT true,
+    `config` json,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_loan_type_forms_loan_type_version_id` (`loan_type_version_id`),
+    INDEX `idx_loan_type_forms_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_loan_type_versions_forms` FOREIGN KEY (`loan_type_version_id`) REFERENCES `loan_type_versions`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `loan_type_version_form_inputs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `loan_type_form_id` bigint unsigned,
+    `label` varchar(255) NOT NULL,
+    `code` varchar(50) NOT NULL,
+    `input_type` varchar(50) NOT NULL,
+    `placeholder` varchar(255),
+    `default_value` text,
+    `validation_rules` json,
+    `options` json,
+    `order` bigint DEFAULT 0,
+    `is_required` boolean DEFAULT false,
+    `is_active` boolean DEFAULT true,
+    `config` json,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_loan_type_version_form_inputs_loan_type_form_id` (`loan_type_form_id`),
+    INDEX `idx_loan_type_version_form_inputs_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_loan_type_forms_form_inputs` FOREIGN KEY (`loan_type_form_id`) REFERENCES `loan_type_forms`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `loans` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `loan_type_id` bigint unsigned NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `status` varchar(50) DEFAULT 'pending',
+    `observation` text,
+    `amount_approved` decimal(13, 2) DEFAULT '0',
+    `credit_score` bigint DEFAULT 0,
+    `identity_verified` boolean DEFAULT false,
+    `identity_match` varchar(20),
+    `affordability` text,
+    `pricing` text,
+    `review_claimed_by` bigint unsigned,
+    `review_claimed_at` datetime(3) NULL,
+    `review_claim_expires_at` datetime(3) NULL,
+    `cancelled_by` bigint unsigned,
+    `cancelled_at` datetime(3) NULL,
+    `cancellation_reason` text,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_loans_loan_type_id` (`loan_type_id`),
+    INDEX `idx_loans_user_id` (`user_id`),
+    INDEX `idx_loans_status` (`status`),
+    INDEX `idx_loans_amount_approved` (`amount_approved`),
+    INDEX `idx_loans_credit_score` (`credit_score`),
+    INDEX `idx_loans_review_claimed_by` (`review_claimed_by`),
+    INDEX `idx_loans_created_at` (`created_at`),
+    INDEX `idx_loans_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_users_loans` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `fk_loans_loan_type` FOREIGN KEY (`loan_type_id`) REFERENCES `loan_types`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `loan_data` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `loan_id` bigint unsigned NOT NULL,
+    `form_id` bigint unsigned NOT NULL,
+    `key` varchar(255) NOT NULL,
+    `value` text,
+    `index` bigint unsigned DEFAULT 0,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_loan_data_loan_id` (`loan_id`),
+    UNIQUE INDEX `idx_loan_data_entry` (`loan_id`,`form_id`,`key`,`index`),
+    INDEX `idx_loan_data_form_id` (`form_id`),
+    INDEX `idx_loan_data_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_loan_data_form` FOREIGN KEY (`form_id`) REFERENCES `loan_type_forms`(`id`),
+    CONSTRAINT `fk_loans_data` FOREIGN KEY (`loan_id`) REFERENCES `loans`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `loan_review_actions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `loan_id` bigint unsigned NOT NULL,
+    `analyst_id` bigint unsigned NOT NULL,
+    `action` varchar(30) NOT NULL,
+    `comment` text,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_loan_review_actions_loan_id` (`loan_id`),
+    INDEX `idx_loan_review_actions_analyst_id` (`analyst_id`),
+    CONSTRAINT `fk_loan_review_actions_loan` FOREIGN KEY (`loan_id`) REFERENCES `loans`(`id`),
+    CONSTRAINT `fk_loan_review_actions_analyst` FOREIGN KEY (`analyst_id`) REFERENCES `users`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `loan_offers` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `loan_id` bigint unsigned NOT NULL,
+    `amount` decimal(13, 2) NOT NULL,
+    `term_months` bigint NOT NULL,
+    `monthly_payment` decimal(13, 2) DEFAULT '0',
+    `status` varchar(20) NOT NULL DEFAULT 'pending',
+    `expires_at` datetime(3) NOT NULL,
+    `responded_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_loan_offers_loan_id` (`loan_id`),
+    CONSTRAINT `fk_loans_offers` FOREIGN KEY (`loan_id`) REFERENCES `loans`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `loan_parties` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `loan_id` bigint unsigned NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `role` varchar(20) NOT NULL,
+    `status` varchar(20) NOT NULL DEFAULT 'invited',
+    `invited_by` bigint unsigned,
+    `monthly_income` decimal(13, 2) DEFAULT '0',
+    `monthly_expenses` decimal(13, 2) DEFAULT '0',
+    `credit_score` bigint,
+    `identity_verified` boolean,
+    `identity_match` varchar(20),
+    `consented_at` datetime(3) NULL,
+    `responded_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_loan_parties_loan_id` (`loan_id`),
+    UNIQUE INDEX `idx_loan_party` (`loan_id`,`user_id`),
+    INDEX `idx_loan_parties_user_id` (`user_id`),
+    CONSTRAINT `fk_loan_parties_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `fk_loans_parties` FOREIGN KEY (`loan_id`) REFERENCES `loans`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `loan_exports` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `tenant_id` bigint unsigned NOT NULL,
+    `requested_by` bigint unsigned NOT NULL,
+    `format` varchar(10) NOT NULL,
+    `filters` text,
+    `status` varchar(20) NOT NULL,
+    `row_count` bigint,
+    `error` text,
+    `started_at` datetime(3) NOT NULL,
+    `finished_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_loan_exports_tenant_id` (`tenant_id`),
+    INDEX `idx_loan_exports_requested_by` (`requested_by`)
+);
+
+CREATE TABLE IF NOT EXISTS `job_leases` (
+    `job_name` varchar(100),
+    `owner` varchar(255),
+    `locked_until` datetime(3) NOT NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`job_name`)
+);
+
+CREATE TABLE IF NOT EXISTS `job_runs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `job_name` varchar(100) NOT NULL,
+    `owner` varchar(255),
+    `status` varchar(20) NOT NULL,
+    `result` text,
+    `error` text,
+    `started_at` datetime(3) NOT NULL,
+    `finished_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_job_runs_job_name` (`job_name`)
+);
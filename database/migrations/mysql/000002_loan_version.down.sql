@@ -0,0 +1,2 @@
+-- Elimina la versión de los préstamos.
+ALTER TABLE `loans` DROP COLUMN `version`;
@@ -0,0 +1,19 @@
+-- Claves de idempotencia de las solicitudes POST con la respuesta guardada para repetirla
+CREATE TABLE IF NOT EXISTS `idempotency_keys` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `tenant_id` bigint unsigned NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `idempotency_key` varchar(255) NOT NULL,
+    `fingerprint` varchar(64) NOT NULL,
+    `status` varchar(20) NOT NULL,
+    `response_status` bigint,
+    `response_content_type` varchar(255),
+    `response_body` longtext,
+    `locked_until` datetime(3) NOT NULL,
+    `expires_at` datetime(3) NOT NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_idempotency_keys_scope` (`tenant_id`, `user_id`, `idempotency_key`),
+    INDEX `idx_idempotency_keys_expires_at` (`expires_at`)
+);
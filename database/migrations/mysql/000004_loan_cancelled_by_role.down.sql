@@ -0,0 +1,2 @@
+-- Elimina el rol de quien canceló el préstamo.
+ALTER TABLE `loans` DROP COLUMN `cancelled_by_role`;
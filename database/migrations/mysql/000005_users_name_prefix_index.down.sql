@@ -0,0 +1 @@
+-- Sin cambios que revertir.
@@ -0,0 +1 @@
+-- La búsqueda por prefijo del nombre usa idx_users_name: la collation por defecto no distingue mayúsculas.
@@ -0,0 +1,263 @@
+-- Esquema inicial: tablas, índices y llaves foráneas de la aplicación.
+-- Es idempotente para que las bases creadas antes con AutoMigrate adopten esta versión.
+
+CREATE TABLE IF NOT EXISTS "tenants" (
+    "id" bigserial,
+    "name" varchar(255) NOT NULL,
+    "code" varchar(50) NOT NULL,
+    "description" text,
+    "is_active" boolean DEFAULT true,
+    "config" jsonb,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tenants_deleted_at" ON "tenants" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tenants_code" ON "tenants" ("code");
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "tenant_id" bigint NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "email" varchar(100) NOT NULL,
+    "phone" varchar(20) NOT NULL,
+    "document_type" varchar(20) NOT NULL,
+    "document_number" varchar(20) NOT NULL,
+    "password" varchar(255) NOT NULL,
+    "role" varchar(20) NOT NULL DEFAULT 'applicant',
+    "income" numeric(15, 2) DEFAULT 0,
+    "ip" varchar(45),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_document_number" ON "users" ("document_number");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+CREATE INDEX IF NOT EXISTS "idx_users_name" ON "users" ("name");
+CREATE INDEX IF NOT EXISTS "idx_users_tenant_id" ON "users" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "loan_types" (
+    "id" bigserial,
+    "tenant_id" bigint NOT NULL,
+    "name" varchar(255) NOT NULL,
+    "code" varchar(50) NOT NULL,
+    "description" text,
+    "is_active" boolean DEFAULT true,
+    "min_amount" numeric(15, 2) DEFAULT 0,
+    "max_amount" numeric(15, 2) DEFAULT 0,
+    "application_ttl_hours" bigint DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_tenants_loan_types" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loan_types_deleted_at" ON "loan_types" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_loan_types_tenant_id" ON "loan_types" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "loan_type_versions" (
+    "id" bigserial,
+    "loan_type_id" bigint NOT NULL,
+    "version" varchar(50) NOT NULL,
+    "description" text,
+    "is_active" boolean DEFAULT true,
+    "is_default" boolean DEFAULT false,
+    "config" jsonb,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_loan_types_versions" FOREIGN KEY ("loan_type_id") REFERENCES "loan_types"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loan_type_versions_deleted_at" ON "loan_type_versions" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_loan_type_versions_loan_type_id" ON "loan_type_versions" ("loan_type_id");
+
+CREATE TABLE IF NOT EXISTS "loan_type_forms" (
+    "id" bigserial,
+    "loan_type_version_id" bigint NOT NULL,
+    "label" varchar(255) NOT NULL,
+    "code" varchar(50) NOT NULL,
+    "description" text,
+    "order" bigint DEFAULT 0,
+    "is_required" boolean DEFAULT false,
+    "is_active" boolean DEFAULT true,
+    "config" jsonb,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_loan_type_versions_forms" FOREIGN KEY ("loan_type_version_id") REFERENCES "loan_type_versions"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loan_type_forms_deleted_at" ON "loan_type_forms" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_loan_type_forms_loan_type_version_id" ON "loan_type_forms" ("loan_type_version_id");
+
+CREATE TABLE IF NOT EXISTS "loan_type_version_form_inputs" (
+    "id" bigserial,
+    "loan_type_form_id" bigint,
+    "label" varchar(255) NOT NULL,
+    "code" varchar(50) NOT NULL,
+    "input_type" varchar(50) NOT NULL,
+    "placeholder" varchar(255),
+    "default_value" text,
+    "validation_rules" jsonb,
+    "options" jsonb,
+    "order" bigint DEFAULT 0,
+    "is_required" boolean DEFAULT false,
+    "is_active" boolean DEFAULT true,
+    "config" jsonb,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_loan_type_forms_form_inputs" FOREIGN KEY ("loan_type_form_id") REFERENCES "loan_type_forms"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loan_type_version_form_inputs_deleted_at" ON "loan_type_version_form_inputs" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_loan_type_version_form_inputs_loan_type_form_id" ON "loan_type_version_form_inputs" ("loan_type_form_id");
+
+CREATE TABLE IF NOT EXISTS "loans" (
+    "id" bigserial,
+    "loan_type_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "status" varchar(50) DEFAULT 'pending',
+    "observation" text,
+    "amount_approved" numeric(13, 2) DEFAULT '0',
+    "credit_score" bigint DEFAULT 0,
+    "identity_verified" boolean DEFAULT false,
+    "identity_match" varchar(20),
+    "affordability" text,
+    "pricing" text,
+    "review_claimed_by" bigint,
+    "review_claimed_at" timestamptz,
+    "review_claim_expires_at" timestamptz,
+    "cancelled_by" bigint,
+    "cancelled_at" timestamptz,
+    "cancellation_reason" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_loans" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_loans_loan_type" FOREIGN KEY ("loan_type_id") REFERENCES "loan_types"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loans_deleted_at" ON "loans" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_loans_created_at" ON "loans" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_loans_review_claimed_by" ON "loans" ("review_claimed_by");
+CREATE INDEX IF NOT EXISTS "idx_loans_credit_score" ON "loans" ("credit_score");
+CREATE INDEX IF NOT EXISTS "idx_loans_amount_approved" ON "loans" ("amount_approved");
+CREATE INDEX IF NOT EXISTS "idx_loans_status" ON "loans" ("status");
+CREATE INDEX IF NOT EXISTS "idx_loans_user_id" ON "loans" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_loans_loan_type_id" ON "loans" ("loan_type_id");
+
+CREATE TABLE IF NOT EXISTS "loan_data" (
+    "id" bigserial,
+    "loan_id" bigint NOT NULL,
+    "form_id" bigint NOT NULL,
+    "key" varchar(255) NOT NULL,
+    "value" text,
+    "index" bigint DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_loan_data_form" FOREIGN KEY ("form_id") REFERENCES "loan_type_forms"("id"),
+    CONSTRAINT "fk_loans_data" FOREIGN KEY ("loan_id") REFERENCES "loans"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loan_data_deleted_at" ON "loan_data" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_loan_data_form_id" ON "loan_data" ("form_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_loan_data_entry" ON "loan_data" ("loan_id","form_id","key","index");
+CREATE INDEX IF NOT EXISTS "idx_loan_data_loan_id" ON "loan_data" ("loan_id");
+
+CREATE TABLE IF NOT EXISTS "loan_review_actions" (
+    "id" bigserial,
+    "loan_id" bigint NOT NULL,
+    "analyst_id" bigint NOT NULL,
+    "action" varchar(30) NOT NULL,
+    "comment" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_loan_review_actions_loan" FOREIGN KEY ("loan_id") REFERENCES "loans"("id"),
+    CONSTRAINT "fk_loan_review_actions_analyst" FOREIGN KEY ("analyst_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loan_review_actions_analyst_id" ON "loan_review_actions" ("analyst_id");
+CREATE INDEX IF NOT EXISTS "idx_loan_review_actions_loan_id" ON "loan_review_actions" ("loan_id");
+
+CREATE TABLE IF NOT EXISTS "loan_offers" (
+    "id" bigserial,
+    "loan_id" bigint NOT NULL,
+    "amount" numeric(13, 2) NOT NULL,
+    "term_months" bigint NOT NULL,
+    "monthly_payment" numeric(13, 2) DEFAULT '0',
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "expires_at" timestamptz NOT NULL,
+    "responded_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_loans_offers" FOREIGN KEY ("loan_id") REFERENCES "loans"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loan_offers_loan_id" ON "loan_offers" ("loan_id");
+
+CREATE TABLE IF NOT EXISTS "loan_parties" (
+    "id" bigserial,
+    "loan_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "role" varchar(20) NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'invited',
+    "invited_by" bigint,
+    "monthly_income" numeric(13, 2) DEFAULT '0',
+    "monthly_expenses" numeric(13, 2) DEFAULT '0',
+    "credit_score" bigint,
+    "identity_verified" boolean,
+    "identity_match" varchar(20),
+    "consented_at" timestamptz,
+    "responded_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_loan_parties_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_loans_parties" FOREIGN KEY ("loan_id") REFERENCES "loans"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loan_parties_user_id" ON "loan_parties" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_loan_party" ON "loan_parties" ("loan_id","user_id");
+CREATE INDEX IF NOT EXISTS "idx_loan_parties_loan_id" ON "loan_parties" ("loan_id");
+
+CREATE TABLE IF NOT EXISTS "loan_exports" (
+    "id" bigserial,
+    "tenant_id" bigint NOT NULL,
+    "requested_by" bigint NOT NULL,
+    "format" varchar(10) NOT NULL,
+    "filters" text,
+    "status" varchar(20) NOT NULL,
+    "row_count" bigint,
+    "error" text,
+    "started_at" timestamptz NOT NULL,
+    "finished_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loan_exports_requested_by" ON "loan_exports" ("requested_by");
+CREATE INDEX IF NOT EXISTS "idx_loan_exports_tenant_id" ON "loan_exports" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "job_leases" (
+    "job_name" varchar(100),
+    "owner" varchar(255),
+    "locked_until" timestamptz NOT NULL,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("job_name")
+);
+
+CREATE TABLE IF NOT EXISTS "job_runs" (
+    "id" bigserial,
+    "job_name" varchar(100) NOT NULL,
+    "owner" varchar(255),
+    "status" varchar(20) NOT NULL,
+    "result" text,
+    "error" text,
+    "started_at" timestamptz NOT NULL,
+    "finished_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_job_runs_job_name" ON "job_runs" ("job_name");
@@ -0,0 +1,19 @@
+-- Claves de idempotencia de las solicitudes POST con la respuesta guardada para repetirla
+CREATE TABLE IF NOT EXISTS "idempotency_keys" (
+    "id" bigserial,
+    "tenant_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "idempotency_key" varchar(255) NOT NULL,
+    "fingerprint" varchar(64) NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "response_status" bigint,
+    "response_content_type" varchar(255),
+    "response_body" text,
+    "locked_until" timestamptz NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_idempotency_keys_scope" ON "idempotency_keys" ("tenant_id", "user_id", "idempotency_key");
+CREATE INDEX IF NOT EXISTS "idx_idempotency_keys_expires_at" ON "idempotency_keys" ("expires_at");
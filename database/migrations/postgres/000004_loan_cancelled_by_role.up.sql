@@ -0,0 +1,3 @@
+-- Rol de quien canceló el préstamo: solicitante o staff
+ALTER TABLE "loans" ADD COLUMN "cancelled_by_role" varchar(20);
+UPDATE "loans" SET "cancelled_by_role" = CASE WHEN "cancelled_by" = "user_id" THEN 'applicant' ELSE 'staff' END WHERE "cancelled_by" IS NOT NULL;
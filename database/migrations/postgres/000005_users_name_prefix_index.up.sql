@@ -0,0 +1,2 @@
+-- Índice para la búsqueda de préstamos por prefijo del nombre del solicitante sin distinguir mayúsculas
+CREATE INDEX IF NOT EXISTS "idx_users_name_lower" ON "users" (LOWER("name") text_pattern_ops);
@@ -0,0 +1,16 @@
+-- Elimina el esquema inicial en orden inverso a sus dependencias.
+
+DROP TABLE IF EXISTS `job_runs`;
+DROP TABLE IF EXISTS `job_leases`;
+DROP TABLE IF EXISTS `loan_exports`;
+DROP TABLE IF EXISTS `loan_parties`;
+DROP TABLE IF EXISTS `loan_offers`;
+DROP TABLE IF EXISTS `loan_review_actions`;
+DROP TABLE IF EXISTS `loan_data`;
+DROP TABLE IF EXISTS `loans`;
+DROP TABLE IF EXISTS `loan_type_version_form_inputs`;
+DROP TABLE IF EXISTS `loan_type_forms`;
+DROP TABLE IF EXISTS `loan_type_versions`;
+DROP TABLE IF EXISTS `loan_types`;
+DROP TABLE IF EXISTS `users`;
+DROP TABLE IF EXISTS `tenants`;
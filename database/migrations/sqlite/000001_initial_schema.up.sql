@@ -0,0 +1,250 @@
+-- Esquema inicial: tablas, índices y llaves foráneas de la aplicación.
+-- Es idempotente para que las bases creadas antes con AutoMigrate adopten esta versión.
+
+CREATE TABLE IF NOT EXISTS `tenants` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` text NOT NULL,
+    `code` text NOT NULL,
+    `description` text,
+    `is_active` numeric DEFAULT true,
+    `config` json,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_tenants_deleted_at` ON `tenants`(`deleted_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_tenants_code` ON `tenants`(`code`);
+
+CREATE TABLE IF NOT EXISTS `users` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `tenant_id` integer NOT NULL,
+    `name` varchar(100) NOT NULL,
+    `email` varchar(100) NOT NULL,
+    `phone` varchar(20) NOT NULL,
+    `document_type` varchar(20) NOT NULL,
+    `document_number` varchar(20) NOT NULL,
+    `password` varchar(255) NOT NULL,
+    `role` varchar(20) NOT NULL DEFAULT "applicant",
+    `income` real DEFAULT 0,
+    `ip` varchar(45),
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `fk_users_tenant` FOREIGN KEY (`tenant_id`) REFERENCES `tenants`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_users_deleted_at` ON `users`(`deleted_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_users_document_number` ON `users`(`document_number`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_users_email` ON `users`(`email`);
+CREATE INDEX IF NOT EXISTS `idx_users_name` ON `users`(`name`);
+CREATE INDEX IF NOT EXISTS `idx_users_tenant_id` ON `users`(`tenant_id`);
+
+CREATE TABLE IF NOT EXISTS `loan_types` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `tenant_id` integer NOT NULL,
+    `name` text NOT NULL,
+    `code` text NOT NULL,
+    `description` text,
+    `is_active` numeric DEFAULT true,
+    `min_amount` real DEFAULT 0,
+    `max_amount` real DEFAULT 0,
+    `application_ttl_hours` integer DEFAULT 0,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `fk_tenants_loan_types` FOREIGN KEY (`tenant_id`) REFERENCES `tenants`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_loan_types_deleted_at` ON `loan_types`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_loan_types_tenant_id` ON `loan_types`(`tenant_id`);
+
+CREATE TABLE IF NOT EXISTS `loan_type_versions` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `loan_type_id` integer NOT NULL,
+    `version` text NOT NULL,
+    `description` text,
+    `is_active` numeric DEFAULT true,
+    `is_default` numeric DEFAULT false,
+    `config` json,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `fk_loan_types_versions` FOREIGN KEY (`loan_type_id`) REFERENCES `loan_types`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_loan_type_versions_deleted_at` ON `loan_type_versions`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_loan_type_versions_loan_type_id` ON `loan_type_versions`(`loan_type_id`);
+
+CREATE TABLE IF NOT EXISTS `loan_type_forms` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `loan_type_version_id` integer NOT NULL,
+    `label` text NOT NULL,
+    `code` text NOT NULL,
+    `description` text,
+    `order` integer DEFAULT 0,
+    `is_required` numeric DEFAULT false,
+    `is_active` numeric DEFAULT true,
+    `config` json,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `fk_loan_type_versions_forms` FOREIGN KEY (`loan_type_version_id`) REFERENCES `loan_type_versions`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_loan_type_forms_deleted_at` ON `loan_type_forms`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_loan_type_forms_loan_type_version_id` ON `loan_type_forms`(`loan_type_version_id`);
+
+CREATE TABLE IF NOT EXISTS `loan_type_version_form_inputs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `loan_type_form_id` integer,
+    `label` text NOT NULL,
+    `code` text NOT NULL,
+    `input_type` text NOT NULL,
+    `placeholder` text,
+    `default_value` text,
+    `validation_rules` json,
+    `options` json,
+    `order` integer DEFAULT 0,
+    `is_required` numeric DEFAULT false,
+    `is_active` numeric DEFAULT true,
+    `config` json,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `fk_loan_type_forms_form_inputs` FOREIGN KEY (`loan_type_form_id`) REFERENCES `loan_type_forms`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_loan_type_version_form_inputs_deleted_at` ON `loan_type_version_form_inputs`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_loan_type_version_form_inputs_loan_type_form_id` ON `loan_type_version_form_inputs`(`loan_type_form_id`);
+
+CREATE TABLE IF NOT EXISTS `loans` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `loan_type_id` integer NOT NULL,
+    `user_id` integer NOT NULL,
+    `status` text DEFAULT "pending",
+    `observation` text,
+    `amount_approved` real DEFAULT "0",
+    `credit_score` integer DEFAULT 0,
+    `identity_verified` numeric DEFAULT false,
+    `identity_match` text,
+    `affordability` text,
+    `pricing` text,
+    `review_claimed_by` integer,
+    `review_claimed_at` datetime,
+    `review_claim_expires_at` datetime,
+    `cancelled_by` integer,
+    `cancelled_at` datetime,
+    `cancellation_reason` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `fk_loans_loan_type` FOREIGN KEY (`loan_type_id`) REFERENCES `loan_types`(`id`),
+    CONSTRAINT `fk_users_loans` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_loans_deleted_at` ON `loans`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_loans_created_at` ON `loans`(`created_at`);
+CREATE INDEX IF NOT EXISTS `idx_loans_review_claimed_by` ON `loans`(`review_claimed_by`);
+CREATE INDEX IF NOT EXISTS `idx_loans_credit_score` ON `loans`(`credit_score`);
+CREATE INDEX IF NOT EXISTS `idx_loans_amount_approved` ON `loans`(`amount_approved`);
+CREATE INDEX IF NOT EXISTS `idx_loans_status` ON `loans`(`status`);
+CREATE INDEX IF NOT EXISTS `idx_loans_user_id` ON `loans`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_loans_loan_type_id` ON `loans`(`loan_type_id`);
+
+CREATE TABLE IF NOT EXISTS `loan_data` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `loan_id` integer NOT NULL,
+    `form_id` integer NOT NULL,
+    `key` text NOT NULL,
+    `value` text,
+    `index` integer DEFAULT 0,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `fk_loans_data` FOREIGN KEY (`loan_id`) REFERENCES `loans`(`id`),
+    CONSTRAINT `fk_loan_data_form` FOREIGN KEY (`form_id`) REFERENCES `loan_type_forms`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_loan_data_deleted_at` ON `loan_data`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_loan_data_form_id` ON `loan_data`(`form_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_loan_data_entry` ON `loan_data`(`loan_id`,`form_id`,`key`,`index`);
+CREATE INDEX IF NOT EXISTS `idx_loan_data_loan_id` ON `loan_data`(`loan_id`);
+
+CREATE TABLE IF NOT EXISTS `loan_review_actions` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `loan_id` integer NOT NULL,
+    `analyst_id` integer NOT NULL,
+    `action` text NOT NULL,
+    `comment` text,
+    `created_at` datetime,
+    CONSTRAINT `fk_loan_review_actions_analyst` FOREIGN KEY (`analyst_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `fk_loan_review_actions_loan` FOREIGN KEY (`loan_id`) REFERENCES `loans`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_loan_review_actions_analyst_id` ON `loan_review_actions`(`analyst_id`);
+CREATE INDEX IF NOT EXISTS `idx_loan_review_actions_loan_id` ON `loan_review_actions`(`loan_id`);
+
+CREATE TABLE IF NOT EXISTS `loan_offers` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `loan_id` integer NOT NULL,
+    `amount` real NOT NULL,
+    `term_months` integer NOT NULL,
+    `monthly_payment` real DEFAULT "0",
+    `status` text NOT NULL DEFAULT "pending",
+    `expires_at` datetime NOT NULL,
+    `responded_at` datetime,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_loans_offers` FOREIGN KEY (`loan_id`) REFERENCES `loans`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_loan_offers_loan_id` ON `loan_offers`(`loan_id`);
+
+CREATE TABLE IF NOT EXISTS `loan_parties` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `loan_id` integer NOT NULL,
+    `user_id` integer NOT NULL,
+    `role` text NOT NULL,
+    `status` text NOT NULL DEFAULT "invited",
+    `invited_by` integer,
+    `monthly_income` real DEFAULT "0",
+    `monthly_expenses` real DEFAULT "0",
+    `credit_score` integer,
+    `identity_verified` numeric,
+    `identity_match` text,
+    `consented_at` datetime,
+    `responded_at` datetime,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_loan_parties_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `fk_loans_parties` FOREIGN KEY (`loan_id`) REFERENCES `loans`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_loan_parties_user_id` ON `loan_parties`(`user_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_loan_party` ON `loan_parties`(`loan_id`,`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_loan_parties_loan_id` ON `loan_parties`(`loan_id`);
+
+CREATE TABLE IF NOT EXISTS `loan_exports` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `tenant_id` integer NOT NULL,
+    `requested_by` integer NOT NULL,
+    `format` text NOT NULL,
+    `filters` text,
+    `status` text NOT NULL,
+    `row_count` integer,
+    `error` text,
+    `started_at` datetime NOT NULL,
+    `finished_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_loan_exports_requested_by` ON `loan_exports`(`requested_by`);
+CREATE INDEX IF NOT EXISTS `idx_loan_exports_tenant_id` ON `loan_exports`(`tenant_id`);
+
+CREATE TABLE IF NOT EXISTS `job_leases` (
+    `job_name` text,
+    `owner` text,
+    `locked_until` datetime NOT NULL,
+    `updated_at` datetime,
+    PRIMARY KEY (`job_name`)
+);
+
+CREATE TABLE IF NOT EXISTS `job_runs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `job_name` text NOT NULL,
+    `owner` text,
+    `status` text NOT NULL,
+    `result` text,
+    `error` text,
+    `started_at` datetime NOT NULL,
+    `finished_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_job_runs_job_name` ON `job_runs`(`job_name`);
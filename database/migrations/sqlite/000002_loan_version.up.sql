@@ -0,0 +1,2 @@
+-- Versión de cada préstamo para el control de concurrencia optimista
+ALTER TABLE `loans` ADD COLUMN `version` integer NOT NULL DEFAULT 0;
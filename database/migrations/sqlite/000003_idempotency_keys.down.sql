@@ -0,0 +1,2 @@
+-- Elimina las claves de idempotencia.
+DROP TABLE IF EXISTS `idempotency_keys`;
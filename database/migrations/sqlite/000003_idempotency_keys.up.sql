@@ -0,0 +1,18 @@
+-- Claves de idempotencia de las solicitudes POST con la respuesta guardada para repetirla
+CREATE TABLE IF NOT EXISTS `idempotency_keys` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `tenant_id` integer NOT NULL,
+    `user_id` integer NOT NULL,
+    `idempotency_key` text NOT NULL,
+    `fingerprint` text NOT NULL,
+    `status` text NOT NULL,
+    `response_status` integer,
+    `response_content_type` text,
+    `response_body` text,
+    `locked_until` datetime NOT NULL,
+    `expires_at` datetime NOT NULL,
+    `created_at` datetime,
+    `updated_at` datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_idempotency_keys_scope` ON `idempotency_keys`(`tenant_id`, `user_id`, `idempotency_key`);
+CREATE INDEX IF NOT EXISTS `idx_idempotency_keys_expires_at` ON `idempotency_keys`(`expires_at`);
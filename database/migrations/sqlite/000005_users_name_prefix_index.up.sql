@@ -0,0 +1,3 @@
+-- Índice para la búsqueda de préstamos por prefijo del nombre del solicitante: LIKE no distingue
+-- mayúsculas en SQLite y solo usa un índice con la misma collation
+CREATE INDEX IF NOT EXISTS `idx_users_name_nocase` ON `users`(`name` COLLATE NOCASE);
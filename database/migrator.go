@@ -0,0 +1,336 @@
+package database
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// MigrationsDir es el directorio de las migraciones, relativo a la raíz del proyecto
+const MigrationsDir = "database/migrations"
+
+// migrationDrivers son los motores que tienen su propio juego de archivos de migración
+var migrationDrivers = []string{"mysql", "postgres", "sqlite"}
+
+// migrationFilePattern reconoce los archivos <versión>_<nombre>.<up|down>.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration representa una migración versionada con sus scripts de subida y bajada
+type Migration struct {
+	Version  uint64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 del script de subida
+}
+
+// SchemaMigration registra una migración aplicada en la tabla schema_migrations
+type SchemaMigration struct {
+	Version   uint64    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	Checksum  string    `gorm:"size:64;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName especifica el nombre de la tabla de control de migraciones
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus representa el estado de una migración frente a la base de datos
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// Migrator aplica y revierte las migraciones versionadas de un motor de base de datos
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator crea un migrador con las migraciones embebidas del motor de la conexión
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// LoadMigrations lee y ordena por versión las migraciones embebidas de un motor
+func LoadMigrations(driver string) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations/"+driver)
+	if err != nil {
+		return nil, fmt.Errorf("no hay migraciones para el motor %s: %w", driver, err)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		matches := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+
+		version, _ := strconv.ParseUint(matches[1], 10, 64)
+		content, err := fs.ReadFile(migrationFiles, "migrations/"+driver+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("la versión %d tiene nombres distintos: %s y %s", version, migration.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("la migración %d_%s no tiene script de subida", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up aplica en orden todas las migraciones pendientes y retorna las aplicadas
+func (m *Migrator) Up() ([]Migration, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, migration := range pending {
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, migration.Up); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Checksum:  migration.Checksum,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return pending[:i], fmt.Errorf("error al aplicar la migración %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// Down revierte las últimas steps migraciones aplicadas y retorna las revertidas
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(applied) - 1; i >= 0 && len(reverted) < steps; i-- {
+		migration, ok := m.find(applied[i].Version)
+		if !ok {
+			return reverted, fmt.Errorf("la migración aplicada %d_%s no existe en los archivos", applied[i].Version, applied[i].Name)
+		}
+		if migration.Down == "" {
+			return reverted, fmt.Errorf("la migración %d_%s no tiene script de bajada", migration.Version, migration.Name)
+		}
+
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, migration.Down); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("error al revertir la migración %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// Status retorna cada migración conocida indicando si está aplicada
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAt := make(map[uint64]time.Time, len(applied))
+	for _, record := range applied {
+		appliedAt[record.Version] = record.AppliedAt
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if at, ok := appliedAt[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending retorna las migraciones sin aplicar. Falla si una migración aplicada fue modificada
+// o ya no existe, porque el esquema dejaría de corresponder con los archivos
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedByVersion := make(map[uint64]SchemaMigration, len(applied))
+	for _, record := range applied {
+		migration, ok := m.find(record.Version)
+		if !ok {
+			return nil, fmt.Errorf("la migración aplicada %d_%s no existe en los archivos", record.Version, record.Name)
+		}
+		if migration.Checksum != record.Checksum {
+			return nil, fmt.Errorf("la migración %d_%s fue modificada después de aplicarse (checksum distinto)", record.Version, record.Name)
+		}
+		appliedByVersion[record.Version] = record
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := appliedByVersion[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// CheckSchema verifica que el esquema esté al día; el servidor no debe atender solicitudes si falla
+func (m *Migrator) CheckSchema() error {
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		names := make([]string, len(pending))
+		for i, migration := range pending {
+			names[i] = fmt.Sprintf("%06d_%s", migration.Version, migration.Name)
+		}
+		return fmt.Errorf("el esquema tiene migraciones pendientes (%s); ejecute \"migrate up\"", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// applied retorna las migraciones registradas en schema_migrations, creando la tabla si no existe
+func (m *Migrator) applied() ([]SchemaMigration, error) {
+	if !m.db.Migrator().HasTable(&SchemaMigration{}) {
+		if err := m.db.Migrator().CreateTable(&SchemaMigration{}); err != nil {
+			return nil, fmt.Errorf("error al crear schema_migrations: %w", err)
+		}
+	}
+
+	var applied []SchemaMigration
+	err := m.db.Order("version ASC").Find(&applied).Error
+	return applied, err
+}
+
+// find busca una migración por versión
+func (m *Migrator) find(version uint64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// execScript ejecuta un script SQL sentencia por sentencia. Cada sentencia termina con ';' al final de una línea
+func execScript(tx *gorm.DB, script string) error {
+	var statement strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			if err := tx.Exec(statement.String()).Error; err != nil {
+				return err
+			}
+			statement.Reset()
+		}
+	}
+
+	if strings.TrimSpace(statement.String()) != "" {
+		return tx.Exec(statement.String()).Error
+	}
+	return nil
+}
+
+// CreateMigration crea los archivos vacíos de una nueva migración para cada motor en dir,
+// con la versión siguiente a la mayor existente, y retorna las rutas creadas
+func CreateMigration(dir, name string) ([]string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !regexp.MustCompile(`^[a-z0-9_]+$`).MatchString(name) {
+		return nil, fmt.Errorf("nombre de migración inválido %q: use letras minúsculas, números y guiones bajos", name)
+	}
+
+	var next uint64 = 1
+	for _, driver := range migrationDrivers {
+		entries, err := os.ReadDir(filepath.Join(dir, driver))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if matches := migrationFilePattern.FindStringSubmatch(entry.Name()); matches != nil {
+				if version, _ := strconv.ParseUint(matches[1], 10, 64); version >= next {
+					next = version + 1
+				}
+			}
+		}
+	}
+
+	var created []string
+	for _, driver := range migrationDrivers {
+		if err := os.MkdirAll(filepath.Join(dir, driver), 0o755); err != nil {
+			return created, err
+		}
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, driver, fmt.Sprintf("%06d_%s.%s.sql", next, name, direction))
+			content := fmt.Sprintf("-- %s (%s): escriba aquí las sentencias, cada una terminada en ';'\n", name, direction)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				return created, err
+			}
+			created = append(created, path)
+		}
+	}
+	return created, nil
+}
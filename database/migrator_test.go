@@ -0,0 +1,169 @@
+package database_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"loan-api/config"
+	"loan-api/database"
+	"loan-api/models"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// openSQLite abre una base SQLite en un archivo temporal, aislada de las demás pruebas
+func openSQLite(t *testing.T) *gorm.DB {
+	cfg := config.Config{
+		DBDriver: config.DBDriverSQLite,
+		DBName:   filepath.Join(t.TempDir(), "migrator.db"),
+		AppEnv:   "test",
+	}
+	db, err := database.Open(&cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("Debería tener las mismas versiones para todos los motores", func(t *testing.T) {
+		c := require.New(t)
+
+		sqliteMigrations, err := database.LoadMigrations(config.DBDriverSQLite)
+		c.NoError(err)
+		c.NotEmpty(sqliteMigrations)
+
+		for _, driver := range []string{config.DBDriverMySQL, config.DBDriverPostgres} {
+			migrations, err := database.LoadMigrations(driver)
+			c.NoError(err)
+			c.Len(migrations, len(sqliteMigrations), driver)
+			for i, migration := range migrations {
+				c.Equal(sqliteMigrations[i].Version, migration.Version, driver)
+				c.Equal(sqliteMigrations[i].Name, migration.Name, driver)
+				c.NotEmpty(migration.Down, driver)
+			}
+		}
+	})
+}
+
+func TestMigrator(t *testing.T) {
+	t.Run("Debería aplicar, reportar y revertir las migraciones", func(t *testing.T) {
+		c := require.New(t)
+		db := openSQLite(t)
+
+		migrator, err := database.NewMigrator(db)
+		c.NoError(err)
+		c.Error(migrator.CheckSchema())
+
+		applied, err := migrator.Up()
+		c.NoError(err)
+		c.NotEmpty(applied)
+		c.NoError(migrator.CheckSchema())
+		c.True(db.Migrator().HasTable(&models.Loan{}))
+
+		applied, err = migrator.Up()
+		c.NoError(err)
+		c.Empty(applied)
+
+		statuses, err := migrator.Status()
+		c.NoError(err)
+		for _, status := range statuses {
+			c.True(status.Applied)
+			c.NotNil(status.AppliedAt)
+		}
+
+		reverted, err := migrator.Down(len(statuses))
+		c.NoError(err)
+		c.Len(reverted, len(statuses))
+		c.False(db.Migrator().HasTable(&models.Loan{}))
+		c.Error(migrator.CheckSchema())
+	})
+
+	t.Run("Debería rechazar una migración modificada después de aplicarse", func(t *testing.T) {
+		c := require.New(t)
+		db := openSQLite(t)
+
+		migrator, err := database.NewMigrator(db)
+		c.NoError(err)
+		_, err = migrator.Up()
+		c.NoError(err)
+
+		c.NoError(db.Model(&database.SchemaMigration{}).Where("version = ?", 1).Update("checksum", "modificado").Error)
+
+		err = migrator.CheckSchema()
+		c.Error(err)
+		c.Contains(err.Error(), "checksum")
+		_, err = migrator.Up()
+		c.Error(err)
+	})
+
+	t.Run("Debería adoptar una base creada antes con el esquema completo", func(t *testing.T) {
+		c := require.New(t)
+		db := openSQLite(t)
+
+		migrator, err := database.NewMigrator(db)
+		c.NoError(err)
+		_, err = migrator.Up()
+		c.NoError(err)
+
+		// Simula una base creada con AutoMigrate: solo el esquema inicial y sin registro de versiones
+		statuses, err := migrator.Status()
+		c.NoError(err)
+		_, err = migrator.Down(len(statuses) - 1)
+		c.NoError(err)
+		c.NoError(db.Migrator().DropTable(&database.SchemaMigration{}))
+
+		applied, err := migrator.Up()
+		c.NoError(err)
+		c.Len(applied, len(statuses))
+		c.True(db.Migrator().HasColumn(&models.Loan{}, "Version"))
+	})
+}
+
+func TestSeed(t *testing.T) {
+	t.Run("Debería sembrar los entornos no productivos de forma idempotente", func(t *testing.T) {
+		c := require.New(t)
+		db := openSQLite(t)
+
+		migrator, err := database.NewMigrator(db)
+		c.NoError(err)
+		_, err = migrator.Up()
+		c.NoError(err)
+
+		c.NoError(database.Seed(db, "test"))
+		c.NoError(database.Seed(db, "test"))
+
+		var tenants int64
+		c.NoError(db.Model(&models.Tenant{}).Count(&tenants).Error)
+		c.Equal(int64(1), tenants)
+	})
+
+	t.Run("Debería fallar en producción", func(t *testing.T) {
+		c := require.New(t)
+		c.Error(database.Seed(openSQLite(t), "prod"))
+	})
+}
+
+func TestCreateMigration(t *testing.T) {
+	t.Run("Debería crear la siguiente versión para cada motor", func(t *testing.T) {
+		c := require.New(t)
+		dir := t.TempDir()
+
+		c.NoError(os.MkdirAll(filepath.Join(dir, "sqlite"), 0o755))
+		c.NoError(os.WriteFile(filepath.Join(dir, "sqlite", "000004_previous.up.sql"), []byte("SELECT 1;\n"), 0o644))
+
+		files, err := database.CreateMigration(dir, "Add_Index")
+		c.NoError(err)
+		c.Len(files, 6)
+		c.FileExists(filepath.Join(dir, "postgres", "000005_add_index.up.sql"))
+		c.FileExists(filepath.Join(dir, "mysql", "000005_add_index.down.sql"))
+
+		_, err = database.CreateMigration(dir, "nombre inválido")
+		c.Error(err)
+	})
+}
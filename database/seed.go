@@ -1,45 +1,54 @@
 package database
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
 
 	"loan-api/models"
+
+	"gorm.io/gorm"
 )
 
-// Migrate ejecuta las migraciones de la base de datos
-func Migrate() error {
-	// Ejecutar migraciones automáticas
-	err := DB.AutoMigrate(
-		&models.User{},
-		&models.Tenant{},
-		&models.LoanType{},
-		&models.LoanTypeVersion{},
-		&models.LoanTypeForm{},
-		&models.LoanTypeVersionFormInput{},
-		&models.Loan{},
-		&models.LoanData{},
-	)
+// Seeder inserta datos iniciales; debe ser idempotente para poder ejecutarse varias veces
+type Seeder func(db *gorm.DB) error
 
-	if err != nil {
-		log.Printf("Error en la migración: %v", err)
-		return err
-	}
+// environmentSeeders define los seeders de cada entorno. Producción no tiene seeders:
+// sus datos se administran por la API y no con datos de demostración
+var environmentSeeders = map[string][]Seeder{
+	"local": {seedInitialData},
+	"dev":   {seedInitialData},
+	"qa":    {seedInitialData},
+	"test":  {seedInitialData},
+}
 
-	// Ejecutar seeders para datos iniciales
-	if err := seedInitialData(); err != nil {
-		log.Printf("Error en los seeders: %v", err)
-		return err
+// Seed ejecuta los seeders definidos para el entorno indicado
+func Seed(db *gorm.DB, env string) error {
+	seeders, ok := environmentSeeders[env]
+	if !ok {
+		envs := make([]string, 0, len(environmentSeeders))
+		for name := range environmentSeeders {
+			envs = append(envs, name)
+		}
+		sort.Strings(envs)
+		return fmt.Errorf("no hay seeders definidos para el entorno %q (disponibles: %s)", env, strings.Join(envs, ", "))
 	}
 
-	log.Println("Migraciones ejecutadas correctamente")
+	for _, seeder := range seeders {
+		if err := seeder(db); err != nil {
+			slog.Error("error en los seeders", "env", env, "error", err)
+			return err
+		}
+	}
 	return nil
 }
 
 // seedInitialData inserta datos iniciales de configuración
-func seedInitialData() error {
+func seedInitialData(db *gorm.DB) error {
 	// Crear tenant de prueba
 	var tenant models.Tenant
-	result := DB.Where("code = ?", "test_bank").First(&tenant)
+	result := db.Where("code = ?", "test_bank").First(&tenant)
 	if result.Error != nil {
 		// El tenant no existe, crearlo
 		tenant = models.Tenant{
@@ -47,34 +56,35 @@ func seedInitialData() error {
 			Code:        "test_bank",
 			Description: "Entidad crediticia para pruebas de desarrollo",
 			IsActive:    true,
-			Config:      `{"max_loan_amount": 50000000, "min_credit_score": 500}`,
+			Config:      `{"max_loan_amount": 50000000, "min_credit_score": 500, "offer_expiration_hours": 72, "usury_rate_ea": 0.2762}`,
 		}
-		if err := DB.Create(&tenant).Error; err != nil {
+		if err := db.Create(&tenant).Error; err != nil {
 			return err
 		}
 	}
 
 	// Crear tipo de préstamo de prueba
 	var loanType models.LoanType
-	result = DB.Where("tenant_id = ? AND code = ?", tenant.ID, "personal_loan").First(&loanType)
+	result = db.Where("tenant_id = ? AND code = ?", tenant.ID, "personal_loan").First(&loanType)
 	if result.Error != nil {
 		loanType = models.LoanType{
-			TenantID:    tenant.ID,
-			Name:        "Préstamo Personal",
-			Code:        "personal_loan",
-			Description: "Préstamo personal para gastos diversos",
-			IsActive:    true,
-			MinAmount:   100000,
-			MaxAmount:   10000000,
+			TenantID:            tenant.ID,
+			Name:                "Préstamo Personal",
+			Code:                "personal_loan",
+			Description:         "Préstamo personal para gastos diversos",
+			IsActive:            true,
+			MinAmount:           100000,
+			MaxAmount:           10000000,
+			ApplicationTTLHours: 720, // Solicitudes sin cambios durante 30 días expiran
 		}
-		if err := DB.Create(&loanType).Error; err != nil {
+		if err := db.Create(&loanType).Error; err != nil {
 			return err
 		}
 	}
 
 	// Crear versión del tipo de préstamo
 	var loanTypeVersion models.LoanTypeVersion
-	result = DB.Where("loan_type_id = ? AND version = ?", loanType.ID, "1.0").First(&loanTypeVersion)
+	result = db.Where("loan_type_id = ? AND version = ?", loanType.ID, "1.0").First(&loanTypeVersion)
 	if result.Error != nil {
 		loanTypeVersion = models.LoanTypeVersion{
 			LoanTypeID:  loanType.ID,
@@ -82,16 +92,16 @@ func seedInitialData() error {
 			Description: "Versión inicial del préstamo personal",
 			IsActive:    true,
 			IsDefault:   true,
-			Config:      `{"approval_rules": {"min_income": 1000000, "max_debt_ratio": 0.4}}`,
+			Config:      `{"approval_rules": {"min_income": 1000000, "max_debt_ratio": 0.4}, "affordability": {"term_months": 24, "annual_rate": 0.24}, "manual_review": {"enabled": true, "score_min": 400, "score_max": 499, "on_identity_partial_match": true, "amount_threshold": 8000000}, "offers": {"terms": [24, 36, 48]}, "pricing": {"tiers": [{"name": "A", "min_score": 700, "nominal_annual_rate": 0.18, "origination_fee_rate": 0.01, "insurance_rate": 0.0005}, {"name": "B", "min_score": 600, "max_score": 699, "nominal_annual_rate": 0.21, "origination_fee_rate": 0.015, "insurance_rate": 0.0008}, {"name": "C", "min_score": 500, "max_score": 599, "nominal_annual_rate": 0.235, "origination_fee_rate": 0.02, "insurance_rate": 0.001}, {"name": "D", "min_score": 300, "max_score": 499, "nominal_annual_rate": 0.27, "origination_fee_rate": 0.03, "insurance_rate": 0.0012}]}, "parties": {"combine_income_roles": ["co_applicant"], "min_party_score": 500}}`,
 		}
-		if err := DB.Create(&loanTypeVersion).Error; err != nil {
+		if err := db.Create(&loanTypeVersion).Error; err != nil {
 			return err
 		}
 	}
 
 	// Verificar si ya existen formularios para esta versión
 	var existingFormsCount int64
-	DB.Model(&models.LoanTypeForm{}).Where("loan_type_version_id = ?", loanTypeVersion.ID).Count(&existingFormsCount)
+	db.Model(&models.LoanTypeForm{}).Where("loan_type_version_id = ?", loanTypeVersion.ID).Count(&existingFormsCount)
 
 	if existingFormsCount == 0 {
 		// Crear todos los formularios en una sola operación
@@ -126,17 +136,27 @@ func seedInitialData() error {
 				IsActive:          true,
 				Config:            `{"section": "loan"}`,
 			},
+			{
+				LoanTypeVersionID: loanTypeVersion.ID,
+				Label:             "Referencias Personales",
+				Code:              "references",
+				Description:       "Personas que pueden dar referencia del solicitante",
+				Order:             4,
+				IsRequired:        false,
+				IsActive:          true,
+				Config:            `{"section": "references", "repeatable": true, "min_items": 0, "max_items": 3}`,
+			},
 		}
 
 		// Insertar todos los formularios de una vez
-		if err := DB.Create(&forms).Error; err != nil {
+		if err := db.Create(&forms).Error; err != nil {
 			return err
 		}
 	}
 
 	// Obtener los formularios creados
 	var createdForms []models.LoanTypeForm
-	DB.Where("loan_type_version_id = ?", loanTypeVersion.ID).Find(&createdForms)
+	db.Where("loan_type_version_id = ?", loanTypeVersion.ID).Find(&createdForms)
 
 	// Verificar si ya existen inputs
 	var existingInputsCount int64
@@ -144,7 +164,7 @@ func seedInitialData() error {
 	for i, form := range createdForms {
 		formIDs[i] = form.ID
 	}
-	DB.Model(&models.LoanTypeVersionFormInput{}).Where("loan_type_form_id IN ?", formIDs).Count(&existingInputsCount)
+	db.Model(&models.LoanTypeVersionFormInput{}).Where("loan_type_form_id IN ?", formIDs).Count(&existingInputsCount)
 
 	if existingInputsCount == 0 {
 		// Crear mapa de formularios por código para fácil acceso
@@ -235,6 +255,32 @@ func seedInitialData() error {
 				IsActive:        true,
 				Config:          `{}`,
 			},
+			{
+				LoanTypeFormID:  formMap["financial_info"],
+				Label:           "Tipo de Empleo",
+				Code:            "employment_type",
+				InputType:       "select",
+				Placeholder:     "Seleccione su tipo de empleo",
+				ValidationRules: `{}`,
+				Options:         `[{"value": "employee", "label": "Empleado"}, {"value": "independent", "label": "Independiente"}, {"value": "pensioner", "label": "Pensionado"}]`,
+				Order:           3,
+				IsRequired:      false,
+				IsActive:        true,
+				Config:          `{}`,
+			},
+			{
+				LoanTypeFormID:  formMap["financial_info"],
+				Label:           "Empresa donde Trabaja",
+				Code:            "employer_name",
+				InputType:       "text",
+				Placeholder:     "Ingrese el nombre de la empresa",
+				ValidationRules: `{"minLength": 2}`,
+				Options:         `{}`,
+				Order:           4,
+				IsRequired:      false,
+				IsActive:        true,
+				Config:          `{"visible_when": {"field": "employment_type", "operator": "eq", "value": "employee"}, "required_when": {"field": "employment_type", "operator": "eq", "value": "employee"}}`,
+			},
 			// Inputs para Detalles del Préstamo
 			{
 				LoanTypeFormID:  formMap["loan_details"],
@@ -262,14 +308,67 @@ func seedInitialData() error {
 				IsActive:        true,
 				Config:          `{}`,
 			},
+			{
+				LoanTypeFormID:  formMap["loan_details"],
+				Label:           "Placa del Vehículo",
+				Code:            "vehicle_plate",
+				InputType:       "text",
+				Placeholder:     "Ingrese la placa del vehículo",
+				ValidationRules: `{"minLength": 5, "maxLength": 7}`,
+				Options:         `{}`,
+				Order:           3,
+				IsRequired:      false,
+				IsActive:        true,
+				Config:          `{"visible_when": {"field": "purpose", "operator": "eq", "value": "Vehículo"}, "required_when": {"field": "purpose", "operator": "eq", "value": "Vehículo"}}`,
+			},
+			// Inputs para Referencias Personales (un elemento por referencia)
+			{
+				LoanTypeFormID:  formMap["references"],
+				Label:           "Nombre de la Referencia",
+				Code:            "reference_name",
+				InputType:       "text",
+				Placeholder:     "Ingrese el nombre de la referencia",
+				ValidationRules: `{"required": true, "minLength": 2}`,
+				Options:         `{}`,
+				Order:           1,
+				IsRequired:      true,
+				IsActive:        true,
+				Config:          `{}`,
+			},
+			{
+				LoanTypeFormID:  formMap["references"],
+				Label:           "Teléfono de la Referencia",
+				Code:            "reference_phone",
+				InputType:       "text",
+				Placeholder:     "Ingrese el teléfono de la referencia",
+				ValidationRules: `{"required": true, "minLength": 7, "maxLength": 15}`,
+				Options:         `{}`,
+				Order:           2,
+				IsRequired:      true,
+				IsActive:        true,
+				Config:          `{}`,
+			},
+			{
+				LoanTypeFormID:  formMap["references"],
+				Label:           "Relación",
+				Code:            "reference_relationship",
+				InputType:       "select",
+				Placeholder:     "Seleccione la relación con el solicitante",
+				ValidationRules: `{}`,
+				Options:         `["Familiar", "Personal", "Laboral"]`,
+				Order:           3,
+				IsRequired:      false,
+				IsActive:        true,
+				Config:          `{}`,
+			},
 		}
 
 		// Insertar todos los inputs de una vez
-		if err := DB.Create(&allInputs).Error; err != nil {
+		if err := db.Create(&allInputs).Error; err != nil {
 			return err
 		}
 	}
 
-	log.Println("Datos iniciales insertados correctamente")
+	slog.Info("datos iniciales insertados correctamente")
 	return nil
 }
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"loan-api/config"
+	"loan-api/utils"
+)
+
+// New crea el logger de la aplicación con el nivel y formato de LOG_LEVEL y LOG_FORMAT. Cada línea
+// incluye el identificador de la solicitud del contexto y enmascara los datos personales
+func New(cfg config.Config, w io.Writer) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		level = slog.LevelInfo
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if strings.EqualFold(cfg.LogFormat, config.LogFormatText) {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+
+	return slog.New(&redactingHandler{next: handler}).With("app", cfg.AppName, "env", cfg.AppEnv)
+}
+
+// Setup configura el logger de la aplicación como logger por defecto. Los mensajes que aún usen el
+// paquete log también pasan por él
+func Setup(cfg config.Config) *slog.Logger {
+	logger := New(cfg, os.Stdout)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// Fatal registra el error y termina el proceso
+func Fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// redactingHandler agrega el identificador de la solicitud y enmascara mensaje y atributos antes
+// de delegar en el handler de salida
+type redactingHandler struct {
+	next slog.Handler
+}
+
+// Enabled indica si el nivel se registra
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle enmascara el registro y agrega el identificador de la solicitud
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, Redact(record.Message), record.PC)
+	if ctx != nil {
+		if requestID := utils.RequestIDFromContext(ctx); requestID != "" {
+			redacted.AddAttrs(slog.String("request_id", requestID))
+		}
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+// WithAttrs enmascara los atributos fijos del logger
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = redactAttr(attr)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(redacted)}
+}
+
+// WithGroup agrupa los atributos siguientes
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name)}
+}
+
+// GormWriter adapta el logger por defecto a la salida de GORM, de modo que las consultas también
+// pasan por el enmascaramiento. Debe usarse con ParameterizedQueries para que los valores de las
+// columnas (por ejemplo, documentos) no lleguen al log
+type GormWriter struct{}
+
+// Printf registra una línea de GORM
+func (GormWriter) Printf(format string, args ...interface{}) {
+	slog.Info(fmt.Sprintf(format, args...), "component", "gorm")
+}
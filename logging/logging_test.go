@@ -0,0 +1,118 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"loan-api/config"
+	"loan-api/logging"
+	"loan-api/models"
+	"loan-api/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+// decodeLines interpreta cada línea JSON escrita por el logger
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestLogger(t *testing.T) {
+	c := require.New(t)
+	cfg := config.Config{LogLevel: "info", LogFormat: config.LogFormatJSON, AppName: "Loan API", AppEnv: "test"}
+
+	t.Run("Debería enmascarar correos, teléfonos, documentos y contraseñas", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logging.New(cfg, &buf)
+
+		logger.Info("registro de juan@example.com con teléfono 300 123 4567",
+			"email", "juan@example.com",
+			"phone", "3001234567",
+			"document_number", "12345678",
+			"password", "Secreto123!",
+			"body", `{"password":"Secreto123!","document_number":"87654321"}`,
+			"error", errors.New("el usuario maria@example.com ya existe"),
+			"user", struct{ Email string }{Email: "ana@example.com"},
+			"authorization", "Bearer abc.def.ghi",
+		)
+
+		output := buf.String()
+		for _, secret := range []string{"juan@example.com", "maria@example.com", "ana@example.com", "3001234567", "300 123 4567", "12345678", "87654321", "Secreto123!", "abc.def.ghi"} {
+			c.NotContains(output, secret)
+		}
+
+		entry := decodeLines(t, &buf)[0]
+		c.Equal("registro de j***@example.com con teléfono ****4567", entry["msg"])
+		c.Equal("j***@example.com", entry["email"])
+		c.Equal("****4567", entry["phone"])
+		c.Equal("****5678", entry["document_number"])
+		c.Equal("[REDACTED]", entry["password"])
+		c.Equal(`{"password":"[REDACTED]","document_number":"[REDACTED]"}`, entry["body"])
+		c.Equal("el usuario m***@example.com ya existe", entry["error"])
+		c.Equal("[REDACTED]", entry["authorization"])
+		c.Equal("Loan API", entry["app"])
+	})
+
+	t.Run("Debería incluir el identificador de la solicitud del contexto", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logging.New(cfg, &buf)
+
+		logger.InfoContext(utils.WithRequestID(context.Background(), "req-123"), "con solicitud")
+		logger.Info("sin solicitud")
+
+		lines := decodeLines(t, &buf)
+		c.Len(lines, 2)
+		c.Equal("req-123", lines[0]["request_id"])
+		c.NotContains(lines[1], "request_id")
+	})
+
+	t.Run("Debería enmascarar los campos de un modelo registrado con %+v", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logging.New(cfg, &buf)
+
+		user := models.User{
+			Name:           "Juan",
+			Email:          "juan@example.com",
+			Phone:          "3001234567",
+			DocumentNumber: "12345678",
+			Password:       "Secreto123!",
+		}
+		logger.Info(fmt.Sprintf("usuario %+v", user), "user", user)
+
+		output := buf.String()
+		for _, secret := range []string{"juan@example.com", "3001234567", "12345678", "Secreto123!"} {
+			c.NotContains(output, secret)
+		}
+		c.Contains(output, "DocumentNumber:[REDACTED]")
+	})
+
+	t.Run("Debería respetar el nivel y el formato configurados", func(t *testing.T) {
+		var buf bytes.Buffer
+		warnCfg := cfg
+		warnCfg.LogLevel = "warn"
+		warnCfg.LogFormat = config.LogFormatText
+		logger := logging.New(warnCfg, &buf)
+
+		logger.Info("informativo")
+		logger.Warn("advertencia", "email", "juan@example.com")
+
+		output := buf.String()
+		c.NotContains(output, "informativo")
+		c.Contains(output, "level=WARN msg=advertencia")
+		c.Contains(output, "email=j***@example.com")
+	})
+}
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// redactedValue reemplaza los secretos, que no se muestran ni parcialmente
+const redactedValue = "[REDACTED]"
+
+// Patrones de datos personales y secretos dentro de textos libres (mensajes, errores, SQL). Las
+// claves también se reconocen con el nombre del campo de Go (DocumentNumber:12345678), como
+// aparecen al registrar structs con %+v
+var (
+	emailPattern    = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phonePattern    = regexp.MustCompile(`(?:\+?57[ \-]?)?\b3\d{2}[ \-]?\d{3}[ \-]?\d{4}\b`)
+	bcryptPattern   = regexp.MustCompile(`\$2[aby]?\$\d{2}\$[./A-Za-z0-9]{53}`)
+	bearerPattern   = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-_.]+`)
+	keyValuePattern = regexp.MustCompile(`(?i)("?(?:password|new_?password|current_?password|document_?number|phone|token|access_?token|authorization)"?\s*[:=]\s*"?)([^"&,\s}]+)`)
+)
+
+// maskFunc enmascara el valor de un atributo sensible
+type maskFunc func(value string) string
+
+// sensitiveKeys atributos que siempre se enmascaran, sin importar su contenido
+var sensitiveKeys = map[string]maskFunc{
+	"password":         maskSecret,
+	"new_password":     maskSecret,
+	"current_password": maskSecret,
+	"token":            maskSecret,
+	"access_token":     maskSecret,
+	"authorization":    maskSecret,
+	"email":            MaskEmail,
+	"phone":            MaskDigits,
+	"document_number":  MaskDigits,
+}
+
+// MaskEmail conserva la primera letra y el dominio del correo: j***@example.com
+func MaskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return redactedValue
+	}
+	return local[:1] + "***@" + domain
+}
+
+// MaskDigits conserva solo los últimos 4 caracteres: ****5678
+func MaskDigits(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) <= 4 {
+		return "****"
+	}
+	return "****" + value[len(value)-4:]
+}
+
+// maskSecret oculta por completo el valor
+func maskSecret(string) string {
+	return redactedValue
+}
+
+// Redact enmascara correos, teléfonos, hashes de contraseñas, tokens y los valores de campos
+// sensibles (clave=valor o JSON) dentro de un texto
+func Redact(text string) string {
+	text = keyValuePattern.ReplaceAllString(text, "${1}"+redactedValue)
+	text = bearerPattern.ReplaceAllString(text, "Bearer "+redactedValue)
+	text = bcryptPattern.ReplaceAllString(text, redactedValue)
+	text = emailPattern.ReplaceAllStringFunc(text, MaskEmail)
+	return phonePattern.ReplaceAllStringFunc(text, MaskDigits)
+}
+
+// redactAttr enmascara el atributo según su clave o, si no es sensible, según su contenido
+func redactAttr(attr slog.Attr) slog.Attr {
+	if mask, ok := sensitiveKeys[strings.ToLower(attr.Key)]; ok {
+		return slog.String(attr.Key, mask(attr.Value.Resolve().String()))
+	}
+
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Redact(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		attrs := make([]any, len(group))
+		for i, groupAttr := range group {
+			attrs[i] = redactAttr(groupAttr)
+		}
+		return slog.Group(attr.Key, attrs...)
+	case slog.KindAny:
+		// Errores y estructuras se registran como texto para poder enmascarar su contenido
+		return slog.String(attr.Key, Redact(fmt.Sprintf("%+v", value.Any())))
+	default:
+		return slog.Attr{Key: attr.Key, Value: value}
+	}
+}
@@ -1,114 +1,122 @@
 package middlewares
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
 
-	"loan-api/config"
-	"loan-api/database"
+	"loan-api/app_error"
 	"loan-api/models"
+	"loan-api/repositories"
 	"loan-api/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware middleware para autenticación
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware middleware para autenticación. publicKey es la clave pública (ACCESS_TOKEN_PUBLIC_KEY)
+// con la que se validan los tokens de acceso
+func AuthMiddleware(publicKey string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
 
 		if tokenString == "" {
-			log.Println("request does not contain an access token")
+			slog.DebugContext(c.Request.Context(), "request does not contain an access token")
 
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error":   true,
-				"message": "Por favor, iniciar sesión",
-			})
+			abortWithError(c, http.StatusUnauthorized, "Por favor, iniciar sesión")
 			return
 		}
 
-		loadConfig, err := config.LoadConfig(".")
+		sub, err := utils.ValidateToken(tokenString, publicKey)
 		if err != nil {
-			log.Fatal("could not load environment variables", err)
+			slog.DebugContext(c.Request.Context(), "invalid access token", "error", err)
 
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"error":   true,
-				"message": "Algo salió mal. Intentar otra vez.",
-			})
-			return
-		}
-
-		sub, err := utils.ValidateToken(tokenString, loadConfig.AccessTokenPublicKey)
-		if err != nil {
-			log.Println(err.Error())
-
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error":   true,
-				"message": "Por favor, iniciar sesión",
-			})
+			abortWithError(c, http.StatusUnauthorized, "Por favor, iniciar sesión")
 			return
 		}
 
 		// Extraer el ID del usuario del payload
 		payload, ok := sub.(map[string]interface{})
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error":   true,
-				"message": "Token inválido",
-			})
+			abortWithError(c, http.StatusUnauthorized, "Token inválido")
 			return
 		}
 
 		userID, ok := payload["id"].(float64)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error":   true,
-				"message": "Token inválido",
-			})
+			abortWithError(c, http.StatusUnauthorized, "Token inválido")
 			return
 		}
 
-		// Guardar solo el ID del usuario en el contexto
+		// Verificar que el usuario pertenezca al tenant de la solicitud
+		if tokenTenantID, ok := payload["tenant_id"].(float64); ok {
+			if tenantID, exists := c.Get("tenant_id"); exists && tenantID != uint(tokenTenantID) {
+				abortWithError(c, http.StatusForbidden, "Usuario no pertenece a este tenant")
+				return
+			}
+		}
+
+		// Guardar el ID y el rol del usuario en el contexto
 		c.Set("user_id", uint(userID))
+		if role, ok := payload["role"].(string); ok && role != "" {
+			c.Set("user_role", models.UserRole(role))
+		} else {
+			c.Set("user_role", models.UserRoleApplicant)
+		}
 		c.Next()
 	}
 }
 
-// Tenant middleware para validar el tenant ID
-func Tenant() gin.HandlerFunc {
+// RequireRole middleware para restringir el acceso a usuarios con alguno de los roles indicados
+func RequireRole(roles ...models.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userRole, exists := c.Get("user_role")
+		if !exists {
+			abortWithError(c, http.StatusUnauthorized, "Por favor, iniciar sesión")
+			return
+		}
+
+		for _, role := range roles {
+			if userRole == role {
+				c.Next()
+				return
+			}
+		}
+
+		abortWithError(c, http.StatusForbidden, "No tiene permisos para acceder a este recurso")
+	}
+}
+
+// Tenant middleware para validar el tenant ID contra el repositorio de tenants
+func Tenant(tenantRepository repositories.TenantRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		loadConfig, err := config.LoadConfig(".")
+		tenantId := ctx.GetHeader("X-Tenant-ID")
+		slog.DebugContext(ctx.Request.Context(), "tenant requested", "tenant_id", tenantId)
+
+		id, err := strconv.ParseUint(tenantId, 10, 64)
 		if err != nil {
-			log.Fatal("could not load environment variables", err)
+			slog.DebugContext(ctx.Request.Context(), "invalid tenant id", "error", err)
 
-			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"error":   true,
-				"message": "Algo salió mal. Intentar otra vez.",
-			})
+			abortWithError(ctx, http.StatusForbidden, "Tenant invalido.")
 
 			return
 		}
-		database.Connect(&loadConfig)
 
-		tenantId := ctx.GetHeader("X-Tenant-ID")
-		log.Printf("Tenant ID: %v", tenantId)
-		var tenant models.Tenant
+		tenant, err := tenantRepository.GetByID(ctx.Request.Context(), uint(id))
+		if appErr, ok := app_error.FromContextError(err); ok {
+			abortWithAppError(ctx, appErr)
 
-		result := database.DB.Where("id = ?", tenantId).First(&tenant)
-		if result.Error != nil {
-			log.Println(result.Error)
-			log.Println("could not load tenant ", err)
+			return
+		}
+		if err != nil {
+			slog.WarnContext(ctx.Request.Context(), "could not load tenant", "error", err)
 
-			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"error":   true,
-				"message": "Tenant invalido.",
-			})
+			abortWithError(ctx, http.StatusForbidden, "Tenant invalido.")
 
 			return
 		}
 
 		ctx.Set("tenant_id", tenant.ID)
-		ctx.Set("tenant", tenant)
+		ctx.Set("tenant", *tenant)
 
 		ctx.Next()
 	}
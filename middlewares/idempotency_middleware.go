@@ -0,0 +1,138 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"loan-api/app_error"
+	"loan-api/models"
+	"loan-api/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Encabezados de idempotencia
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength limita la clave recibida del cliente al tamaño de la columna
+const maxIdempotencyKeyLength = 255
+
+// Idempotency middleware que permite reintentar una solicitud POST con el encabezado Idempotency-Key
+// sin repetir sus efectos. La clave se guarda por tenant y usuario con la huella de la solicitud:
+//   - un reintento con la misma huella recibe la respuesta guardada
+//   - la misma clave con otro cuerpo o ruta responde 422
+//   - un reintento mientras la original se procesa responde 409
+//
+// Las claves vencen después de ttl. lockTTL limita cuánto se espera a una solicitud original que no
+// terminó antes de permitir que un reintento la procese. Las respuestas 5xx, las vencidas y las
+// canceladas no se guardan para que el cliente pueda reintentar con la misma clave.
+// Debe registrarse después de AuthMiddleware
+func Idempotency(idempotencyRepository repositories.IdempotencyRepository, ttl, lockTTL time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortWithAppError(c, app_error.ErrIdempotencyKeyInvalid)
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithAppError(c, app_error.ErrInvalidJSON)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		record := &models.IdempotencyKey{
+			TenantID:    c.GetUint("tenant_id"),
+			UserID:      c.GetUint("user_id"),
+			Key:         key,
+			Fingerprint: requestFingerprint(c.Request.Method, c.Request.URL.Path, body),
+			Status:      models.IdempotencyKeyStatusInProgress,
+			LockedUntil: now.Add(lockTTL),
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		stored, acquired, err := idempotencyRepository.Acquire(c.Request.Context(), record, now)
+		if err != nil {
+			if appErr, ok := app_error.FromContextError(err); ok {
+				abortWithAppError(c, appErr)
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "could not acquire idempotency key", "error", err)
+			abortWithAppError(c, app_error.NewDatabaseError("registrar clave de idempotencia", err.Error()))
+			return
+		}
+
+		if !acquired {
+			switch {
+			case stored.Fingerprint != record.Fingerprint:
+				abortWithAppError(c, app_error.ErrIdempotencyKeyReused)
+			case stored.Status == models.IdempotencyKeyStatusInProgress:
+				abortWithAppError(c, app_error.ErrIdempotencyKeyInProgress)
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(stored.ResponseStatus, stored.ResponseContentType, []byte(stored.ResponseBody))
+				c.Abort()
+			}
+			return
+		}
+
+		writer := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		// La respuesta se registra aunque la solicitud haya vencido o el cliente se haya desconectado
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := writer.Status()
+		if status >= http.StatusInternalServerError || status == app_error.StatusClientClosedRequest {
+			if err := idempotencyRepository.Release(ctx, stored.ID); err != nil {
+				slog.ErrorContext(ctx, "could not release idempotency key", "error", err)
+			}
+			return
+		}
+		if err := idempotencyRepository.Complete(ctx, stored.ID, status, writer.Header().Get("Content-Type"), writer.body.String()); err != nil {
+			slog.ErrorContext(ctx, "could not store idempotent response", "error", err)
+		}
+	}
+}
+
+// requestFingerprint calcula la huella de la solicitud con el método, la ruta y el cuerpo
+func requestFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder conserva una copia del cuerpo de la respuesta mientras se escribe al cliente
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write escribe la respuesta y guarda una copia
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString escribe la respuesta y guarda una copia
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
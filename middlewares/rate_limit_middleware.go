@@ -0,0 +1,132 @@
+package middlewares
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"loan-api/app_error"
+	"loan-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Grupos de rutas con límite de solicitudes propio
+const (
+	RateLimitGroupAuth  = "auth"
+	RateLimitGroupLoans = "loans"
+)
+
+// RateLimiter limita las solicitudes por grupo de rutas con token buckets por tenant y usuario
+// (o IP cuando la ruta no requiere autenticación)
+type RateLimiter struct {
+	store RateLimitStore
+	rules map[string]RateLimitRule
+	now   func() time.Time
+}
+
+// NewRateLimiter crea el limitador con los límites por defecto de cada grupo. now permite
+// reemplazar el reloj en las pruebas
+func NewRateLimiter(store RateLimitStore, rules map[string]RateLimitRule, now func() time.Time) *RateLimiter {
+	return &RateLimiter{
+		store: store,
+		rules: rules,
+		now:   now,
+	}
+}
+
+// ParseRateLimitRules interpreta los límites por defecto de cada grupo de rutas
+func ParseRateLimitRules(values map[string]string) (map[string]RateLimitRule, error) {
+	rules := make(map[string]RateLimitRule, len(values))
+	for group, value := range values {
+		rule, err := ParseRateLimitRule(value)
+		if err != nil {
+			return nil, fmt.Errorf("grupo %s: %w", group, err)
+		}
+		rules[group] = rule
+	}
+	return rules, nil
+}
+
+// Limit middleware que aplica el límite del grupo indicado. El tenant puede reemplazarlo con
+// rate_limits en su configuración. Agrega las cabeceras RateLimit-* y responde 429 con Retry-After
+// cuando se agota el límite. Debe registrarse después de Tenant y, si la ruta la requiere, de
+// AuthMiddleware. Un RateLimiter nil no limita
+func (l *RateLimiter) Limit(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l == nil {
+			c.Next()
+			return
+		}
+
+		rule := l.rule(c, group)
+		if !rule.Enabled() {
+			c.Next()
+			return
+		}
+
+		result, err := l.store.Take(c.Request.Context(), rateLimitKey(c, group), rule, l.now())
+		if err != nil {
+			// Una falla del almacenamiento no debe dejar la API sin servicio
+			slog.ErrorContext(c.Request.Context(), "could not apply rate limit", "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", rule.Requests, ceilSeconds(rule.Period)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			abortWithAppError(c, app_error.ErrTooManyRequests)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rule retorna el límite del grupo, reemplazado por el de la configuración del tenant si lo define
+func (l *RateLimiter) rule(c *gin.Context, group string) RateLimitRule {
+	rule := l.rules[group]
+
+	tenant, ok := c.Get("tenant")
+	if !ok {
+		return rule
+	}
+	tenantModel, ok := tenant.(models.Tenant)
+	if !ok {
+		return rule
+	}
+	tenantConfig, err := tenantModel.ParseConfig()
+	if err != nil {
+		return rule
+	}
+	value, ok := tenantConfig.RateLimits[group]
+	if !ok {
+		return rule
+	}
+	tenantRule, err := ParseRateLimitRule(value)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid tenant rate limit", "tenant_id", tenantModel.ID, "error", err)
+		return rule
+	}
+	return tenantRule
+}
+
+// rateLimitKey identifica el bucket por grupo, tenant y usuario autenticado o IP del cliente
+func rateLimitKey(c *gin.Context, group string) string {
+	if userID, ok := c.Get("user_id"); ok {
+		return fmt.Sprintf("%s:%d:user:%v", group, c.GetUint("tenant_id"), userID)
+	}
+	return fmt.Sprintf("%s:%d:ip:%s", group, c.GetUint("tenant_id"), c.ClientIP())
+}
+
+// ceilSeconds redondea la duración hacia arriba a segundos enteros
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,143 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitRule límite de un token bucket: Requests solicitudes como ráfaga máxima, que se
+// recuperan de forma continua durante Period. Un límite sin solicitudes no restringe nada
+type RateLimitRule struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseRateLimitRule interpreta un límite con el formato solicitudes/periodo (por ejemplo, 10/1m).
+// Un valor vacío o 0 desactiva el límite
+func ParseRateLimitRule(value string) (RateLimitRule, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return RateLimitRule{}, nil
+	}
+
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return RateLimitRule{}, fmt.Errorf("límite de solicitudes inválido %q: el formato es solicitudes/periodo, por ejemplo 10/1m", value)
+	}
+	rule := RateLimitRule{}
+	var err error
+	if rule.Requests, err = strconv.Atoi(strings.TrimSpace(requests)); err != nil || rule.Requests < 0 {
+		return RateLimitRule{}, fmt.Errorf("límite de solicitudes inválido %q: la cantidad debe ser un entero no negativo", value)
+	}
+	if rule.Period, err = time.ParseDuration(strings.TrimSpace(period)); err != nil || rule.Period <= 0 {
+		return RateLimitRule{}, fmt.Errorf("límite de solicitudes inválido %q: el periodo debe ser una duración positiva", value)
+	}
+	return rule, nil
+}
+
+// Enabled indica si el límite restringe las solicitudes
+func (r RateLimitRule) Enabled() bool {
+	return r.Requests > 0 && r.Period > 0
+}
+
+// interval tiempo en recuperar una solicitud
+func (r RateLimitRule) interval() time.Duration {
+	return r.Period / time.Duration(r.Requests)
+}
+
+// RateLimitResult resultado de consumir una solicitud del bucket
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset tiempo hasta que el bucket vuelva a estar lleno
+	Reset time.Duration
+	// RetryAfter tiempo hasta que haya una solicitud disponible cuando no se permitió
+	RetryAfter time.Duration
+}
+
+// RateLimitStore guarda el estado de los token buckets. La implementación en memoria sirve para
+// una sola réplica; con varias réplicas debe usarse un almacenamiento compartido (por ejemplo, Redis)
+// que consuma la solicitud de forma atómica
+type RateLimitStore interface {
+	// Take consume una solicitud del bucket de key según rule en el instante now
+	Take(ctx context.Context, key string, rule RateLimitRule, now time.Time) (RateLimitResult, error)
+}
+
+// tokenBucket estado de un bucket: solicitudes disponibles en el instante updatedAt
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+// memoryRateLimitStore almacenamiento de los buckets en la memoria del proceso
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	sweptAt   time.Time
+	sweepEach time.Duration
+}
+
+// NewMemoryRateLimitStore crea un almacenamiento de token buckets en memoria
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{
+		buckets:   make(map[string]*tokenBucket),
+		sweepEach: time.Minute,
+	}
+}
+
+// Take consume una solicitud del bucket de key
+func (s *memoryRateLimitStore) Take(ctx context.Context, key string, rule RateLimitRule, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	capacity := float64(rule.Requests)
+	perToken := rule.interval()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, updatedAt: now}
+		s.buckets[key] = bucket
+	}
+
+	// Recuperar las solicitudes del tiempo transcurrido sin superar la ráfaga (que puede haber bajado)
+	if elapsed := now.Sub(bucket.updatedAt); elapsed > 0 {
+		bucket.tokens += float64(elapsed) / float64(perToken)
+		bucket.updatedAt = now
+	}
+	bucket.tokens = math.Min(bucket.tokens, capacity)
+
+	result := RateLimitResult{Limit: rule.Requests}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - bucket.tokens) * float64(perToken))
+	}
+	result.Remaining = int(bucket.tokens)
+	result.Reset = time.Duration((capacity - bucket.tokens) * float64(perToken))
+	bucket.fullAt = now.Add(result.Reset)
+
+	return result, nil
+}
+
+// sweep descarta los buckets que ya se recuperaron por completo, equivalentes a uno nuevo
+func (s *memoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < s.sweepEach {
+		return
+	}
+	s.sweptAt = now
+	for key, bucket := range s.buckets {
+		if !bucket.fullAt.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+}
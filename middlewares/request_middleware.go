@@ -0,0 +1,157 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"loan-api/app_error"
+	"loan-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader es la cabecera con el identificador de la solicitud
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limita el identificador recibido del cliente
+const maxRequestIDLength = 128
+
+// RequestContext middleware que asigna el identificador de la solicitud (el recibido en X-Request-ID
+// o uno nuevo) y lo guarda, junto con la cabecera traceparent, en el contexto de la solicitud.
+// Los logs con ese contexto y las respuestas de error incluyen el identificador
+func RequestContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		ctx := utils.WithRequestID(c.Request.Context(), requestID)
+		if traceParent := c.GetHeader("traceparent"); traceParent != "" {
+			ctx = utils.WithTraceParent(ctx, traceParent)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// Timeout middleware que fija el plazo máximo de la solicitud. routeTimeouts define plazos
+// propios por ruta (por ejemplo, exportaciones) y el resto usa defaultTimeout.
+// Al vencer el plazo se cancelan las consultas y llamadas a proveedores que usen el contexto
+func Timeout(defaultTimeout time.Duration, routeTimeouts map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := defaultTimeout
+		if routeTimeout, ok := routeTimeouts[c.FullPath()]; ok {
+			timeout = routeTimeout
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// AccessLog middleware que registra una línea por solicitud con su resultado y duración.
+// healthPath se omite para no llenar los logs con los chequeos del balanceador
+func AccessLog(healthPath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.URL.Path == healthPath {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+		}
+		if tenantID, ok := c.Get("tenant_id"); ok {
+			attrs = append(attrs, slog.Any("tenant_id", tenantID))
+		}
+		if userID, ok := c.Get("user_id"); ok {
+			attrs = append(attrs, slog.Any("user_id", userID))
+		}
+		if ginErrors := c.Errors.String(); ginErrors != "" {
+			attrs = append(attrs, slog.String("errors", ginErrors))
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery middleware que registra los pánicos de los handlers y responde 500 sin exponer el detalle
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		slog.ErrorContext(c.Request.Context(), "panic recovered", "panic", recovered, "path", c.Request.URL.Path)
+		abortWithAppError(c, app_error.ErrInternalServer)
+	})
+}
+
+// abortWithError corta la cadena de handlers respondiendo con el código y mensaje indicados
+// y el identificador de la solicitud
+func abortWithError(c *gin.Context, code int, message string) {
+	c.AbortWithStatusJSON(code, gin.H{
+		"error":      true,
+		"message":    message,
+		"request_id": c.GetString("request_id"),
+	})
+}
+
+// abortWithAppError corta la cadena de handlers respondiendo con el AppError indicado
+func abortWithAppError(c *gin.Context, appErr *app_error.AppError) {
+	abortWithError(c, appErr.Code, appErr.Message)
+}
+
+// validRequestID acepta identificadores de hasta maxRequestIDLength caracteres alfanuméricos, '-', '_' o '.'
+// para que un valor del cliente no altere los logs
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, char := range requestID {
+		switch {
+		case 'a' <= char && char <= 'z', 'A' <= char && char <= 'Z', '0' <= char && char <= '9':
+		case char == '-' || char == '_' || char == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID genera un identificador aleatorio de 16 bytes en hexadecimal
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
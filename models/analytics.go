@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// AnalyticsPeriod define la granularidad de las series de tiempo de analítica
+type AnalyticsPeriod string
+
+const (
+	AnalyticsPeriodDay   AnalyticsPeriod = "day"
+	AnalyticsPeriodWeek  AnalyticsPeriod = "week" // Semanas de lunes a domingo
+	AnalyticsPeriodMonth AnalyticsPeriod = "month"
+)
+
+// AnalyticsFilter representa el rango y la granularidad de la analítica del portafolio
+type AnalyticsFilter struct {
+	TenantID   uint
+	LoanTypeID uint
+	From       time.Time
+	To         time.Time // Exclusivo
+	Period     AnalyticsPeriod
+}
+
+// FunnelAggregate representa los totales del embudo de solicitudes de un tipo de préstamo en un día
+type FunnelAggregate struct {
+	LoanTypeID        uint
+	Day               string
+	Started           int64
+	OnProgress        int64
+	Completed         int64
+	Approved          int64
+	Rejected          int64
+	Cancelled         int64
+	Expired           int64
+	ScoreSum          int64
+	ScoreCount        int64
+	ApprovedAmountSum decimal.Decimal
+}
+
+// RejectionReasonAggregate representa la cantidad de rechazos de un tipo de préstamo por motivo
+type RejectionReasonAggregate struct {
+	LoanTypeID uint
+	Reason     string
+	Count      int64
+}
+
+// FunnelMetrics representa las métricas del embudo de solicitudes
+type FunnelMetrics struct {
+	Started               int64            `json:"started"`
+	OnProgress            int64            `json:"on_progress"` // Solicitudes que llegaron a on_progress
+	Completed             int64            `json:"completed"`   // Solicitudes que llegaron a completed
+	Approved              int64            `json:"approved"`
+	Rejected              int64            `json:"rejected"`
+	Cancelled             int64            `json:"cancelled"`
+	Expired               int64            `json:"expired"`
+	Conversion            FunnelConversion `json:"conversion"`
+	AverageScore          *float64         `json:"average_score,omitempty"`
+	AverageApprovedAmount decimal.Decimal  `json:"average_approved_amount"`
+}
+
+// FunnelConversion representa la conversión entre etapas del embudo (0 a 1)
+type FunnelConversion struct {
+	StartedToOnProgress   float64 `json:"started_to_on_progress"`
+	OnProgressToCompleted float64 `json:"on_progress_to_completed"`
+	CompletedToApproved   float64 `json:"completed_to_approved"`
+	CompletedToRejected   float64 `json:"completed_to_rejected"`
+	StartedToApproved     float64 `json:"started_to_approved"`
+}
+
+// AnalyticsPoint representa las métricas de un período de la serie de tiempo
+type AnalyticsPoint struct {
+	PeriodStart string `json:"period_start"` // YYYY-MM-DD
+	FunnelMetrics
+}
+
+// RejectionReasonCount representa la cantidad de rechazos por motivo
+type RejectionReasonCount struct {
+	Reason string `json:"reason"`
+	Count  int64  `json:"count"`
+}
+
+// LoanTypeAnalytics representa la analítica de un tipo de préstamo
+type LoanTypeAnalytics struct {
+	LoanTypeID       uint                   `json:"loan_type_id"`
+	LoanTypeName     string                 `json:"loan_type_name"`
+	Totals           FunnelMetrics          `json:"totals"`
+	Series           []AnalyticsPoint       `json:"series"`
+	RejectionReasons []RejectionReasonCount `json:"rejection_reasons"`
+}
+
+// AnalyticsResponse representa la analítica del portafolio del tenant en un rango de fechas
+type AnalyticsResponse struct {
+	From             string                 `json:"from"`
+	To               string                 `json:"to"`
+	Period           AnalyticsPeriod        `json:"period"`
+	Totals           FunnelMetrics          `json:"totals"`
+	Series           []AnalyticsPoint       `json:"series"`
+	RejectionReasons []RejectionReasonCount `json:"rejection_reasons"`
+	LoanTypes        []LoanTypeAnalytics    `json:"loan_types"`
+	GeneratedAt      time.Time              `json:"generated_at"`
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Operadores soportados en las condiciones de los inputs
+const (
+	ConditionOperatorEq     = "eq"
+	ConditionOperatorNeq    = "neq"
+	ConditionOperatorIn     = "in"
+	ConditionOperatorNotIn  = "not_in"
+	ConditionOperatorFilled = "filled"
+	ConditionOperatorEmpty  = "empty"
+	ConditionOperatorGt     = "gt"
+	ConditionOperatorGte    = "gte"
+	ConditionOperatorLt     = "lt"
+	ConditionOperatorLte    = "lte"
+)
+
+// FormInputConfig representa la configuración JSON de un input de formulario
+type FormInputConfig struct {
+	VisibleWhen  *InputCondition `json:"visible_when,omitempty"`  // Si no se cumple, el input se oculta y no acepta valores
+	RequiredWhen *InputCondition `json:"required_when,omitempty"` // Si se cumple, el input visible es requerido
+}
+
+// InputCondition representa una condición sobre las respuestas de otros inputs.
+// Una condición compuesta usa all (todas se cumplen) o any (al menos una se cumple);
+// una condición simple compara la respuesta del input field con value o values
+type InputCondition struct {
+	Field    string           `json:"field,omitempty"`
+	Operator string           `json:"operator,omitempty"` // eq (por defecto), neq, in, not_in, filled, empty, gt, gte, lt, lte
+	Value    string           `json:"value,omitempty"`
+	Values   []string         `json:"values,omitempty"`
+	All      []InputCondition `json:"all,omitempty"`
+	Any      []InputCondition `json:"any,omitempty"`
+}
+
+// Evaluate evalúa la condición con la función que obtiene la respuesta de un input por su código.
+// Una condición nula siempre se cumple
+func (c *InputCondition) Evaluate(answer func(code string) string) bool {
+	if c == nil {
+		return true
+	}
+
+	if len(c.All) > 0 {
+		for i := range c.All {
+			if !c.All[i].Evaluate(answer) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if len(c.Any) > 0 {
+		for i := range c.Any {
+			if c.Any[i].Evaluate(answer) {
+				return true
+			}
+		}
+		return false
+	}
+
+	value := strings.TrimSpace(answer(c.Field))
+
+	switch c.Operator {
+	case "", ConditionOperatorEq:
+		return value == c.Value
+	case ConditionOperatorNeq:
+		return value != c.Value
+	case ConditionOperatorIn:
+		return containsValue(c.Values, value)
+	case ConditionOperatorNotIn:
+		return !containsValue(c.Values, value)
+	case ConditionOperatorFilled:
+		return value != ""
+	case ConditionOperatorEmpty:
+		return value == ""
+	case ConditionOperatorGt, ConditionOperatorGte, ConditionOperatorLt, ConditionOperatorLte:
+		return compareNumbers(value, c.Value, c.Operator)
+	}
+
+	return false
+}
+
+// containsValue verifica si el valor está en la lista
+func containsValue(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// compareNumbers compara numéricamente la respuesta con el valor de la condición.
+// Si alguno no es numérico la condición no se cumple
+func compareNumbers(value, expected, operator string) bool {
+	actual, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false
+	}
+	limit, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return false
+	}
+
+	switch operator {
+	case ConditionOperatorGt:
+		return actual > limit
+	case ConditionOperatorGte:
+		return actual >= limit
+	case ConditionOperatorLt:
+		return actual < limit
+	default:
+		return actual <= limit
+	}
+}
+
+// ParseConfig deserializa la configuración JSON del input
+func (i *LoanTypeVersionFormInput) ParseConfig() (FormInputConfig, error) {
+	var cfg FormInputConfig
+	if i.Config == "" {
+		return cfg, nil
+	}
+	err := json.Unmarshal([]byte(i.Config), &cfg)
+	return cfg, err
+}
+
+// EvaluateState determina si el input es visible y requerido según las respuestas.
+// Un input oculto nunca es requerido; una configuración inválida no aplica condiciones
+func (i *LoanTypeVersionFormInput) EvaluateState(answer func(code string) string) (visible bool, required bool) {
+	cfg, err := i.ParseConfig()
+	if err != nil {
+		return true, i.IsRequired
+	}
+
+	if !cfg.VisibleWhen.Evaluate(answer) {
+		return false, false
+	}
+
+	required = i.IsRequired
+	if cfg.RequiredWhen != nil && cfg.RequiredWhen.Evaluate(answer) {
+		required = true
+	}
+	return true, required
+}
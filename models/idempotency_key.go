@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// IdempotencyKeyStatus define los posibles estados de una clave de idempotencia
+type IdempotencyKeyStatus string
+
+const (
+	IdempotencyKeyStatusInProgress IdempotencyKeyStatus = "in_progress" // La solicitud original aún se procesa
+	IdempotencyKeyStatusCompleted  IdempotencyKeyStatus = "completed"   // La respuesta está guardada para repetirla
+)
+
+// IdempotencyKey representa una clave de idempotencia enviada por un usuario en el encabezado Idempotency-Key,
+// junto con la huella de la solicitud original y la respuesta que se repite en los reintentos
+type IdempotencyKey struct {
+	ID                  uint                 `json:"id" gorm:"primaryKey"`
+	TenantID            uint                 `json:"tenant_id" gorm:"not null;uniqueIndex:idx_idempotency_keys_scope"`
+	UserID              uint                 `json:"user_id" gorm:"not null;uniqueIndex:idx_idempotency_keys_scope"`
+	Key                 string               `json:"key" gorm:"column:idempotency_key;size:255;not null;uniqueIndex:idx_idempotency_keys_scope"`
+	Fingerprint         string               `json:"fingerprint" gorm:"size:64;not null"`
+	Status              IdempotencyKeyStatus `json:"status" gorm:"size:20;not null"`
+	ResponseStatus      int                  `json:"response_status"`
+	ResponseContentType string               `json:"response_content_type" gorm:"size:255"`
+	ResponseBody        string               `json:"-" gorm:"type:text"`
+	LockedUntil         time.Time            `json:"locked_until" gorm:"not null"`
+	ExpiresAt           time.Time            `json:"expires_at" gorm:"not null;index"`
+	CreatedAt           time.Time            `json:"created_at" gorm:"autoCreateTime:true"`
+	UpdatedAt           time.Time            `json:"updated_at" gorm:"autoUpdateTime:true"`
+}
+
+// TableName especifica el nombre de la tabla para GORM
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
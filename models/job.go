@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// JobRunStatus define los posibles estados de una ejecución de tarea programada
+type JobRunStatus string
+
+const (
+	JobRunStatusRunning JobRunStatus = "running" // En ejecución
+	JobRunStatusSuccess JobRunStatus = "success" // Finalizada correctamente
+	JobRunStatusFailed  JobRunStatus = "failed"  // Finalizada con error
+)
+
+// JobLease representa el bloqueo de una tarea programada para que solo una réplica la ejecute
+type JobLease struct {
+	JobName     string    `json:"job_name" gorm:"primaryKey;size:100"`
+	Owner       string    `json:"owner" gorm:"size:255"`
+	LockedUntil time.Time `json:"locked_until" gorm:"not null"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime:true"`
+}
+
+// TableName especifica el nombre de la tabla para GORM
+func (JobLease) TableName() string {
+	return "job_leases"
+}
+
+// JobRun representa una ejecución registrada de una tarea programada
+type JobRun struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	JobName    string       `json:"job_name" gorm:"size:100;not null;index"`
+	Owner      string       `json:"owner" gorm:"size:255"`
+	Status     JobRunStatus `json:"status" gorm:"size:20;not null"`
+	Result     string       `json:"result,omitempty" gorm:"type:text"`
+	Error      string       `json:"error,omitempty" gorm:"type:text"`
+	StartedAt  time.Time    `json:"started_at" gorm:"not null"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+}
+
+// TableName especifica el nombre de la tabla para GORM
+func (JobRun) TableName() string {
+	return "job_runs"
+}
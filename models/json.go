@@ -0,0 +1,18 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// JSON es un documento JSON persistido como texto; su columna usa jsonb en PostgreSQL
+// y json en MySQL y SQLite
+type JSON string
+
+// GormDBDataType retorna el tipo de columna según el motor de base de datos
+func (JSON) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "jsonb"
+	}
+	return "json"
+}
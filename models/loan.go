@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -11,11 +12,52 @@ import (
 type LoanStatus string
 
 const (
-	LoanStatusPending    LoanStatus = "pending"     // Préstamo creado, sin datos
-	LoanStatusOnProgress LoanStatus = "on_progress" // Datos parciales guardados
-	LoanStatusCompleted  LoanStatus = "completed"   // Datos completados + validaciones realizadas
-	LoanStatusApproved   LoanStatus = "approved"    // Préstamo aprobado
-	LoanStatusRejected   LoanStatus = "rejected"    // Préstamo rechazado
+	LoanStatusPending      LoanStatus = "pending"       // Préstamo creado, sin datos
+	LoanStatusOnProgress   LoanStatus = "on_progress"   // Datos parciales guardados
+	LoanStatusCompleted    LoanStatus = "completed"     // Datos completados + validaciones realizadas
+	LoanStatusDisbursing   LoanStatus = "disbursing"    // Préstamo aprobado, desembolso en curso con el proveedor
+	LoanStatusApproved     LoanStatus = "approved"      // Préstamo aprobado
+	LoanStatusRejected     LoanStatus = "rejected"      // Préstamo rechazado
+	LoanStatusManualReview LoanStatus = "manual_review" // Préstamo en revisión manual por un analista
+	LoanStatusOfferPending LoanStatus = "offer_pending" // Contraofertas generadas, esperando respuesta del solicitante
+	LoanStatusCancelled    LoanStatus = "cancelled"     // Préstamo cancelado por el solicitante o por el staff
+	LoanStatusExpired      LoanStatus = "expired"       // Solicitud expirada por inactividad
+)
+
+// IsCancellable verifica si un préstamo en el estado indicado puede cancelarse (solo antes de la aprobación)
+func (s LoanStatus) IsCancellable() bool {
+	switch s {
+	case LoanStatusPending, LoanStatusOnProgress, LoanStatusCompleted, LoanStatusManualReview, LoanStatusOfferPending:
+		return true
+	}
+	return false
+}
+
+// IsValid verifica si el estado corresponde a uno de los estados conocidos del préstamo
+func (s LoanStatus) IsValid() bool {
+	switch s {
+	case LoanStatusPending, LoanStatusOnProgress, LoanStatusCompleted, LoanStatusDisbursing, LoanStatusApproved,
+		LoanStatusRejected, LoanStatusManualReview, LoanStatusOfferPending, LoanStatusCancelled, LoanStatusExpired:
+		return true
+	}
+	return false
+}
+
+// CancellerRole define quién canceló el préstamo
+type CancellerRole string
+
+const (
+	CancellerRoleApplicant CancellerRole = "applicant" // Cancelado por el solicitante
+	CancellerRoleStaff     CancellerRole = "staff"     // Cancelado por el staff del tenant
+)
+
+// IdentityMatch define el resultado de la verificación de identidad
+type IdentityMatch string
+
+const (
+	IdentityMatchFull    IdentityMatch = "full"    // Documento y nombre coinciden
+	IdentityMatchPartial IdentityMatch = "partial" // Documento coincide, nombre coincide parcialmente
+	IdentityMatchNone    IdentityMatch = "none"    // No hay coincidencia
 )
 
 // Loan representa una solicitud de préstamo
@@ -25,33 +67,61 @@ type Loan struct {
 	LoanType       LoanType        `json:"loan_type"`
 	UserID         uint            `json:"user_id" gorm:"not null;index"`
 	User           User            `json:"user"`
-	Status         string          `json:"status" gorm:"size:50;default:'pending'"`
+	Status         string          `json:"status" gorm:"size:50;default:'pending';index"`
 	Observation    string          `json:"observation" gorm:"type:text"`
-	AmountApproved decimal.Decimal `json:"amount_approved" gorm:"type:decimal(13,2);default:0"`
+	AmountApproved decimal.Decimal `json:"amount_approved" gorm:"type:decimal(13,2);default:0;index"`
 	// Campos para resultados de validaciones
-	CreditScore      *int           `json:"credit_score,omitempty" gorm:"type:int;default:0"`
-	IdentityVerified *bool          `json:"identity_verified,omitempty" gorm:"default:false"`
-	Data             []LoanData     `json:"data"`
-	CreatedAt        time.Time      `json:"created_at" gorm:"autoCreateTime:true"`
-	UpdatedAt        time.Time      `json:"updated_at" gorm:"autoUpdateTime:true"`
-	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+	CreditScore      *int          `json:"credit_score,omitempty" gorm:"type:int;default:0;index"`
+	IdentityVerified *bool         `json:"identity_verified,omitempty" gorm:"default:false"`
+	IdentityMatch    IdentityMatch `json:"identity_match,omitempty" gorm:"size:20"`
+	Affordability    string        `json:"-" gorm:"type:text"`
+	Pricing          string        `json:"-" gorm:"type:text"`
+	// Asignación de la revisión manual
+	ReviewClaimedBy      *uint         `json:"review_claimed_by,omitempty" gorm:"index"`
+	ReviewClaimedAt      *time.Time    `json:"review_claimed_at,omitempty"`
+	ReviewClaimExpiresAt *time.Time    `json:"review_claim_expires_at,omitempty"`
+	CancelledBy          *uint         `json:"cancelled_by,omitempty"`
+	CancelledByRole      CancellerRole `json:"cancelled_by_role,omitempty" gorm:"size:20"`
+	CancelledAt          *time.Time    `json:"cancelled_at,omitempty"`
+	CancellationReason   string        `json:"cancellation_reason,omitempty" gorm:"type:text"`
+	// Versión para el control de concurrencia optimista; aumenta en cada actualización
+	Version   uint64         `json:"-" gorm:"not null;default:0"`
+	Data      []LoanData     `json:"data"`
+	Offers    []LoanOffer    `json:"offers,omitempty"`
+	Parties   []LoanParty    `json:"parties,omitempty"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime:true;index"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime:true"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // LoanData representa los datos dinámicos de una solicitud de préstamo
 type LoanData struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
-	LoanID    uint           `json:"loan_id" gorm:"not null;index"`
+	LoanID    uint           `json:"loan_id" gorm:"not null;index;uniqueIndex:idx_loan_data_entry"`
 	Loan      Loan           `json:"-"`
-	FormID    uint           `json:"form_id" gorm:"not null;index"`
+	FormID    uint           `json:"form_id" gorm:"not null;index;uniqueIndex:idx_loan_data_entry"`
 	Form      LoanTypeForm   `json:"-"`
-	Key       string         `json:"key" gorm:"size:255;not null"`
+	Key       string         `json:"key" gorm:"size:255;not null;uniqueIndex:idx_loan_data_entry"`
 	Value     string         `json:"value" gorm:"type:text"`
-	Index     uint           `json:"index" gorm:"default:0"`
+	Index     uint           `json:"index" gorm:"default:0;uniqueIndex:idx_loan_data_entry"`
 	CreatedAt time.Time      `json:"-" gorm:"autoCreateTime:true"`
 	UpdatedAt time.Time      `json:"-" gorm:"autoUpdateTime:true"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// LoanQueryOptions representa la paginación, los filtros y el orden del listado de préstamos de un usuario
+type LoanQueryOptions struct {
+	UserID           uint
+	IncludeCancelled bool
+	Statuses         []LoanStatus // Si se indican, reemplazan la exclusión de cancelados
+	CreatedFrom      *time.Time
+	CreatedTo        *time.Time // Exclusivo
+	SortBy           string     // created_at, updated_at, amount_approved
+	SortOrder        string     // asc, desc
+	Page             int
+	Limit            int
+}
+
 // Requests para la nueva estructura
 // CreateLoanRequest representa la estructura para crear solicitudes de préstamo
 type CreateLoanRequest struct {
@@ -64,6 +134,35 @@ type SaveLoanDataRequest struct {
 	Data   []LoanDataItemRequest `json:"data" validate:"required,dive"`
 }
 
+// FormDataItemRequest representa un valor de un formulario específico
+type FormDataItemRequest struct {
+	Key   string `json:"key" validate:"required"`
+	Value string `json:"value"`
+	Index uint   `json:"index"`
+}
+
+// FormDataKeyRequest identifica un valor de un formulario a eliminar
+type FormDataKeyRequest struct {
+	Key   string `json:"key" validate:"required"`
+	Index uint   `json:"index"`
+}
+
+// ReplaceFormDataRequest representa la estructura para reemplazar los datos de un formulario
+type ReplaceFormDataRequest struct {
+	Data []FormDataItemRequest `json:"data" validate:"dive"`
+}
+
+// PatchFormDataRequest representa la estructura para modificar valores puntuales de un formulario
+type PatchFormDataRequest struct {
+	Data   []FormDataItemRequest `json:"data" validate:"dive"`
+	Delete []FormDataKeyRequest  `json:"delete" validate:"dive"`
+}
+
+// CancelLoanRequest representa la estructura para cancelar un préstamo
+type CancelLoanRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
 // LoanDataItemRequest representa un item de datos de préstamo
 type LoanDataItemRequest struct {
 	FormID uint   `json:"form_id" validate:"required"`
@@ -75,21 +174,46 @@ type LoanDataItemRequest struct {
 // Responses para la nueva estructura
 // LoanResponse representa la respuesta de préstamo
 type LoanResponse struct {
-	ID               uint               `json:"id"`
-	LoanTypeID       uint               `json:"loan_type_id"`
-	LoanType         LoanTypeResponse   `json:"loan_type"`
-	UserID           uint               `json:"user_id"`
-	User             UserResponse       `json:"user"`
-	Status           string             `json:"status"`
-	Observation      string             `json:"observation"`
-	AmountApproved   decimal.Decimal    `json:"amount_approved"`
-	CreditScore      *int               `json:"credit_score,omitempty"`
-	IdentityVerified *bool              `json:"identity_verified,omitempty"`
-	Data             []LoanDataResponse `json:"data"`
-	CreatedAt        time.Time          `json:"created_at"`
-	UpdatedAt        time.Time          `json:"updated_at"`
+	ID                 uint                    `json:"id"`
+	LoanTypeID         uint                    `json:"loan_type_id"`
+	LoanType           LoanTypeResponse        `json:"loan_type"`
+	UserID             uint                    `json:"user_id"`
+	User               UserResponse            `json:"user"`
+	Status             string                  `json:"status"`
+	Observation        string                  `json:"observation"`
+	AmountApproved     decimal.Decimal         `json:"amount_approved"`
+	CreditScore        *int                    `json:"credit_score,omitempty"`
+	IdentityVerified   *bool                   `json:"identity_verified,omitempty"`
+	IdentityMatch      IdentityMatch           `json:"identity_match,omitempty"`
+	Affordability      *AffordabilityBreakdown `json:"affordability,omitempty"`
+	Pricing            *LoanPricing            `json:"pricing,omitempty"`
+	CancelledBy        *uint                   `json:"cancelled_by,omitempty"`
+	CancelledByRole    CancellerRole           `json:"cancelled_by_role,omitempty"`
+	CancelledAt        *time.Time              `json:"cancelled_at,omitempty"`
+	CancellationReason string                  `json:"cancellation_reason,omitempty"`
+	Fields             LoanFields              `json:"fields,omitempty"`
+	Data               []LoanDataResponse      `json:"data,omitempty"`
+	Groups             LoanDataGroups          `json:"groups,omitempty"` // Formularios repetibles por código, un objeto por elemento
+	Offers             []LoanOfferResponse     `json:"offers,omitempty"`
+	Parties            []LoanPartyResponse     `json:"parties,omitempty"`
+	Role               LoanPartyRole           `json:"role,omitempty"` // Rol del usuario autenticado en el préstamo (listado del usuario)
+	CreatedAt          time.Time               `json:"created_at"`
+	UpdatedAt          time.Time               `json:"updated_at"`
 }
 
+// LoanFields representa los datos del préstamo tipados por código de formulario y código de input.
+// Los formularios repetibles contienen un arreglo con un LoanFormFields por elemento
+type LoanFields map[string]interface{}
+
+// LoanFormFields representa los valores tipados de un formulario por código de input
+type LoanFormFields map[string]interface{}
+
+// LoanDataGroups agrupa los elementos de los formularios repetibles por código de formulario
+type LoanDataGroups map[string][]LoanDataGroupItem
+
+// LoanDataGroupItem representa un elemento de un formulario repetible con sus valores por código de input
+type LoanDataGroupItem map[string]string
+
 // LoanDataResponse representa la respuesta de datos de préstamo
 type LoanDataResponse struct {
 	ID     uint   `json:"id"`
@@ -99,6 +223,84 @@ type LoanDataResponse struct {
 	Index  uint   `json:"index"`
 }
 
+// AffordabilityBreakdown representa el desglose del cálculo de capacidad de pago
+type AffordabilityBreakdown struct {
+	MonthlyIncome     decimal.Decimal `json:"monthly_income"`
+	MonthlyExpenses   decimal.Decimal `json:"monthly_expenses"`
+	DisposableIncome  decimal.Decimal `json:"disposable_income"`
+	DebtToIncomeRatio decimal.Decimal `json:"debt_to_income_ratio"`
+	MaxDebtRatio      decimal.Decimal `json:"max_debt_ratio"`
+	MaxMonthlyPayment decimal.Decimal `json:"max_monthly_payment"`
+	TermMonths        int             `json:"term_months"`
+	AnnualRate        decimal.Decimal `json:"annual_rate"`
+	MaxPrincipal      decimal.Decimal `json:"max_principal"`
+	RequestedAmount   decimal.Decimal `json:"requested_amount"`
+}
+
+// GetAffordability deserializa el desglose de capacidad de pago guardado en el préstamo
+func (l *Loan) GetAffordability() *AffordabilityBreakdown {
+	if l.Affordability == "" {
+		return nil
+	}
+
+	var breakdown AffordabilityBreakdown
+	if err := json.Unmarshal([]byte(l.Affordability), &breakdown); err != nil {
+		return nil
+	}
+	return &breakdown
+}
+
+// SetAffordability serializa el desglose de capacidad de pago en el préstamo
+func (l *Loan) SetAffordability(breakdown AffordabilityBreakdown) error {
+	data, err := json.Marshal(breakdown)
+	if err != nil {
+		return err
+	}
+	l.Affordability = string(data)
+	return nil
+}
+
+// LoanPricing representa la tarifa fijada al préstamo en la aprobación
+type LoanPricing struct {
+	Tier                string          `json:"tier"`
+	Amount              decimal.Decimal `json:"amount"`
+	TermMonths          int             `json:"term_months"`
+	NominalAnnualRate   decimal.Decimal `json:"nominal_annual_rate"`
+	MonthlyRate         decimal.Decimal `json:"monthly_rate"`
+	EffectiveAnnualRate decimal.Decimal `json:"effective_annual_rate"`
+	APR                 decimal.Decimal `json:"apr"`
+	OriginationFee      decimal.Decimal `json:"origination_fee"`
+	MonthlyInsurance    decimal.Decimal `json:"monthly_insurance"`
+	MonthlyPayment      decimal.Decimal `json:"monthly_payment"`
+	TotalMonthlyPayment decimal.Decimal `json:"total_monthly_payment"`
+	UsuryRateEA         decimal.Decimal `json:"usury_rate_ea,omitempty"`
+	CappedByUsury       bool            `json:"capped_by_usury"`
+	PricedAt            time.Time       `json:"priced_at"`
+}
+
+// GetPricing deserializa la tarifa fijada en el préstamo
+func (l *Loan) GetPricing() *LoanPricing {
+	if l.Pricing == "" {
+		return nil
+	}
+
+	var pricing LoanPricing
+	if err := json.Unmarshal([]byte(l.Pricing), &pricing); err != nil {
+		return nil
+	}
+	return &pricing
+}
+
+// SetPricing serializa la tarifa en el préstamo
+func (l *Loan) SetPricing(pricing LoanPricing) error {
+	data, err := json.Marshal(pricing)
+	if err != nil {
+		return err
+	}
+	l.Pricing = string(data)
+	return nil
+}
+
 // ToResponse convierte un Loan a LoanResponse
 func (l *Loan) ToResponse() LoanResponse {
 	dataResponse := make([]LoanDataResponse, len(l.Data))
@@ -113,20 +315,34 @@ func (l *Loan) ToResponse() LoanResponse {
 	}
 
 	return LoanResponse{
-		ID:               l.ID,
-		LoanTypeID:       l.LoanTypeID,
-		UserID:           l.UserID,
-		Status:           l.Status,
-		Observation:      l.Observation,
-		AmountApproved:   l.AmountApproved,
-		CreditScore:      l.CreditScore,
-		IdentityVerified: l.IdentityVerified,
-		Data:             dataResponse,
-		CreatedAt:        l.CreatedAt,
-		UpdatedAt:        l.UpdatedAt,
+		ID:                 l.ID,
+		LoanTypeID:         l.LoanTypeID,
+		UserID:             l.UserID,
+		Status:             l.Status,
+		Observation:        l.Observation,
+		AmountApproved:     l.AmountApproved,
+		CreditScore:        l.CreditScore,
+		IdentityVerified:   l.IdentityVerified,
+		IdentityMatch:      l.IdentityMatch,
+		Affordability:      l.GetAffordability(),
+		Pricing:            l.GetPricing(),
+		CancelledBy:        l.CancelledBy,
+		CancelledByRole:    l.CancelledByRole,
+		CancelledAt:        l.CancelledAt,
+		CancellationReason: l.CancellationReason,
+		Data:               dataResponse,
+		Offers:             BuildLoanOffersResponse(l.Offers),
+		Parties:            BuildLoanPartiesResponse(l.Parties),
+		CreatedAt:          l.CreatedAt,
+		UpdatedAt:          l.UpdatedAt,
 	}
 }
 
+// HasActiveReviewClaim verifica si el préstamo tiene una asignación de revisión vigente
+func (l *Loan) HasActiveReviewClaim(now time.Time) bool {
+	return l.ReviewClaimedBy != nil && l.ReviewClaimExpiresAt != nil && l.ReviewClaimExpiresAt.After(now)
+}
+
 // TableName especifica el nombre de la tabla para GORM
 func (Loan) TableName() string {
 	return "loans"
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// ExportFormat define los formatos disponibles para exportar préstamos
+type ExportFormat string
+
+const (
+	ExportFormatCSV  ExportFormat = "csv"
+	ExportFormatXLSX ExportFormat = "xlsx"
+)
+
+// LoanExportStatus define los posibles estados de una exportación de préstamos
+type LoanExportStatus string
+
+const (
+	LoanExportStatusRunning LoanExportStatus = "running" // En curso
+	LoanExportStatusSuccess LoanExportStatus = "success" // Finalizada correctamente
+	LoanExportStatusFailed  LoanExportStatus = "failed"  // Interrumpida por un error
+)
+
+// LoanExportFilter representa los filtros de una exportación de préstamos
+type LoanExportFilter struct {
+	TenantID    uint         `json:"-"`
+	Statuses    []LoanStatus `json:"statuses,omitempty"`
+	LoanTypeID  uint         `json:"loan_type_id,omitempty"`
+	CreatedFrom *time.Time   `json:"created_from,omitempty"`
+	CreatedTo   *time.Time   `json:"created_to,omitempty"` // Exclusivo
+}
+
+// LoanExport registra una exportación de préstamos, quién la solicitó y con qué filtros
+type LoanExport struct {
+	ID          uint             `json:"id" gorm:"primaryKey"`
+	TenantID    uint             `json:"tenant_id" gorm:"not null;index"`
+	RequestedBy uint             `json:"requested_by" gorm:"not null;index"`
+	Format      ExportFormat     `json:"format" gorm:"size:10;not null"`
+	Filters     string           `json:"filters" gorm:"type:text"`
+	Status      LoanExportStatus `json:"status" gorm:"size:20;not null"`
+	RowCount    int              `json:"row_count"`
+	Error       string           `json:"error,omitempty" gorm:"type:text"`
+	StartedAt   time.Time        `json:"started_at" gorm:"not null"`
+	FinishedAt  *time.Time       `json:"finished_at,omitempty"`
+}
+
+// TableName especifica el nombre de la tabla para GORM
+func (LoanExport) TableName() string {
+	return "loan_exports"
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// LoanOfferStatus define los posibles estados de una contraoferta
+type LoanOfferStatus string
+
+const (
+	LoanOfferStatusPending  LoanOfferStatus = "pending"  // Esperando respuesta del solicitante
+	LoanOfferStatusAccepted LoanOfferStatus = "accepted" // Aceptada por el solicitante
+	LoanOfferStatusDeclined LoanOfferStatus = "declined" // Rechazada por el solicitante o descartada al aceptar otra
+	LoanOfferStatusExpired  LoanOfferStatus = "expired"  // Vencida sin respuesta
+)
+
+// LoanOffer representa una contraoferta generada en la decisión de un préstamo
+type LoanOffer struct {
+	ID             uint            `json:"id" gorm:"primaryKey"`
+	LoanID         uint            `json:"loan_id" gorm:"not null;index"`
+	Loan           Loan            `json:"-"`
+	Amount         decimal.Decimal `json:"amount" gorm:"type:decimal(13,2);not null"`
+	TermMonths     int             `json:"term_months" gorm:"not null"`
+	MonthlyPayment decimal.Decimal `json:"monthly_payment" gorm:"type:decimal(13,2);default:0"`
+	Status         LoanOfferStatus `json:"status" gorm:"size:20;not null;default:'pending'"`
+	ExpiresAt      time.Time       `json:"expires_at" gorm:"not null"`
+	RespondedAt    *time.Time      `json:"responded_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"autoCreateTime:true"`
+	UpdatedAt      time.Time       `json:"updated_at" gorm:"autoUpdateTime:true"`
+}
+
+// LoanOfferResponse representa la respuesta de una contraoferta
+type LoanOfferResponse struct {
+	ID             uint            `json:"id"`
+	Amount         decimal.Decimal `json:"amount"`
+	TermMonths     int             `json:"term_months"`
+	MonthlyPayment decimal.Decimal `json:"monthly_payment"`
+	Status         LoanOfferStatus `json:"status"`
+	ExpiresAt      time.Time       `json:"expires_at"`
+	RespondedAt    *time.Time      `json:"responded_at,omitempty"`
+}
+
+// IsExpired verifica si la contraoferta está vencida
+func (o *LoanOffer) IsExpired(now time.Time) bool {
+	return !o.ExpiresAt.After(now)
+}
+
+// BuildLoanOffersResponse convierte las contraofertas a su respuesta
+func BuildLoanOffersResponse(offers []LoanOffer) []LoanOfferResponse {
+	if len(offers) == 0 {
+		return nil
+	}
+
+	response := make([]LoanOfferResponse, len(offers))
+	for i, offer := range offers {
+		response[i] = LoanOfferResponse{
+			ID:             offer.ID,
+			Amount:         offer.Amount,
+			TermMonths:     offer.TermMonths,
+			MonthlyPayment: offer.MonthlyPayment,
+			Status:         offer.Status,
+			ExpiresAt:      offer.ExpiresAt,
+			RespondedAt:    offer.RespondedAt,
+		}
+	}
+	return response
+}
+
+// TableName especifica el nombre de la tabla para GORM
+func (LoanOffer) TableName() string {
+	return "loan_offers"
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// LoanPartyRole define el rol de una persona en un préstamo
+type LoanPartyRole string
+
+const (
+	LoanPartyRolePrimary     LoanPartyRole = "primary"      // Solicitante que creó el préstamo
+	LoanPartyRoleCoApplicant LoanPartyRole = "co_applicant" // Codeudor que responde solidariamente por el préstamo
+	LoanPartyRoleGuarantor   LoanPartyRole = "guarantor"    // Garante que respalda el préstamo
+)
+
+// LoanPartyStatus define el estado de la participación de una persona en un préstamo
+type LoanPartyStatus string
+
+const (
+	LoanPartyStatusInvited  LoanPartyStatus = "invited"  // Invitación enviada, esperando consentimiento
+	LoanPartyStatusAccepted LoanPartyStatus = "accepted" // Participación aceptada con consentimiento
+	LoanPartyStatusDeclined LoanPartyStatus = "declined" // Invitación rechazada
+)
+
+// LoanParty representa a una persona que participa en un préstamo
+type LoanParty struct {
+	ID               uint            `json:"id" gorm:"primaryKey"`
+	LoanID           uint            `json:"loan_id" gorm:"not null;index;uniqueIndex:idx_loan_party"`
+	Loan             Loan            `json:"-"`
+	UserID           uint            `json:"user_id" gorm:"not null;index;uniqueIndex:idx_loan_party"`
+	User             User            `json:"-"`
+	Role             LoanPartyRole   `json:"role" gorm:"size:20;not null"`
+	Status           LoanPartyStatus `json:"status" gorm:"size:20;not null;default:'invited'"`
+	InvitedBy        *uint           `json:"invited_by,omitempty"`
+	MonthlyIncome    decimal.Decimal `json:"monthly_income" gorm:"type:decimal(13,2);default:0"`
+	MonthlyExpenses  decimal.Decimal `json:"monthly_expenses" gorm:"type:decimal(13,2);default:0"`
+	CreditScore      *int            `json:"credit_score,omitempty"`
+	IdentityVerified *bool           `json:"identity_verified,omitempty"`
+	IdentityMatch    IdentityMatch   `json:"identity_match,omitempty" gorm:"size:20"`
+	ConsentedAt      *time.Time      `json:"consented_at,omitempty"`
+	RespondedAt      *time.Time      `json:"responded_at,omitempty"`
+	CreatedAt        time.Time       `json:"created_at" gorm:"autoCreateTime:true"`
+	UpdatedAt        time.Time       `json:"updated_at" gorm:"autoUpdateTime:true"`
+}
+
+// InviteLoanPartyRequest representa la solicitud para invitar a otra persona al préstamo
+type InviteLoanPartyRequest struct {
+	Email string        `json:"email" validate:"required,email"`
+	Role  LoanPartyRole `json:"role" validate:"required"` // co_applicant o guarantor
+}
+
+// AcceptLoanPartyRequest representa la aceptación de una invitación con el consentimiento y los datos del invitado
+type AcceptLoanPartyRequest struct {
+	Consent         bool            `json:"consent"`
+	FullName        string          `json:"full_name" validate:"required"`
+	DocumentType    string          `json:"document_type" validate:"required"`
+	DocumentNumber  string          `json:"document_number" validate:"required"`
+	MonthlyIncome   decimal.Decimal `json:"monthly_income"`
+	MonthlyExpenses decimal.Decimal `json:"monthly_expenses"`
+}
+
+// LoanPartyResponse representa la respuesta de una persona que participa en un préstamo
+type LoanPartyResponse struct {
+	ID               uint            `json:"id"`
+	UserID           uint            `json:"user_id"`
+	UserName         string          `json:"user_name"`
+	Role             LoanPartyRole   `json:"role"`
+	Status           LoanPartyStatus `json:"status"`
+	MonthlyIncome    decimal.Decimal `json:"monthly_income"`
+	MonthlyExpenses  decimal.Decimal `json:"monthly_expenses"`
+	CreditScore      *int            `json:"credit_score,omitempty"`
+	IdentityVerified *bool           `json:"identity_verified,omitempty"`
+	IdentityMatch    IdentityMatch   `json:"identity_match,omitempty"`
+	ConsentedAt      *time.Time      `json:"consented_at,omitempty"`
+	RespondedAt      *time.Time      `json:"responded_at,omitempty"`
+}
+
+// IsInvitable verifica si el rol puede asignarse al invitar a otra persona
+func (r LoanPartyRole) IsInvitable() bool {
+	return r == LoanPartyRoleCoApplicant || r == LoanPartyRoleGuarantor
+}
+
+// BuildLoanPartiesResponse convierte las partes del préstamo a su respuesta
+func BuildLoanPartiesResponse(parties []LoanParty) []LoanPartyResponse {
+	if len(parties) == 0 {
+		return nil
+	}
+
+	response := make([]LoanPartyResponse, len(parties))
+	for i, party := range parties {
+		response[i] = LoanPartyResponse{
+			ID:               party.ID,
+			UserID:           party.UserID,
+			UserName:         party.User.Name,
+			Role:             party.Role,
+			Status:           party.Status,
+			MonthlyIncome:    party.MonthlyIncome,
+			MonthlyExpenses:  party.MonthlyExpenses,
+			CreditScore:      party.CreditScore,
+			IdentityVerified: party.IdentityVerified,
+			IdentityMatch:    party.IdentityMatch,
+			ConsentedAt:      party.ConsentedAt,
+			RespondedAt:      party.RespondedAt,
+		}
+	}
+	return response
+}
+
+// TableName especifica el nombre de la tabla para GORM
+func (LoanParty) TableName() string {
+	return "loan_parties"
+}
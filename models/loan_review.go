@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ReviewAction define las acciones que un analista puede registrar sobre un préstamo
+type ReviewAction string
+
+const (
+	ReviewActionClaim       ReviewAction = "claim"        // El analista toma el préstamo
+	ReviewActionRelease     ReviewAction = "release"      // El analista libera el préstamo
+	ReviewActionApprove     ReviewAction = "approve"      // El analista aprueba el préstamo
+	ReviewActionReject      ReviewAction = "reject"       // El analista rechaza el préstamo
+	ReviewActionRequestInfo ReviewAction = "request_info" // El analista solicita más información
+)
+
+// LoanReviewAction representa una acción registrada durante la revisión manual de un préstamo
+type LoanReviewAction struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	LoanID    uint         `json:"loan_id" gorm:"not null;index"`
+	Loan      Loan         `json:"-"`
+	AnalystID uint         `json:"analyst_id" gorm:"not null;index"`
+	Analyst   User         `json:"-" gorm:"foreignKey:AnalystID"`
+	Action    ReviewAction `json:"action" gorm:"size:30;not null"`
+	Comment   string       `json:"comment" gorm:"type:text"`
+	CreatedAt time.Time    `json:"created_at" gorm:"autoCreateTime:true"`
+}
+
+// ReviewQueueFilter representa los filtros y el orden de la cola de revisión
+type ReviewQueueFilter struct {
+	TenantID   uint
+	AnalystID  uint
+	Status     string
+	Assignment string // all, mine, unclaimed
+	LoanTypeID uint
+	MinScore   *int
+	MaxScore   *int
+	SortBy     string // created_at, updated_at, credit_score
+	SortOrder  string // asc, desc
+}
+
+// ReviewDecisionRequest representa la acción de un analista sobre un préstamo en revisión
+type ReviewDecisionRequest struct {
+	Comment string `json:"comment" validate:"required"`
+}
+
+// ReviewQueueItemResponse representa un préstamo dentro de la cola de revisión
+type ReviewQueueItemResponse struct {
+	ID                   uint            `json:"id"`
+	LoanTypeID           uint            `json:"loan_type_id"`
+	LoanTypeName         string          `json:"loan_type_name"`
+	UserID               uint            `json:"user_id"`
+	UserName             string          `json:"user_name"`
+	Status               string          `json:"status"`
+	Observation          string          `json:"observation"`
+	CreditScore          *int            `json:"credit_score,omitempty"`
+	IdentityMatch        IdentityMatch   `json:"identity_match,omitempty"`
+	RequestedAmount      decimal.Decimal `json:"requested_amount"`
+	ReviewClaimedBy      *uint           `json:"review_claimed_by,omitempty"`
+	ReviewClaimExpiresAt *time.Time      `json:"review_claim_expires_at,omitempty"`
+	CreatedAt            time.Time       `json:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at"`
+}
+
+// TableName especifica el nombre de la tabla para GORM
+func (LoanReviewAction) TableName() string {
+	return "loan_review_actions"
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// LoanSearchFilter representa los criterios de búsqueda de préstamos del back-office
+type LoanSearchFilter struct {
+	TenantID       uint
+	DocumentNumber string
+	Email          string
+	Name           string // Prefijo del nombre del solicitante, sin distinguir mayúsculas
+	Statuses       []LoanStatus
+	LoanTypeID     uint
+	MinAmount      *decimal.Decimal
+	MaxAmount      *decimal.Decimal
+	MinScore       *int
+	MaxScore       *int
+	CreatedFrom    *time.Time
+	CreatedTo      *time.Time // Exclusivo
+	AfterID        uint       // Cursor: se obtienen los préstamos con ID menor
+	Limit          int
+}
+
+// LoanSearchItem representa el resumen de un préstamo en los resultados de búsqueda
+type LoanSearchItem struct {
+	ID             uint            `json:"id"`
+	LoanTypeID     uint            `json:"loan_type_id"`
+	LoanTypeName   string          `json:"loan_type_name"`
+	UserID         uint            `json:"user_id"`
+	ApplicantName  string          `json:"applicant_name"`
+	ApplicantEmail string          `json:"applicant_email"`
+	DocumentNumber string          `json:"document_number"`
+	Status         string          `json:"status"`
+	AmountApproved decimal.Decimal `json:"amount_approved"`
+	CreditScore    *int            `json:"credit_score,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
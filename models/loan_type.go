@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
@@ -8,19 +9,20 @@ import (
 
 // LoanType representa un tipo de crédito
 type LoanType struct {
-	ID          uint              `json:"id" gorm:"primaryKey"`
-	TenantID    uint              `json:"tenant_id" gorm:"not null;index"`
-	Tenant      Tenant            `json:"tenant,omitempty"`
-	Name        string            `json:"name" gorm:"size:255;not null"`
-	Code        string            `json:"code" gorm:"size:50;not null"`
-	Description string            `json:"description" gorm:"type:text"`
-	IsActive    bool              `json:"is_active" gorm:"default:true"`
-	MinAmount   float64           `json:"min_amount" gorm:"type:decimal(15,2);default:0"`
-	MaxAmount   float64           `json:"max_amount" gorm:"type:decimal(15,2);default:0"`
-	Versions    []LoanTypeVersion `json:"versions,omitempty"`
-	CreatedAt   time.Time         `json:"created_at" gorm:"autoCreateTime:true"`
-	UpdatedAt   time.Time         `json:"updated_at" gorm:"autoUpdateTime:true"`
-	DeletedAt   gorm.DeletedAt    `json:"-" gorm:"index"`
+	ID                  uint              `json:"id" gorm:"primaryKey"`
+	TenantID            uint              `json:"tenant_id" gorm:"not null;index"`
+	Tenant              Tenant            `json:"tenant,omitempty"`
+	Name                string            `json:"name" gorm:"size:255;not null"`
+	Code                string            `json:"code" gorm:"size:50;not null"`
+	Description         string            `json:"description" gorm:"type:text"`
+	IsActive            bool              `json:"is_active" gorm:"default:true"`
+	MinAmount           float64           `json:"min_amount" gorm:"precision:15;scale:2;default:0"`
+	MaxAmount           float64           `json:"max_amount" gorm:"precision:15;scale:2;default:0"`
+	ApplicationTTLHours int               `json:"application_ttl_hours" gorm:"default:0"` // Horas sin cambios tras las cuales una solicitud pendiente o en progreso expira (0 = no expira)
+	Versions            []LoanTypeVersion `json:"versions,omitempty"`
+	CreatedAt           time.Time         `json:"created_at" gorm:"autoCreateTime:true"`
+	UpdatedAt           time.Time         `json:"updated_at" gorm:"autoUpdateTime:true"`
+	DeletedAt           gorm.DeletedAt    `json:"-" gorm:"index"`
 }
 
 // LoanTypeVersion representa una versión de un tipo de crédito
@@ -32,13 +34,100 @@ type LoanTypeVersion struct {
 	Description string         `json:"description" gorm:"type:text"`
 	IsActive    bool           `json:"is_active" gorm:"default:true"`
 	IsDefault   bool           `json:"is_default" gorm:"default:false"`
-	Config      string         `json:"config" gorm:"type:json"`
+	Config      JSON           `json:"config"`
 	Forms       []LoanTypeForm `json:"forms,omitempty"`
 	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime:true"`
 	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime:true"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// LoanTypeVersionConfig representa la configuración JSON de una versión de tipo de crédito
+type LoanTypeVersionConfig struct {
+	ApprovalRules ApprovalRulesConfig `json:"approval_rules"`
+	Affordability AffordabilityConfig `json:"affordability"`
+	ManualReview  ManualReviewConfig  `json:"manual_review"`
+	Offers        OffersConfig        `json:"offers"`
+	Pricing       PricingConfig       `json:"pricing"`
+	Parties       PartiesConfig       `json:"parties"`
+}
+
+// ApprovalRulesConfig contiene las reglas de aprobación de la versión
+type ApprovalRulesConfig struct {
+	MinIncome    float64 `json:"min_income"`
+	MaxDebtRatio float64 `json:"max_debt_ratio"`
+}
+
+// AffordabilityConfig contiene el plazo y la tasa usados para calcular el monto máximo financiable
+type AffordabilityConfig struct {
+	TermMonths int     `json:"term_months"`
+	AnnualRate float64 `json:"annual_rate"`
+}
+
+// ManualReviewConfig contiene las condiciones que envían una solicitud a revisión manual
+type ManualReviewConfig struct {
+	Enabled                bool    `json:"enabled"`
+	ScoreMin               int     `json:"score_min"`                 // Inicio de la banda gris de score
+	ScoreMax               int     `json:"score_max"`                 // Fin de la banda gris de score
+	OnIdentityPartialMatch bool    `json:"on_identity_partial_match"` // Revisar coincidencias parciales de identidad
+	AmountThreshold        float64 `json:"amount_threshold"`          // Montos solicitados desde este valor requieren revisión
+}
+
+// PartiesConfig contiene las reglas sobre codeudores y garantes para la decisión
+type PartiesConfig struct {
+	RequiredRoles      []LoanPartyRole `json:"required_roles"`       // Roles que deben haber aceptado antes de la decisión
+	CombineIncomeRoles []LoanPartyRole `json:"combine_income_roles"` // Roles cuyos ingresos y gastos se suman a la capacidad de pago
+	MinPartyScore      int             `json:"min_party_score"`      // Score mínimo de cada codeudor o garante aceptado
+}
+
+// OffersConfig contiene los plazos en meses para los que se generan contraofertas
+type OffersConfig struct {
+	Terms []int `json:"terms"`
+}
+
+// PricingConfig contiene los tramos de riesgo usados para fijar la tasa del préstamo
+type PricingConfig struct {
+	Tiers []PricingTier `json:"tiers"`
+}
+
+// PricingTier define la tasa, comisión y seguro de un tramo de riesgo.
+// Los límites en cero no restringen el tramo
+type PricingTier struct {
+	Name               string  `json:"name"`
+	MinScore           int     `json:"min_score"`
+	MaxScore           int     `json:"max_score"`
+	MinAmount          float64 `json:"min_amount"`
+	MaxAmount          float64 `json:"max_amount"`
+	MinTermMonths      int     `json:"min_term_months"`
+	MaxTermMonths      int     `json:"max_term_months"`
+	NominalAnnualRate  float64 `json:"nominal_annual_rate"`  // Tasa nominal anual mes vencido
+	OriginationFeeRate float64 `json:"origination_fee_rate"` // Comisión de estudio sobre el monto, cobrada al desembolso
+	InsuranceRate      float64 `json:"insurance_rate"`       // Seguro mensual sobre el monto
+}
+
+// Matches verifica si el tramo aplica al score, monto y plazo indicados
+func (t PricingTier) Matches(score int, amount float64, termMonths int) bool {
+	if score < t.MinScore || (t.MaxScore > 0 && score > t.MaxScore) {
+		return false
+	}
+	if amount < t.MinAmount || (t.MaxAmount > 0 && amount > t.MaxAmount) {
+		return false
+	}
+	if termMonths < t.MinTermMonths || (t.MaxTermMonths > 0 && termMonths > t.MaxTermMonths) {
+		return false
+	}
+	return true
+}
+
+// ParseConfig deserializa la configuración JSON de la versión
+func (v *LoanTypeVersion) ParseConfig() (LoanTypeVersionConfig, error) {
+	var cfg LoanTypeVersionConfig
+	if v.Config == "" {
+		return cfg, nil
+	}
+	err := json.Unmarshal([]byte(v.Config), &cfg)
+	return cfg, err
+}
+
 // LoanTypeForm representa un formulario disponible para un tipo de crédito
 type LoanTypeForm struct {
 	ID                uint                       `json:"id" gorm:"primaryKey"`
@@ -50,13 +139,31 @@ type LoanTypeForm struct {
 	Order             int                        `json:"order" gorm:"default:0"`
 	IsRequired        bool                       `json:"is_required" gorm:"default:false"`
 	IsActive          bool                       `json:"is_active" gorm:"default:true"`
-	Config            string                     `json:"config" gorm:"type:json"`
+	Config            JSON                       `json:"config"`
 	FormInputs        []LoanTypeVersionFormInput `json:"form_inputs,omitempty" gorm:"foreignKey:LoanTypeFormID"`
 	CreatedAt         time.Time                  `json:"created_at" gorm:"autoCreateTime:true"`
 	UpdatedAt         time.Time                  `json:"updated_at" gorm:"autoUpdateTime:true"`
 	DeletedAt         gorm.DeletedAt             `json:"-" gorm:"index"`
 }
 
+// LoanTypeFormConfig representa la configuración JSON de un formulario
+type LoanTypeFormConfig struct {
+	Section    string `json:"section"`
+	Repeatable bool   `json:"repeatable"` // Permite varios elementos del formulario (referencias, deudas, etc.)
+	MinItems   int    `json:"min_items"`  // Mínimo de elementos requeridos para un formulario repetible
+	MaxItems   int    `json:"max_items"`  // Máximo de elementos permitidos; 0 indica sin límite
+}
+
+// ParseConfig deserializa la configuración JSON del formulario
+func (f *LoanTypeForm) ParseConfig() (LoanTypeFormConfig, error) {
+	var cfg LoanTypeFormConfig
+	if f.Config == "" {
+		return cfg, nil
+	}
+	err := json.Unmarshal([]byte(f.Config), &cfg)
+	return cfg, err
+}
+
 // LoanTypeVersionFormInput representa un input para la configuración de formularios
 type LoanTypeVersionFormInput struct {
 	ID              uint           `json:"id" gorm:"primaryKey"`
@@ -67,12 +174,12 @@ type LoanTypeVersionFormInput struct {
 	InputType       string         `json:"input_type" gorm:"size:50;not null"` // text, number, email, select, etc.
 	Placeholder     string         `json:"placeholder" gorm:"size:255"`
 	DefaultValue    string         `json:"default_value" gorm:"type:text"`
-	ValidationRules string         `json:"validation_rules" gorm:"type:json"`
-	Options         string         `json:"options" gorm:"type:json"`
+	ValidationRules JSON           `json:"validation_rules"`
+	Options         JSON           `json:"options"`
 	Order           int            `json:"order" gorm:"default:0"`
 	IsRequired      bool           `json:"is_required" gorm:"default:false"`
 	IsActive        bool           `json:"is_active" gorm:"default:true"`
-	Config          string         `json:"config" gorm:"type:json"`
+	Config          JSON           `json:"config"`
 	CreatedAt       time.Time      `json:"created_at" gorm:"autoCreateTime:true"`
 	UpdatedAt       time.Time      `json:"updated_at" gorm:"autoUpdateTime:true"`
 	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
@@ -105,19 +212,51 @@ type LoanTypeFormResponse struct {
 	Description string                             `json:"description"`
 	Order       int                                `json:"order"`
 	IsRequired  bool                               `json:"is_required"`
+	Repeatable  bool                               `json:"repeatable"`
+	MinItems    int                                `json:"min_items,omitempty"`
+	MaxItems    int                                `json:"max_items,omitempty"`
 	FormInputs  []LoanTypeVersionFormInputResponse `json:"form_inputs"`
 }
 
 // LoanTypeVersionFormInputResponse representa la respuesta de un input
 type LoanTypeVersionFormInputResponse struct {
-	ID              uint   `json:"id"`
-	Label           string `json:"label"`
-	Code            string `json:"code"`
-	InputType       string `json:"input_type"`
-	Placeholder     string `json:"placeholder"`
-	DefaultValue    string `json:"default_value"`
-	ValidationRules string `json:"validation_rules"`
-	Options         string `json:"options"`
-	Order           int    `json:"order"`
-	IsRequired      bool   `json:"is_required"`
+	ID              uint            `json:"id"`
+	Label           string          `json:"label"`
+	Code            string          `json:"code"`
+	InputType       string          `json:"input_type"`
+	Placeholder     string          `json:"placeholder"`
+	DefaultValue    string          `json:"default_value"`
+	ValidationRules string          `json:"validation_rules"`
+	Options         string          `json:"options"`
+	Order           int             `json:"order"`
+	IsRequired      bool            `json:"is_required"`
+	VisibleWhen     *InputCondition `json:"visible_when,omitempty"`
+	RequiredWhen    *InputCondition `json:"required_when,omitempty"`
+	Visible         *bool           `json:"visible,omitempty"`  // Estado evaluado con las respuestas de un préstamo
+	Required        *bool           `json:"required,omitempty"` // Estado evaluado con las respuestas de un préstamo
+}
+
+// SelectOption representa una opción de un input de selección
+type SelectOption struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// ParseOptions deserializa las opciones del input. Admite una lista de valores
+// (la etiqueta es el mismo valor) o una lista de objetos con valor y etiqueta
+func (i *LoanTypeVersionFormInput) ParseOptions() []SelectOption {
+	var options []SelectOption
+	if err := json.Unmarshal([]byte(i.Options), &options); err == nil {
+		return options
+	}
+
+	var values []string
+	if err := json.Unmarshal([]byte(i.Options), &values); err != nil {
+		return nil
+	}
+	options = make([]SelectOption, len(values))
+	for idx, value := range values {
+		options[idx] = SelectOption{Value: value, Label: value}
+	}
+	return options
 }
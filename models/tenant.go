@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
@@ -13,13 +14,33 @@ type Tenant struct {
 	Code        string         `json:"code" gorm:"size:50;uniqueIndex;not null"`
 	Description string         `json:"description" gorm:"type:text"`
 	IsActive    bool           `json:"is_active" gorm:"default:true"`
-	Config      string         `json:"config" gorm:"type:json"`
+	Config      JSON           `json:"config"`
 	LoanTypes   []LoanType     `json:"loan_types,omitempty"`
 	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime:true"`
 	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime:true"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// TenantConfig representa la configuración JSON de un tenant
+type TenantConfig struct {
+	MaxLoanAmount        float64 `json:"max_loan_amount"`
+	MinCreditScore       int     `json:"min_credit_score"`
+	OfferExpirationHours int     `json:"offer_expiration_hours"`
+	UsuryRateEA          float64 `json:"usury_rate_ea"` // Tasa de usura vigente, efectiva anual
+	// RateLimits reemplaza el límite de solicitudes por grupo de rutas (auth, loans), por ejemplo {"loans": "300/1m"}
+	RateLimits map[string]string `json:"rate_limits,omitempty"`
+}
+
+// ParseConfig deserializa la configuración JSON del tenant
+func (t *Tenant) ParseConfig() (TenantConfig, error) {
+	var cfg TenantConfig
+	if t.Config == "" {
+		return cfg, nil
+	}
+	err := json.Unmarshal([]byte(t.Config), &cfg)
+	return cfg, err
+}
+
 // TenantResponse representa la respuesta del tenant
 type TenantResponse struct {
 	ID          uint   `json:"id"`
@@ -15,17 +15,27 @@ const (
 	DocumentTypeTarjetaIdentidad DocumentType = "tarjeta_identidad"
 )
 
+// UserRole representa los roles disponibles para un usuario
+type UserRole string
+
+const (
+	UserRoleApplicant UserRole = "applicant" // Solicitante de préstamos
+	UserRoleAnalyst   UserRole = "analyst"   // Analista de riesgo (revisión manual)
+	UserRoleAdmin     UserRole = "admin"     // Administrador del tenant
+)
+
 // User representa un usuario en el sistema
 type User struct {
 	ID             uint           `json:"id" gorm:"primaryKey"`
 	TenantID       uint           `json:"tenant_id" gorm:"not null;index"`
-	Name           string         `json:"name" gorm:"type:varchar(100);not null" validate:"required,min=2,max=100"`
+	Name           string         `json:"name" gorm:"type:varchar(100);not null;index" validate:"required,min=2,max=100"`
 	Email          string         `json:"email" gorm:"type:varchar(100);uniqueIndex;not null" validate:"required,email"`
 	Phone          string         `json:"phone" gorm:"type:varchar(20);not null" validate:"required,min=10,max=20"`
 	DocumentType   DocumentType   `json:"document_type" gorm:"type:varchar(20);not null" validate:"required"`
 	DocumentNumber string         `json:"document_number" gorm:"type:varchar(20);uniqueIndex;not null" validate:"required,min=5,max=20"`
 	Password       string         `json:"-" gorm:"type:varchar(255);not null" validate:"required,min=8"`
-	Income         *float64       `json:"income" gorm:"type:decimal(15,2);default:0"`
+	Role           UserRole       `json:"role" gorm:"type:varchar(20);not null;default:'applicant'"`
+	Income         *float64       `json:"income" gorm:"precision:15;scale:2;default:0"`
 	IP             string         `json:"ip,omitempty" gorm:"type:varchar(45)"`
 	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime:true"`
 	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime:true"`
@@ -62,6 +72,7 @@ type UserResponse struct {
 	Phone          string       `json:"phone"`
 	DocumentType   DocumentType `json:"document_type"`
 	DocumentNumber string       `json:"document_number"`
+	Role           UserRole     `json:"role"`
 	CreatedAt      time.Time    `json:"created_at"`
 	UpdatedAt      time.Time    `json:"updated_at"`
 }
@@ -82,6 +93,7 @@ func (u *User) ToResponse() UserResponse {
 		Phone:          u.Phone,
 		DocumentType:   u.DocumentType,
 		DocumentNumber: u.DocumentNumber,
+		Role:           u.Role,
 		CreatedAt:      u.CreatedAt,
 		UpdatedAt:      u.UpdatedAt,
 	}
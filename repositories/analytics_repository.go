@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"loan-api/models"
+
+	"gorm.io/gorm"
+)
+
+// Estados que implican que la solicitud alcanzó cada etapa del embudo
+var (
+	funnelOnProgressStatuses = []models.LoanStatus{
+		models.LoanStatusOnProgress, models.LoanStatusCompleted, models.LoanStatusManualReview,
+		models.LoanStatusOfferPending, models.LoanStatusDisbursing, models.LoanStatusApproved, models.LoanStatusRejected,
+	}
+	funnelCompletedStatuses = []models.LoanStatus{
+		models.LoanStatusCompleted, models.LoanStatusManualReview, models.LoanStatusOfferPending,
+		models.LoanStatusDisbursing, models.LoanStatusApproved, models.LoanStatusRejected,
+	}
+)
+
+// AnalyticsRepository interface para las agregaciones de analítica del portafolio
+type AnalyticsRepository interface {
+	GetFunnelAggregates(ctx context.Context, filter models.AnalyticsFilter) ([]models.FunnelAggregate, error)
+	GetRejectionReasons(ctx context.Context, filter models.AnalyticsFilter) ([]models.RejectionReasonAggregate, error)
+}
+
+// analyticsRepository implementación del repository
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+// NewAnalyticsRepository crea una nueva instancia del repository
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+// tenantLoans construye la consulta de préstamos del tenant creados en el rango
+func (r *analyticsRepository) tenantLoans(ctx context.Context, filter models.AnalyticsFilter) *gorm.DB {
+	query := dbFromContext(ctx, r.db).Model(&models.Loan{}).
+		Joins("JOIN loan_types ON loan_types.id = loans.loan_type_id").
+		Where("loan_types.tenant_id = ?", filter.TenantID).
+		Where("loans.created_at >= ? AND loans.created_at < ?", filter.From, filter.To)
+	if filter.LoanTypeID != 0 {
+		query = query.Where("loans.loan_type_id = ?", filter.LoanTypeID)
+	}
+	return query
+}
+
+// GetFunnelAggregates agrega el embudo por tipo de préstamo y día de creación. La etapa alcanzada se deduce
+// del estado actual, y se devuelven sumas en lugar de promedios para poder agrupar por semana o mes
+func (r *analyticsRepository) GetFunnelAggregates(ctx context.Context, filter models.AnalyticsFilter) ([]models.FunnelAggregate, error) {
+	var aggregates []models.FunnelAggregate
+	err := r.tenantLoans(ctx, filter).
+		Select("loans.loan_type_id, DATE(loans.created_at) AS day, COUNT(*) AS started, "+
+			"SUM(CASE WHEN loans.status IN ? THEN 1 ELSE 0 END) AS on_progress, "+
+			"SUM(CASE WHEN loans.status IN ? THEN 1 ELSE 0 END) AS completed, "+
+			"SUM(CASE WHEN loans.status = ? THEN 1 ELSE 0 END) AS approved, "+
+			"SUM(CASE WHEN loans.status = ? THEN 1 ELSE 0 END) AS rejected, "+
+			"SUM(CASE WHEN loans.status = ? THEN 1 ELSE 0 END) AS cancelled, "+
+			"SUM(CASE WHEN loans.status = ? THEN 1 ELSE 0 END) AS expired, "+
+			"SUM(CASE WHEN loans.credit_score > 0 THEN loans.credit_score ELSE 0 END) AS score_sum, "+
+			"SUM(CASE WHEN loans.credit_score > 0 THEN 1 ELSE 0 END) AS score_count, "+
+			"SUM(CASE WHEN loans.status = ? THEN loans.amount_approved ELSE 0 END) AS approved_amount_sum",
+			funnelOnProgressStatuses, funnelCompletedStatuses,
+			models.LoanStatusApproved, models.LoanStatusRejected, models.LoanStatusCancelled, models.LoanStatusExpired,
+			models.LoanStatusApproved).
+		Group("loans.loan_type_id, DATE(loans.created_at)").
+		Scan(&aggregates).Error
+	return aggregates, err
+}
+
+// GetRejectionReasons agrega los préstamos rechazados por tipo de préstamo y observación
+func (r *analyticsRepository) GetRejectionReasons(ctx context.Context, filter models.AnalyticsFilter) ([]models.RejectionReasonAggregate, error) {
+	var reasons []models.RejectionReasonAggregate
+	err := r.tenantLoans(ctx, filter).
+		Select("loans.loan_type_id, loans.observation AS reason, COUNT(*) AS count").
+		Where("loans.status = ?", models.LoanStatusRejected).
+		Group("loans.loan_type_id, loans.observation").
+		Scan(&reasons).Error
+	return reasons, err
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"loan-api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepository interface para las claves de idempotencia de las solicitudes
+type IdempotencyRepository interface {
+	Acquire(ctx context.Context, record *models.IdempotencyKey, now time.Time) (*models.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, id uint, status int, contentType, body string) error
+	Release(ctx context.Context, id uint) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// idempotencyRepository implementación del repository
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository crea una nueva instancia del repository
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Acquire registra la clave como en curso y retorna true si la solicitud debe procesarse.
+// Si la clave ya existe retorna el registro guardado y false, salvo que la solicitud original haya
+// abandonado el bloqueo con la misma huella, en cuyo caso lo toma. Las claves vencidas se descartan
+func (r *idempotencyRepository) Acquire(ctx context.Context, record *models.IdempotencyKey, now time.Time) (*models.IdempotencyKey, bool, error) {
+	scope := func() *gorm.DB {
+		return dbFromContext(ctx, r.db).
+			Where("tenant_id = ? AND user_id = ? AND idempotency_key = ?", record.TenantID, record.UserID, record.Key)
+	}
+
+	// Una clave vencida puede volver a usarse
+	if err := scope().Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, false, err
+	}
+
+	// El índice único garantiza que solo una solicitud concurrente registre la clave
+	result := dbFromContext(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return record, true, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := scope().First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+
+	// Tomar el bloqueo de una solicitud que no terminó (por ejemplo, si la réplica se detuvo)
+	if existing.Status == models.IdempotencyKeyStatusInProgress && !existing.LockedUntil.After(now) && existing.Fingerprint == record.Fingerprint {
+		taken := dbFromContext(ctx, r.db).Model(&models.IdempotencyKey{}).
+			Where("id = ? AND status = ? AND locked_until <= ?", existing.ID, models.IdempotencyKeyStatusInProgress, now).
+			Updates(map[string]interface{}{
+				"locked_until": record.LockedUntil,
+				"expires_at":   record.ExpiresAt,
+			})
+		if taken.Error != nil {
+			return nil, false, taken.Error
+		}
+		if taken.RowsAffected == 1 {
+			existing.LockedUntil = record.LockedUntil
+			existing.ExpiresAt = record.ExpiresAt
+			return &existing, true, nil
+		}
+	}
+
+	return &existing, false, nil
+}
+
+// Complete guarda la respuesta de la solicitud original para repetirla en los reintentos
+func (r *idempotencyRepository) Complete(ctx context.Context, id uint, status int, contentType, body string) error {
+	return dbFromContext(ctx, r.db).Model(&models.IdempotencyKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":                models.IdempotencyKeyStatusCompleted,
+			"response_status":       status,
+			"response_content_type": contentType,
+			"response_body":         body,
+		}).Error
+}
+
+// Release elimina la clave para que la solicitud pueda reintentarse con ella
+func (r *idempotencyRepository) Release(ctx context.Context, id uint) error {
+	return dbFromContext(ctx, r.db).Delete(&models.IdempotencyKey{}, id).Error
+}
+
+// DeleteExpired elimina las claves vencidas
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := dbFromContext(ctx, r.db).Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
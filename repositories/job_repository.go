@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"loan-api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobRepository interface para el bloqueo y el historial de las tareas programadas
+type JobRepository interface {
+	AcquireLease(ctx context.Context, jobName, owner string, now, lockedUntil time.Time) (bool, error)
+	RenewLease(ctx context.Context, jobName, owner string, lockedUntil time.Time) (bool, error)
+	ReleaseLease(ctx context.Context, jobName, owner string, lockedUntil time.Time) error
+	CreateRun(ctx context.Context, run *models.JobRun) error
+	UpdateRun(ctx context.Context, run *models.JobRun) error
+	GetRuns(ctx context.Context, jobName string, limit int) ([]models.JobRun, error)
+}
+
+// jobRepository implementación del repository
+type jobRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRepository crea una nueva instancia del repository
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+// AcquireLease toma el bloqueo de la tarea si está libre o vencido. Retorna false si otra réplica lo tiene
+func (r *jobRepository) AcquireLease(ctx context.Context, jobName, owner string, now, lockedUntil time.Time) (bool, error) {
+	// Asegurar que exista la fila del bloqueo sin sobrescribir un bloqueo vigente
+	lease := models.JobLease{JobName: jobName, LockedUntil: now}
+	if err := dbFromContext(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(&lease).Error; err != nil {
+		return false, err
+	}
+
+	// Actualización condicional: solo una réplica puede tomar el bloqueo
+	result := dbFromContext(ctx, r.db).Model(&models.JobLease{}).
+		Where("job_name = ? AND (locked_until <= ? OR owner = ?)", jobName, now, owner).
+		Updates(map[string]interface{}{
+			"owner":        owner,
+			"locked_until": lockedUntil,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RenewLease extiende el bloqueo de la tarea si aún pertenece al propietario indicado. Retorna false si
+// otra réplica lo tomó después de vencerse
+func (r *jobRepository) RenewLease(ctx context.Context, jobName, owner string, lockedUntil time.Time) (bool, error) {
+	result := dbFromContext(ctx, r.db).Model(&models.JobLease{}).
+		Where("job_name = ? AND owner = ?", jobName, owner).
+		Update("locked_until", lockedUntil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseLease libera el bloqueo de la tarea a partir de lockedUntil si pertenece al propietario indicado
+func (r *jobRepository) ReleaseLease(ctx context.Context, jobName, owner string, lockedUntil time.Time) error {
+	return dbFromContext(ctx, r.db).Model(&models.JobLease{}).
+		Where("job_name = ? AND owner = ?", jobName, owner).
+		Update("locked_until", lockedUntil).Error
+}
+
+// CreateRun registra el inicio de una ejecución
+func (r *jobRepository) CreateRun(ctx context.Context, run *models.JobRun) error {
+	return dbFromContext(ctx, r.db).Create(run).Error
+}
+
+// UpdateRun actualiza el resultado de una ejecución
+func (r *jobRepository) UpdateRun(ctx context.Context, run *models.JobRun) error {
+	return dbFromContext(ctx, r.db).Save(run).Error
+}
+
+// GetRuns obtiene las ejecuciones más recientes, opcionalmente filtradas por tarea
+func (r *jobRepository) GetRuns(ctx context.Context, jobName string, limit int) ([]models.JobRun, error) {
+	query := dbFromContext(ctx, r.db).Model(&models.JobRun{})
+	if jobName != "" {
+		query = query.Where("job_name = ?", jobName)
+	}
+
+	var runs []models.JobRun
+	err := query.Order("started_at DESC, id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"loan-api/models"
+
+	"gorm.io/gorm"
+)
+
+// LoanExportRepository interface para operaciones de exportación de préstamos
+type LoanExportRepository interface {
+	Create(ctx context.Context, export *models.LoanExport) error
+	Update(ctx context.Context, export *models.LoanExport) error
+	GetLoansBatch(ctx context.Context, filter models.LoanExportFilter, afterID uint, limit int) ([]models.Loan, error)
+}
+
+// loanExportRepository implementación del repository
+type loanExportRepository struct {
+	db *gorm.DB
+}
+
+// NewLoanExportRepository crea una nueva instancia del repository
+func NewLoanExportRepository(db *gorm.DB) LoanExportRepository {
+	return &loanExportRepository{db: db}
+}
+
+// Create registra una exportación
+func (r *loanExportRepository) Create(ctx context.Context, export *models.LoanExport) error {
+	return dbFromContext(ctx, r.db).Create(export).Error
+}
+
+// Update actualiza el resultado de una exportación
+func (r *loanExportRepository) Update(ctx context.Context, export *models.LoanExport) error {
+	return dbFromContext(ctx, r.db).Save(export).Error
+}
+
+// GetLoansBatch obtiene el siguiente lote de préstamos del tenant a exportar, ordenados por ID a partir de afterID,
+// con el solicitante y sus datos para no cargar todos los préstamos en memoria
+func (r *loanExportRepository) GetLoansBatch(ctx context.Context, filter models.LoanExportFilter, afterID uint, limit int) ([]models.Loan, error) {
+	query := dbFromContext(ctx, r.db).Model(&models.Loan{}).
+		Joins("JOIN loan_types ON loan_types.id = loans.loan_type_id").
+		Where("loan_types.tenant_id = ? AND loans.id > ?", filter.TenantID, afterID)
+
+	if len(filter.Statuses) > 0 {
+		query = query.Where("loans.status IN ?", filter.Statuses)
+	}
+	if filter.LoanTypeID != 0 {
+		query = query.Where("loans.loan_type_id = ?", filter.LoanTypeID)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("loans.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("loans.created_at < ?", *filter.CreatedTo)
+	}
+
+	var loans []models.Loan
+	err := query.Order("loans.id ASC").
+		Limit(limit).
+		Preload("LoanType").
+		Preload("User").
+		Preload("Data").
+		Find(&loans).Error
+	return loans, err
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"loan-api/models"
+
+	"gorm.io/gorm"
+)
+
+// LoanPartyRepository interface para operaciones de codeudores y garantes de un préstamo
+type LoanPartyRepository interface {
+	Create(ctx context.Context, party *models.LoanParty) error
+	GetByLoanAndUser(ctx context.Context, loanID, userID uint) (*models.LoanParty, error)
+	GetByLoanID(ctx context.Context, loanID uint) ([]models.LoanParty, error)
+	Update(ctx context.Context, party *models.LoanParty) error
+}
+
+// loanPartyRepository implementación del repository
+type loanPartyRepository struct {
+	db *gorm.DB
+}
+
+// NewLoanPartyRepository crea una nueva instancia del repository
+func NewLoanPartyRepository(db *gorm.DB) LoanPartyRepository {
+	return &loanPartyRepository{db: db}
+}
+
+// Create crea una nueva parte del préstamo
+func (r *loanPartyRepository) Create(ctx context.Context, party *models.LoanParty) error {
+	return dbFromContext(ctx, r.db).Create(party).Error
+}
+
+// GetByLoanAndUser obtiene la participación de un usuario en un préstamo
+func (r *loanPartyRepository) GetByLoanAndUser(ctx context.Context, loanID, userID uint) (*models.LoanParty, error) {
+	var party models.LoanParty
+	err := dbFromContext(ctx, r.db).Where("loan_id = ? AND user_id = ?", loanID, userID).
+		Preload("User").
+		First(&party).Error
+	if err != nil {
+		return nil, err
+	}
+	return &party, nil
+}
+
+// GetByLoanID obtiene todas las partes de un préstamo
+func (r *loanPartyRepository) GetByLoanID(ctx context.Context, loanID uint) ([]models.LoanParty, error) {
+	var parties []models.LoanParty
+	err := dbFromContext(ctx, r.db).Where("loan_id = ?", loanID).
+		Preload("User").
+		Order("id ASC").
+		Find(&parties).Error
+	return parties, err
+}
+
+// Update actualiza una parte del préstamo
+func (r *loanPartyRepository) Update(ctx context.Context, party *models.LoanParty) error {
+	return dbFromContext(ctx, r.db).Omit("User", "Loan").Save(party).Error
+}
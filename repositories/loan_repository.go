@@ -1,20 +1,35 @@
 package repositories
 
 import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"loan-api/app_error"
 	"loan-api/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // LoanRepository interface para operaciones de préstamo
 type LoanRepository interface {
-	Create(loan *models.Loan) error
-	GetByID(id uint) (*models.Loan, error)
-	GetByUserID(userID uint) ([]models.Loan, error)
-	Update(loan *models.Loan) error
-	SaveLoanData(loanData []models.LoanData) error
-	GetLoanDataByLoanID(loanID uint) ([]models.LoanData, error)
-	DeleteLoanDataByLoanID(loanID uint) error
+	Create(ctx context.Context, loan *models.Loan) error
+	GetByID(ctx context.Context, id uint) (*models.Loan, error)
+	GetByUserID(ctx context.Context, opts models.LoanQueryOptions) ([]models.Loan, int64, error)
+	Search(ctx context.Context, filter models.LoanSearchFilter) ([]models.LoanSearchItem, error)
+	Update(ctx context.Context, loan *models.Loan) error
+	SaveLoanData(ctx context.Context, loanData []models.LoanData) error
+	ReplaceFormData(ctx context.Context, loanID, formID uint, loanData []models.LoanData) error
+	DeleteLoanData(ctx context.Context, loanID, formID uint, keys []models.FormDataKeyRequest) error
+	GetLoanDataByLoanID(ctx context.Context, loanID uint) ([]models.LoanData, error)
+	CreateOffers(ctx context.Context, offers []models.LoanOffer) error
+	GetOfferByID(ctx context.Context, loanID, offerID uint) (*models.LoanOffer, error)
+	UpdateOffer(ctx context.Context, offer *models.LoanOffer) error
+	DeclinePendingOffers(ctx context.Context, loanID uint, exceptOfferID uint, respondedAt time.Time) error
+	ExpireStale(ctx context.Context, loanTypeID uint, idleSince time.Time, observation string) (int64, error)
+	ExpireOffers(ctx context.Context, now time.Time, observation string) (int64, error)
 }
 
 // loanRepository implementación del repository
@@ -28,17 +43,19 @@ func NewLoanRepository(db *gorm.DB) LoanRepository {
 }
 
 // Create crea un nuevo préstamo
-func (r *loanRepository) Create(loan *models.Loan) error {
-	return r.db.Create(loan).Error
+func (r *loanRepository) Create(ctx context.Context, loan *models.Loan) error {
+	return dbFromContext(ctx, r.db).Create(loan).Error
 }
 
 // GetByID obtiene un préstamo por ID con todas sus relaciones
-func (r *loanRepository) GetByID(id uint) (*models.Loan, error) {
+func (r *loanRepository) GetByID(ctx context.Context, id uint) (*models.Loan, error) {
 	var loan models.Loan
-	err := r.db.Where("id = ?", id).
+	err := dbFromContext(ctx, r.db).Where("id = ?", id).
 		Preload("LoanType").
 		Preload("User").
 		Preload("Data").
+		Preload("Offers").
+		Preload("Parties.User").
 		First(&loan).Error
 	if err != nil {
 		return nil, err
@@ -46,27 +63,204 @@ func (r *loanRepository) GetByID(id uint) (*models.Loan, error) {
 	return &loan, nil
 }
 
-// GetByUserID obtiene todos los préstamos de un usuario
-func (r *loanRepository) GetByUserID(userID uint) ([]models.Loan, error) {
+// loanSortColumns columnas permitidas para ordenar el listado de préstamos
+var loanSortColumns = map[string]string{
+	"created_at":      "created_at",
+	"updated_at":      "updated_at",
+	"amount_approved": "amount_approved",
+}
+
+// GetByUserID obtiene una página de los préstamos de un usuario, incluidos aquellos en los que participa
+// como codeudor o garante, junto con el total de préstamos que cumplen los filtros
+func (r *loanRepository) GetByUserID(ctx context.Context, opts models.LoanQueryOptions) ([]models.Loan, int64, error) {
+	partyLoans := dbFromContext(ctx, r.db).Model(&models.LoanParty{}).
+		Select("loan_id").
+		Where("user_id = ? AND status <> ?", opts.UserID, models.LoanPartyStatusDeclined)
+	query := dbFromContext(ctx, r.db).Model(&models.Loan{}).Where("user_id = ? OR id IN (?)", opts.UserID, partyLoans)
+
+	switch {
+	case len(opts.Statuses) > 0:
+		query = query.Where("status IN ?", opts.Statuses)
+	case !opts.IncludeCancelled:
+		query = query.Where("status <> ?", string(models.LoanStatusCancelled))
+	}
+	if opts.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *opts.CreatedFrom)
+	}
+	if opts.CreatedTo != nil {
+		query = query.Where("created_at < ?", *opts.CreatedTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	sortColumn, ok := loanSortColumns[opts.SortBy]
+	if !ok {
+		sortColumn = loanSortColumns["created_at"]
+	}
+	sortOrder := "DESC"
+	if opts.SortOrder == "asc" {
+		sortOrder = "ASC"
+	}
+
 	var loans []models.Loan
-	err := r.db.Where("user_id = ?", userID).
-		Preload("LoanType").
+	if total == 0 {
+		return loans, 0, nil
+	}
+
+	// El ID desempata el orden para que las páginas sean estables
+	err := query.Order(sortColumn + " " + sortOrder).
+		Order("id " + sortOrder).
+		Offset((opts.Page - 1) * opts.Limit).
+		Limit(opts.Limit).
 		Preload("User").
 		Preload("Data").
+		Preload("Offers").
+		Preload("Parties.User").
 		Find(&loans).Error
-	return loans, err
+	return loans, total, err
+}
+
+// loanSearchColumns columnas de la proyección resumida de la búsqueda de préstamos
+const loanSearchColumns = "loans.id, loans.loan_type_id, loan_types.name AS loan_type_name, loans.user_id, " +
+	"users.name AS applicant_name, users.email AS applicant_email, users.document_number, " +
+	"loans.status, loans.amount_approved, loans.credit_score, loans.created_at, loans.updated_at"
+
+// Search busca los préstamos del tenant con los criterios indicados y obtiene su resumen en una sola consulta,
+// ordenados del más reciente al más antiguo por ID para paginar con cursor
+func (r *loanRepository) Search(ctx context.Context, filter models.LoanSearchFilter) ([]models.LoanSearchItem, error) {
+	query := dbFromContext(ctx, r.db).Model(&models.Loan{}).
+		Select(loanSearchColumns).
+		Joins("JOIN loan_types ON loan_types.id = loans.loan_type_id").
+		Joins("JOIN users ON users.id = loans.user_id").
+		Where("loan_types.tenant_id = ?", filter.TenantID)
+
+	if filter.DocumentNumber != "" {
+		query = query.Where("users.document_number = ?", filter.DocumentNumber)
+	}
+	if filter.Email != "" {
+		query = query.Where("users.email = ?", filter.Email)
+	}
+	if filter.Name != "" {
+		query = query.Where(nameSearchCondition(query), strings.ToLower(filter.Name)+"%")
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("loans.status IN ?", filter.Statuses)
+	}
+	if filter.LoanTypeID != 0 {
+		query = query.Where("loans.loan_type_id = ?", filter.LoanTypeID)
+	}
+	if filter.MinAmount != nil {
+		query = query.Where("loans.amount_approved >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query = query.Where("loans.amount_approved <= ?", *filter.MaxAmount)
+	}
+	if filter.MinScore != nil {
+		query = query.Where("loans.credit_score >= ?", *filter.MinScore)
+	}
+	if filter.MaxScore != nil {
+		query = query.Where("loans.credit_score <= ?", *filter.MaxScore)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("loans.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("loans.created_at < ?", *filter.CreatedTo)
+	}
+	if filter.AfterID != 0 {
+		query = query.Where("loans.id < ?", filter.AfterID)
+	}
+
+	var items []models.LoanSearchItem
+	err := query.Order("loans.id DESC").Limit(filter.Limit).Scan(&items).Error
+	return items, err
+}
+
+// nameSearchCondition retorna la condición de búsqueda por prefijo del nombre del solicitante, sin distinguir
+// mayúsculas, que usa el índice de cada motor: en Postgres LIKE distingue mayúsculas y se compara en minúsculas
+// con idx_users_name_lower; en MySQL (collation sin distinción de mayúsculas) y SQLite (LIKE sin distinción e
+// índice NOCASE) se compara la columna directamente
+func nameSearchCondition(db *gorm.DB) string {
+	if db.Dialector.Name() == "postgres" {
+		return "LOWER(users.name) LIKE ?"
+	}
+	return "users.name LIKE ?"
 }
 
-// Update actualiza un préstamo
-func (r *loanRepository) Update(loan *models.Loan) error {
-	return r.db.Save(loan).Error
+// Update actualiza un préstamo solo si no cambió desde que se leyó, comparando su versión.
+// Retorna app_error.ErrLoanConflict si otra operación lo modificó antes
+func (r *loanRepository) Update(ctx context.Context, loan *models.Loan) error {
+	readVersion := loan.Version
+	loan.Version++
+
+	// Los datos y contraofertas tienen sus propias operaciones; no se reescriben al guardar el préstamo
+	result := dbFromContext(ctx, r.db).Model(loan).
+		Where("version = ?", readVersion).
+		Select("*").
+		Omit(clause.Associations, "id", "created_at").
+		Updates(loan)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = app_error.ErrLoanConflict
+	}
+	if result.Error != nil {
+		loan.Version = readVersion
+		return result.Error
+	}
+	return nil
 }
 
-// SaveLoanData guarda los datos de un préstamo
-func (r *loanRepository) SaveLoanData(loanData []models.LoanData) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+// SaveLoanData inserta o actualiza los datos del préstamo por (loan_id, form_id, key, index) sin tocar los demás
+func (r *loanRepository) SaveLoanData(ctx context.Context, loanData []models.LoanData) error {
+	if len(loanData) == 0 {
+		return nil
+	}
+	return upsertLoanData(dbFromContext(ctx, r.db), loanData)
+}
+
+// ReplaceFormData reemplaza los datos de un formulario del préstamo: elimina los valores que no vienen y
+// actualiza o inserta los recibidos, conservando los IDs de los existentes
+func (r *loanRepository) ReplaceFormData(ctx context.Context, loanID, formID uint, loanData []models.LoanData) error {
+	return dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var existing []models.LoanData
+		if err := tx.Where("loan_id = ? AND form_id = ?", loanID, formID).Find(&existing).Error; err != nil {
+			return err
+		}
+
+		keep := make(map[string]bool, len(loanData))
 		for _, data := range loanData {
-			if err := tx.Create(&data).Error; err != nil {
+			keep[loanDataEntryKey(data.Key, data.Index)] = true
+		}
+
+		var staleIDs []uint
+		for _, data := range existing {
+			if !keep[loanDataEntryKey(data.Key, data.Index)] {
+				staleIDs = append(staleIDs, data.ID)
+			}
+		}
+		if len(staleIDs) > 0 {
+			if err := tx.Unscoped().Delete(&models.LoanData{}, staleIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(loanData) == 0 {
+			return nil
+		}
+		return upsertLoanData(tx, loanData)
+	})
+}
+
+// DeleteLoanData elimina valores puntuales de un formulario del préstamo
+func (r *loanRepository) DeleteLoanData(ctx context.Context, loanID, formID uint, keys []models.FormDataKeyRequest) error {
+	return dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for _, key := range keys {
+			err := tx.Unscoped().
+				Where(map[string]interface{}{"loan_id": loanID, "form_id": formID, "key": key.Key, "index": key.Index}).
+				Delete(&models.LoanData{}).Error
+			if err != nil {
 				return err
 			}
 		}
@@ -74,16 +268,97 @@ func (r *loanRepository) SaveLoanData(loanData []models.LoanData) error {
 	})
 }
 
+// upsertLoanData inserta los datos o actualiza el valor si ya existe la entrada (loan_id, form_id, key, index)
+func upsertLoanData(db *gorm.DB, loanData []models.LoanData) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "loan_id"}, {Name: "form_id"}, {Name: "key"}, {Name: "index"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at", "deleted_at"}),
+	}).Create(&loanData).Error
+}
+
+// loanDataEntryKey identifica un valor dentro de un formulario
+func loanDataEntryKey(key string, index uint) string {
+	return key + "#" + strconv.FormatUint(uint64(index), 10)
+}
+
 // GetLoanDataByLoanID obtiene todos los datos de un préstamo
-func (r *loanRepository) GetLoanDataByLoanID(loanID uint) ([]models.LoanData, error) {
+func (r *loanRepository) GetLoanDataByLoanID(ctx context.Context, loanID uint) ([]models.LoanData, error) {
 	var loanData []models.LoanData
-	err := r.db.Where("loan_id = ?", loanID).
+	err := dbFromContext(ctx, r.db).Where("loan_id = ?", loanID).
 		Preload("Form").
 		Find(&loanData).Error
 	return loanData, err
 }
 
-// DeleteLoanDataByLoanID elimina todos los datos de un préstamo
-func (r *loanRepository) DeleteLoanDataByLoanID(loanID uint) error {
-	return r.db.Where("loan_id = ?", loanID).Delete(&models.LoanData{}).Error
+// CreateOffers guarda las contraofertas generadas para un préstamo
+func (r *loanRepository) CreateOffers(ctx context.Context, offers []models.LoanOffer) error {
+	if len(offers) == 0 {
+		return nil
+	}
+	return dbFromContext(ctx, r.db).Create(&offers).Error
+}
+
+// GetOfferByID obtiene una contraoferta de un préstamo
+func (r *loanRepository) GetOfferByID(ctx context.Context, loanID, offerID uint) (*models.LoanOffer, error) {
+	var offer models.LoanOffer
+	err := dbFromContext(ctx, r.db).Where("id = ? AND loan_id = ?", offerID, loanID).First(&offer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+// UpdateOffer actualiza una contraoferta
+func (r *loanRepository) UpdateOffer(ctx context.Context, offer *models.LoanOffer) error {
+	return dbFromContext(ctx, r.db).Save(offer).Error
+}
+
+// DeclinePendingOffers marca como rechazadas las contraofertas pendientes de un préstamo, excepto la indicada
+func (r *loanRepository) DeclinePendingOffers(ctx context.Context, loanID uint, exceptOfferID uint, respondedAt time.Time) error {
+	return dbFromContext(ctx, r.db).Model(&models.LoanOffer{}).
+		Where("loan_id = ? AND id <> ? AND status = ?", loanID, exceptOfferID, models.LoanOfferStatusPending).
+		Updates(map[string]interface{}{
+			"status":       models.LoanOfferStatusDeclined,
+			"responded_at": respondedAt,
+		}).Error
+}
+
+// ExpireStale marca como expiradas las solicitudes pendientes o en progreso del tipo sin cambios desde idleSince
+func (r *loanRepository) ExpireStale(ctx context.Context, loanTypeID uint, idleSince time.Time, observation string) (int64, error) {
+	result := dbFromContext(ctx, r.db).Model(&models.Loan{}).
+		Where("loan_type_id = ? AND status IN ? AND updated_at < ?", loanTypeID,
+			[]string{string(models.LoanStatusPending), string(models.LoanStatusOnProgress)}, idleSince).
+		Updates(map[string]interface{}{
+			"status":      string(models.LoanStatusExpired),
+			"observation": observation,
+			"version":     gorm.Expr("version + 1"),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// ExpireOffers vence las contraofertas pendientes cuyo plazo terminó antes de now y marca como expirados
+// los préstamos con contraofertas pendientes que se quedaron sin ninguna vigente. Retorna los préstamos expirados
+func (r *loanRepository) ExpireOffers(ctx context.Context, now time.Time, observation string) (int64, error) {
+	var expired int64
+	err := dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.LoanOffer{}).
+			Where("status = ? AND expires_at < ?", models.LoanOfferStatusPending, now).
+			Update("status", models.LoanOfferStatusExpired).Error
+		if err != nil {
+			return err
+		}
+
+		pendingOffers := tx.Model(&models.LoanOffer{}).Select("1").
+			Where("loan_offers.loan_id = loans.id AND loan_offers.status = ?", models.LoanOfferStatusPending)
+		result := tx.Model(&models.Loan{}).
+			Where("status = ? AND NOT EXISTS (?)", models.LoanStatusOfferPending, pendingOffers).
+			Updates(map[string]interface{}{
+				"status":      string(models.LoanStatusExpired),
+				"observation": observation,
+				"version":     gorm.Expr("version + 1"),
+			})
+		expired = result.RowsAffected
+		return result.Error
+	})
+	return expired, err
 }
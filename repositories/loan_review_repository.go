@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"loan-api/models"
+
+	"gorm.io/gorm"
+)
+
+// LoanReviewRepository interface para operaciones de la cola de revisión manual
+type LoanReviewRepository interface {
+	GetQueue(ctx context.Context, filter models.ReviewQueueFilter, now time.Time) ([]models.Loan, error)
+	Claim(ctx context.Context, loanID, analystID uint, now, expiresAt time.Time) (bool, error)
+	Release(ctx context.Context, loanID, analystID uint) (bool, error)
+	CreateAction(ctx context.Context, action *models.LoanReviewAction) error
+	GetActionsByLoanID(ctx context.Context, loanID uint) ([]models.LoanReviewAction, error)
+}
+
+// loanReviewRepository implementación del repository
+type loanReviewRepository struct {
+	db *gorm.DB
+}
+
+// NewLoanReviewRepository crea una nueva instancia del repository
+func NewLoanReviewRepository(db *gorm.DB) LoanReviewRepository {
+	return &loanReviewRepository{db: db}
+}
+
+// reviewSortColumns columnas permitidas para ordenar la cola de revisión
+var reviewSortColumns = map[string]string{
+	"created_at":   "loans.created_at",
+	"updated_at":   "loans.updated_at",
+	"credit_score": "loans.credit_score",
+}
+
+// GetQueue obtiene los préstamos de la cola de revisión del tenant aplicando filtros y orden
+func (r *loanReviewRepository) GetQueue(ctx context.Context, filter models.ReviewQueueFilter, now time.Time) ([]models.Loan, error) {
+	query := dbFromContext(ctx, r.db).Model(&models.Loan{}).
+		Joins("JOIN loan_types ON loan_types.id = loans.loan_type_id").
+		Where("loan_types.tenant_id = ?", filter.TenantID)
+
+	status := filter.Status
+	if status == "" {
+		status = string(models.LoanStatusManualReview)
+	}
+	query = query.Where("loans.status = ?", status)
+
+	switch filter.Assignment {
+	case "mine":
+		query = query.Where("loans.review_claimed_by = ? AND loans.review_claim_expires_at > ?", filter.AnalystID, now)
+	case "unclaimed":
+		query = query.Where("(loans.review_claimed_by IS NULL OR loans.review_claim_expires_at <= ?)", now)
+	}
+
+	if filter.LoanTypeID != 0 {
+		query = query.Where("loans.loan_type_id = ?", filter.LoanTypeID)
+	}
+	if filter.MinScore != nil {
+		query = query.Where("loans.credit_score >= ?", *filter.MinScore)
+	}
+	if filter.MaxScore != nil {
+		query = query.Where("loans.credit_score <= ?", *filter.MaxScore)
+	}
+
+	sortColumn, ok := reviewSortColumns[filter.SortBy]
+	if !ok {
+		sortColumn = reviewSortColumns["created_at"]
+	}
+	sortOrder := "ASC"
+	if filter.SortOrder == "desc" {
+		sortOrder = "DESC"
+	}
+
+	var loans []models.Loan
+	err := query.Order(sortColumn + " " + sortOrder).
+		Preload("LoanType").
+		Preload("User").
+		Preload("Data").
+		Find(&loans).Error
+	return loans, err
+}
+
+// Claim asigna un préstamo en revisión a un analista si no tiene una asignación vigente de otro analista
+func (r *loanReviewRepository) Claim(ctx context.Context, loanID, analystID uint, now, expiresAt time.Time) (bool, error) {
+	result := dbFromContext(ctx, r.db).Model(&models.Loan{}).
+		Where("id = ? AND status = ?", loanID, models.LoanStatusManualReview).
+		Where("(review_claimed_by IS NULL OR review_claim_expires_at <= ? OR review_claimed_by = ?)", now, analystID).
+		Updates(map[string]interface{}{
+			"review_claimed_by":       analystID,
+			"review_claimed_at":       now,
+			"review_claim_expires_at": expiresAt,
+			"version":                 gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Release libera la asignación de un préstamo si pertenece al analista
+func (r *loanReviewRepository) Release(ctx context.Context, loanID, analystID uint) (bool, error) {
+	result := dbFromContext(ctx, r.db).Model(&models.Loan{}).
+		Where("id = ? AND review_claimed_by = ?", loanID, analystID).
+		Updates(map[string]interface{}{
+			"review_claimed_by":       nil,
+			"review_claimed_at":       nil,
+			"review_claim_expires_at": nil,
+			"version":                 gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CreateAction registra una acción de revisión
+func (r *loanReviewRepository) CreateAction(ctx context.Context, action *models.LoanReviewAction) error {
+	return dbFromContext(ctx, r.db).Create(action).Error
+}
+
+// GetActionsByLoanID obtiene el historial de acciones de revisión de un préstamo
+func (r *loanReviewRepository) GetActionsByLoanID(ctx context.Context, loanID uint) ([]models.LoanReviewAction, error) {
+	var actions []models.LoanReviewAction
+	err := dbFromContext(ctx, r.db).Where("loan_id = ?", loanID).
+		Order("created_at ASC").
+		Find(&actions).Error
+	return actions, err
+}
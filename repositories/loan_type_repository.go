@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"loan-api/models"
 
 	"gorm.io/gorm"
@@ -8,10 +9,12 @@ import (
 
 // LoanTypeRepository interface para operaciones de loan type
 type LoanTypeRepository interface {
-	GetByTenantID(tenantID uint) ([]models.LoanType, error)
-	GetByTenantIDAndCode(tenantID uint, code string) (*models.LoanType, error)
-	GetByIDWithForms(id uint) (*models.LoanType, error)
-	GetActiveByTenantID(tenantID uint) ([]models.LoanType, error)
+	GetByTenantID(ctx context.Context, tenantID uint) ([]models.LoanType, error)
+	GetByTenantIDAndCode(ctx context.Context, tenantID uint, code string) (*models.LoanType, error)
+	GetByIDWithForms(ctx context.Context, id uint) (*models.LoanType, error)
+	GetByIDsWithForms(ctx context.Context, ids []uint) ([]models.LoanType, error)
+	GetActiveByTenantID(ctx context.Context, tenantID uint) ([]models.LoanType, error)
+	GetWithApplicationTTL(ctx context.Context) ([]models.LoanType, error)
 }
 
 // loanTypeRepository implementación del repository
@@ -25,18 +28,18 @@ func NewLoanTypeRepository(db *gorm.DB) LoanTypeRepository {
 }
 
 // GetByTenantID obtiene todos los tipos de préstamo por tenant
-func (r *loanTypeRepository) GetByTenantID(tenantID uint) ([]models.LoanType, error) {
+func (r *loanTypeRepository) GetByTenantID(ctx context.Context, tenantID uint) ([]models.LoanType, error) {
 	var loanTypes []models.LoanType
-	err := r.db.Where("tenant_id = ?", tenantID).
+	err := dbFromContext(ctx, r.db).Where("tenant_id = ?", tenantID).
 		Preload("Versions").
 		Find(&loanTypes).Error
 	return loanTypes, err
 }
 
 // GetByTenantIDAndCode obtiene un tipo de préstamo por tenant y código
-func (r *loanTypeRepository) GetByTenantIDAndCode(tenantID uint, code string) (*models.LoanType, error) {
+func (r *loanTypeRepository) GetByTenantIDAndCode(ctx context.Context, tenantID uint, code string) (*models.LoanType, error) {
 	var loanType models.LoanType
-	err := r.db.Where("tenant_id = ? AND code = ? AND is_active = ?", tenantID, code, true).
+	err := dbFromContext(ctx, r.db).Where("tenant_id = ? AND code = ? AND is_active = ?", tenantID, code, true).
 		Preload("Versions", "is_active = ?", true).
 		Preload("Versions.Forms", "is_active = ?", true).
 		Preload("Versions.FormInputs", "is_active = ?", true).
@@ -48,9 +51,9 @@ func (r *loanTypeRepository) GetByTenantIDAndCode(tenantID uint, code string) (*
 }
 
 // GetByIDWithForms obtiene un tipo de préstamo con todos sus formularios
-func (r *loanTypeRepository) GetByIDWithForms(id uint) (*models.LoanType, error) {
+func (r *loanTypeRepository) GetByIDWithForms(ctx context.Context, id uint) (*models.LoanType, error) {
 	var loanType models.LoanType
-	err := r.db.Where("id = ? AND is_active = ?", id, true).
+	err := dbFromContext(ctx, r.db).Where("id = ? AND is_active = ?", id, true).
 		Preload("Versions", "is_active = ? AND is_default = ?", true, true).
 		Preload("Versions.Forms", "is_active = ?", true).
 		Preload("Versions.Forms.FormInputs", "is_active = ?", true).
@@ -61,11 +64,33 @@ func (r *loanTypeRepository) GetByIDWithForms(id uint) (*models.LoanType, error)
 	return &loanType, nil
 }
 
+// GetByIDsWithForms obtiene en una sola consulta por relación los tipos de préstamo activos indicados
+// con todos sus formularios
+func (r *loanTypeRepository) GetByIDsWithForms(ctx context.Context, ids []uint) ([]models.LoanType, error) {
+	var loanTypes []models.LoanType
+	if len(ids) == 0 {
+		return loanTypes, nil
+	}
+	err := dbFromContext(ctx, r.db).Where("id IN ? AND is_active = ?", ids, true).
+		Preload("Versions", "is_active = ? AND is_default = ?", true, true).
+		Preload("Versions.Forms", "is_active = ?", true).
+		Preload("Versions.Forms.FormInputs", "is_active = ?", true).
+		Find(&loanTypes).Error
+	return loanTypes, err
+}
+
 // GetActiveByTenantID obtiene todos los tipos de préstamo activos por tenant
-func (r *loanTypeRepository) GetActiveByTenantID(tenantID uint) ([]models.LoanType, error) {
+func (r *loanTypeRepository) GetActiveByTenantID(ctx context.Context, tenantID uint) ([]models.LoanType, error) {
 	var loanTypes []models.LoanType
-	err := r.db.Where("tenant_id = ? AND is_active = ?", tenantID, true).
+	err := dbFromContext(ctx, r.db).Where("tenant_id = ? AND is_active = ?", tenantID, true).
 		Preload("Versions", "is_active = ? AND is_default = ?", true, true).
 		Find(&loanTypes).Error
 	return loanTypes, err
 }
+
+// GetWithApplicationTTL obtiene los tipos de préstamo de todos los tenants que tienen TTL de solicitudes configurado
+func (r *loanTypeRepository) GetWithApplicationTTL(ctx context.Context) ([]models.LoanType, error) {
+	var loanTypes []models.LoanType
+	err := dbFromContext(ctx, r.db).Where("application_ttl_hours > ?", 0).Find(&loanTypes).Error
+	return loanTypes, err
+}
@@ -0,0 +1,550 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"loan-api/app_error"
+	"loan-api/config"
+	"loan-api/models"
+	"loan-api/repositories"
+	"loan-api/services"
+	"loan-api/test"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var databases []*test.TestDatabase
+
+func TestMain(m *testing.M) {
+	cfg, err := config.LoadConfig("../")
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	databases, err = test.OpenTestDatabases(cfg)
+	if err != nil {
+		log.Fatalf("Error opening test databases: %v", err)
+	}
+
+	code := m.Run()
+
+	for _, testDB := range databases {
+		if testDB.DB != nil {
+			test.ClearDatabase(testDB.DB)
+		}
+		testDB.Close()
+	}
+
+	os.Exit(code)
+}
+
+// forEachDriver ejecuta la prueba contra cada motor disponible con los datos de prueba recién cargados
+func forEachDriver(t *testing.T, fn func(t *testing.T, db *gorm.DB)) {
+	for _, testDB := range databases {
+		t.Run(testDB.Driver, func(t *testing.T) {
+			if testDB.DB == nil {
+				t.Skipf("%s omitido: %s", testDB.Driver, testDB.SkipReason)
+			}
+			test.LoadTestData(testDB.DB)
+			fn(t, testDB.DB)
+		})
+	}
+}
+
+func TestLoanRepository_GetByUserID(t *testing.T) {
+	t.Run("Debería filtrar, ordenar y paginar igual en todos los motores", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			ctx := context.Background()
+			repo := repositories.NewLoanRepository(db)
+
+			opts := models.LoanQueryOptions{
+				UserID:    1,
+				Statuses:  []models.LoanStatus{models.LoanStatusPending},
+				SortBy:    "amount_approved",
+				SortOrder: "asc",
+				Page:      1,
+				Limit:     1,
+			}
+			loans, total, err := repo.GetByUserID(ctx, opts)
+			c.NoError(err)
+			c.Equal(int64(2), total)
+			c.Len(loans, 1)
+			c.Equal(uint(1), loans[0].ID)
+			c.True(decimal.NewFromInt(10000000).Equal(loans[0].AmountApproved))
+
+			opts.Page = 2
+			loans, _, err = repo.GetByUserID(ctx, opts)
+			c.NoError(err)
+			c.Len(loans, 1)
+			c.Equal(uint(6), loans[0].ID)
+		})
+	})
+}
+
+func TestLoanRepository_Context(t *testing.T) {
+	t.Run("Debería abortar la consulta si el contexto fue cancelado", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			repo := repositories.NewLoanRepository(db)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := repo.GetByID(ctx, 1)
+			c.ErrorIs(err, context.Canceled)
+
+			_, err = repo.GetByID(context.Background(), 1)
+			c.NoError(err)
+		})
+	})
+}
+
+func TestLoanRepository_Update(t *testing.T) {
+	t.Run("Debería rechazar la actualización de un préstamo modificado después de leerlo", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			ctx := context.Background()
+			repo := repositories.NewLoanRepository(db)
+
+			first, err := repo.GetByID(ctx, 1)
+			c.NoError(err)
+			stale, err := repo.GetByID(ctx, 1)
+			c.NoError(err)
+
+			first.Status = string(models.LoanStatusOnProgress)
+			c.NoError(repo.Update(ctx, first))
+			c.Equal(stale.Version+1, first.Version)
+
+			stale.Status = string(models.LoanStatusCancelled)
+			c.ErrorIs(repo.Update(ctx, stale), app_error.ErrLoanConflict)
+			c.Equal(first.Version-1, stale.Version)
+
+			loan, err := repo.GetByID(ctx, 1)
+			c.NoError(err)
+			c.Equal(string(models.LoanStatusOnProgress), loan.Status)
+			c.Equal(first.Version, loan.Version)
+		})
+	})
+}
+
+// countingDisbursementProvider cuenta los desembolsos realizados con el proveedor simulado
+type countingDisbursementProvider struct {
+	services.DisbursementProvider
+	calls atomic.Int64
+}
+
+func (p *countingDisbursementProvider) Disburse(ctx context.Context, userID uint, amount decimal.Decimal) (bool, error) {
+	p.calls.Add(1)
+	return p.DisbursementProvider.Disburse(ctx, userID, amount)
+}
+
+func TestLoanService_ConcurrentDecisions(t *testing.T) {
+	t.Run("Debería desembolsar una sola vez ante decisiones simultáneas en todos los motores", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			ctx := context.Background()
+
+			// Préstamo 1 completo y con validaciones que llevan a la aprobación
+			c.NoError(db.Create(&[]models.LoanData{
+				{LoanID: 1, FormID: 2, Key: "monthly_income", Value: "5000000"},
+				{LoanID: 1, FormID: 2, Key: "monthly_expenses", Value: "2000000"},
+				{LoanID: 1, FormID: 3, Key: "requested_amount", Value: "2000000"},
+			}).Error)
+			c.NoError(db.Model(&models.Loan{}).Where("id = ?", 1).Updates(map[string]interface{}{
+				"status":            models.LoanStatusCompleted,
+				"credit_score":      720,
+				"identity_verified": true,
+				"identity_match":    models.IdentityMatchFull,
+			}).Error)
+
+			disbursement := &countingDisbursementProvider{DisbursementProvider: services.NewSimulatedDisbursementProvider()}
+			loanService := services.NewLoanService(repositories.NewLoanRepository(db), repositories.NewUserRepository(db),
+				repositories.NewLoanTypeRepository(db), repositories.NewTenantRepository(db), repositories.NewTransactionManager(db), disbursement)
+
+			const decisions = 8
+			errs := make([]error, decisions)
+			var wg sync.WaitGroup
+			for i := 0; i < decisions; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					_, errs[i] = loanService.ProcessLoanDecision(ctx, 1)
+				}(i)
+			}
+			wg.Wait()
+
+			succeeded := 0
+			for _, err := range errs {
+				if err == nil {
+					succeeded++
+					continue
+				}
+				// Pierden por conflicto de versión o porque leen el préstamo ya decidido
+				appErr, ok := app_error.IsAppError(err)
+				c.True(ok, err)
+				c.Equal(409, appErr.Code, err)
+			}
+			c.Equal(1, succeeded)
+			c.Equal(int64(1), disbursement.calls.Load())
+
+			loan, err := repositories.NewLoanRepository(db).GetByID(ctx, 1)
+			c.NoError(err)
+			c.Equal(string(models.LoanStatusApproved), loan.Status)
+		})
+	})
+}
+
+func TestTransactionManager_WithinTransaction(t *testing.T) {
+	t.Run("Debería revertir todas las operaciones si alguna falla", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			repo := repositories.NewLoanRepository(db)
+			txManager := repositories.NewTransactionManager(db)
+			injected := errors.New("falla simulada")
+
+			err := txManager.WithinTransaction(context.Background(), func(ctx context.Context) error {
+				c.NoError(repo.SaveLoanData(ctx, []models.LoanData{{LoanID: 1, FormID: 1, Key: "full_name", Value: "Juan Pérez"}}))
+
+				loan, err := repo.GetByID(ctx, 1)
+				c.NoError(err)
+				c.Len(loan.Data, 1)
+				loan.Status = string(models.LoanStatusOnProgress)
+				c.NoError(repo.Update(ctx, loan))
+				return injected
+			})
+			c.ErrorIs(err, injected)
+
+			loan, err := repo.GetByID(context.Background(), 1)
+			c.NoError(err)
+			c.Empty(loan.Data)
+			c.Equal("pending", loan.Status)
+		})
+	})
+
+	t.Run("Debería confirmar las operaciones, incluidas las de transacciones anidadas", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			repo := repositories.NewLoanRepository(db)
+			txManager := repositories.NewTransactionManager(db)
+
+			err := txManager.WithinTransaction(context.Background(), func(ctx context.Context) error {
+				if err := repo.SaveLoanData(ctx, []models.LoanData{{LoanID: 1, FormID: 1, Key: "full_name", Value: "Juan Pérez"}}); err != nil {
+					return err
+				}
+				return txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+					return repo.ReplaceFormData(ctx, 1, 2, []models.LoanData{{LoanID: 1, FormID: 2, Key: "monthly_income", Value: "5000000"}})
+				})
+			})
+			c.NoError(err)
+
+			data, err := repo.GetLoanDataByLoanID(context.Background(), 1)
+			c.NoError(err)
+			c.Len(data, 2)
+		})
+	})
+}
+
+func TestLoanRepository_Search(t *testing.T) {
+	t.Run("Debería buscar por prefijo sin distinguir mayúsculas y paginar por cursor", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			ctx := context.Background()
+			repo := repositories.NewLoanRepository(db)
+
+			items, err := repo.Search(ctx, models.LoanSearchFilter{TenantID: 1, Name: "JUAN", Limit: 10})
+			c.NoError(err)
+			c.Len(items, 2)
+			c.Equal(uint(6), items[0].ID)
+			c.Equal(uint(1), items[1].ID)
+			c.Equal("Préstamo Personal", items[0].LoanTypeName)
+
+			// El nombre se busca por prefijo para usar el índice
+			items, err = repo.Search(ctx, models.LoanSearchFilter{TenantID: 1, Name: "juan p", Limit: 10})
+			c.NoError(err)
+			c.Len(items, 2)
+
+			items, err = repo.Search(ctx, models.LoanSearchFilter{TenantID: 1, Name: "Pérez", Limit: 10})
+			c.NoError(err)
+			c.Empty(items)
+
+			minAmount := decimal.NewFromInt(8000000)
+			items, err = repo.Search(ctx, models.LoanSearchFilter{TenantID: 1, MinAmount: &minAmount, Limit: 2})
+			c.NoError(err)
+			c.Len(items, 2)
+			c.Equal(uint(6), items[0].ID)
+			c.Equal(uint(4), items[1].ID)
+
+			items, err = repo.Search(ctx, models.LoanSearchFilter{TenantID: 1, MinAmount: &minAmount, AfterID: 4, Limit: 2})
+			c.NoError(err)
+			c.Len(items, 2)
+			c.Equal(uint(3), items[0].ID)
+			c.Equal(uint(1), items[1].ID)
+		})
+	})
+}
+
+func TestLoanRepository_SaveLoanData(t *testing.T) {
+	t.Run("Debería actualizar el valor existente en lugar de duplicar la entrada", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			ctx := context.Background()
+			repo := repositories.NewLoanRepository(db)
+
+			c.NoError(repo.SaveLoanData(ctx, []models.LoanData{
+				{LoanID: 1, FormID: 4, Key: "reference_name", Value: "Pedro Gómez", Index: 0},
+				{LoanID: 1, FormID: 4, Key: "reference_name", Value: "Lucía Ruiz", Index: 1},
+			}))
+			c.NoError(repo.SaveLoanData(ctx, []models.LoanData{
+				{LoanID: 1, FormID: 4, Key: "reference_name", Value: "Pedro Gómez Ruiz", Index: 0},
+			}))
+
+			data, err := repo.GetLoanDataByLoanID(ctx, 1)
+			c.NoError(err)
+			c.Len(data, 2)
+
+			values := map[uint]string{}
+			for _, item := range data {
+				values[item.Index] = item.Value
+			}
+			c.Equal("Pedro Gómez Ruiz", values[0])
+			c.Equal("Lucía Ruiz", values[1])
+		})
+	})
+}
+
+func TestLoanRepository_ExpireStale(t *testing.T) {
+	t.Run("Debería expirar solo las solicitudes inactivas", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			ctx := context.Background()
+			repo := repositories.NewLoanRepository(db)
+
+			c.NoError(db.Model(&models.Loan{}).Where("id = ?", 1).
+				UpdateColumn("updated_at", time.Now().Add(-40*24*time.Hour)).Error)
+
+			expired, err := repo.ExpireStale(ctx, 1, time.Now().Add(-30*24*time.Hour), "Expirada por inactividad")
+			c.NoError(err)
+			c.Equal(int64(1), expired)
+
+			loan, err := repo.GetByID(ctx, 1)
+			c.NoError(err)
+			c.Equal(string(models.LoanStatusExpired), loan.Status)
+		})
+	})
+}
+
+func TestLoanRepository_ExpireOffers(t *testing.T) {
+	t.Run("Debería expirar solo los préstamos sin contraofertas vigentes", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			ctx := context.Background()
+			repo := repositories.NewLoanRepository(db)
+			now := time.Now()
+
+			// El préstamo 1 tiene una contraoferta vencida; el 5, una vencida y otra vigente
+			c.NoError(db.Model(&models.Loan{}).Where("id IN ?", []uint{1, 5}).
+				Update("status", models.LoanStatusOfferPending).Error)
+			c.NoError(db.Create(&[]models.LoanOffer{
+				{LoanID: 1, Amount: decimal.NewFromInt(3000000), TermMonths: 24, Status: models.LoanOfferStatusPending, ExpiresAt: now.Add(-time.Hour)},
+				{LoanID: 5, Amount: decimal.NewFromInt(3000000), TermMonths: 24, Status: models.LoanOfferStatusPending, ExpiresAt: now.Add(-time.Hour)},
+				{LoanID: 5, Amount: decimal.NewFromInt(4000000), TermMonths: 36, Status: models.LoanOfferStatusPending, ExpiresAt: now.Add(time.Hour)},
+			}).Error)
+
+			expired, err := repo.ExpireOffers(ctx, now, "Contraofertas vencidas")
+			c.NoError(err)
+			c.Equal(int64(1), expired)
+
+			loan, err := repo.GetByID(ctx, 1)
+			c.NoError(err)
+			c.Equal(string(models.LoanStatusExpired), loan.Status)
+
+			loan, err = repo.GetByID(ctx, 5)
+			c.NoError(err)
+			c.Equal(string(models.LoanStatusOfferPending), loan.Status)
+			var pending int64
+			c.NoError(db.Model(&models.LoanOffer{}).Where("status = ?", models.LoanOfferStatusPending).Count(&pending).Error)
+			c.Equal(int64(1), pending)
+		})
+	})
+}
+
+func TestJobRepository_AcquireLease(t *testing.T) {
+	t.Run("Debería otorgar el bloqueo a una sola réplica a la vez", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			ctx := context.Background()
+			repo := repositories.NewJobRepository(db)
+			now := time.Now()
+
+			acquired, err := repo.AcquireLease(ctx, "repository_test", "replica-a", now, now.Add(time.Minute))
+			c.NoError(err)
+			c.True(acquired)
+
+			acquired, err = repo.AcquireLease(ctx, "repository_test", "replica-b", now, now.Add(time.Minute))
+			c.NoError(err)
+			c.False(acquired)
+
+			c.NoError(repo.ReleaseLease(ctx, "repository_test", "replica-a", now))
+
+			acquired, err = repo.AcquireLease(ctx, "repository_test", "replica-b", now.Add(time.Second), now.Add(time.Minute))
+			c.NoError(err)
+			c.True(acquired)
+
+			// Solo el propietario vigente puede renovar el bloqueo
+			renewed, err := repo.RenewLease(ctx, "repository_test", "replica-a", now.Add(2*time.Minute))
+			c.NoError(err)
+			c.False(renewed)
+			renewed, err = repo.RenewLease(ctx, "repository_test", "replica-b", now.Add(2*time.Minute))
+			c.NoError(err)
+			c.True(renewed)
+
+			acquired, err = repo.AcquireLease(ctx, "repository_test", "replica-a", now.Add(90*time.Second), now.Add(3*time.Minute))
+			c.NoError(err)
+			c.False(acquired)
+		})
+	})
+}
+
+func TestIdempotencyRepository_Acquire(t *testing.T) {
+	t.Run("Debería registrar la clave una sola vez y tomar los bloqueos abandonados", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			ctx := context.Background()
+			repo := repositories.NewIdempotencyRepository(db)
+			now := time.Now()
+
+			newRecord := func(fingerprint string, now time.Time) *models.IdempotencyKey {
+				return &models.IdempotencyKey{
+					TenantID:    1,
+					UserID:      1,
+					Key:         "repository-test",
+					Fingerprint: fingerprint,
+					Status:      models.IdempotencyKeyStatusInProgress,
+					LockedUntil: now.Add(time.Minute),
+					ExpiresAt:   now.Add(time.Hour),
+				}
+			}
+
+			stored, acquired, err := repo.Acquire(ctx, newRecord("a", now), now)
+			c.NoError(err)
+			c.True(acquired)
+
+			// Mientras la original está en curso, la clave no se vuelve a otorgar
+			existing, acquired, err := repo.Acquire(ctx, newRecord("a", now), now)
+			c.NoError(err)
+			c.False(acquired)
+			c.Equal(stored.ID, existing.ID)
+			c.Equal(models.IdempotencyKeyStatusInProgress, existing.Status)
+
+			// Vencido el bloqueo, un reintento con la misma huella lo toma; con otra huella no
+			later := now.Add(2 * time.Minute)
+			_, acquired, err = repo.Acquire(ctx, newRecord("b", later), later)
+			c.NoError(err)
+			c.False(acquired)
+			_, acquired, err = repo.Acquire(ctx, newRecord("a", later), later)
+			c.NoError(err)
+			c.True(acquired)
+
+			c.NoError(repo.Complete(ctx, stored.ID, 201, "application/json", `{"ok":true}`))
+			existing, acquired, err = repo.Acquire(ctx, newRecord("a", later), later)
+			c.NoError(err)
+			c.False(acquired)
+			c.Equal(models.IdempotencyKeyStatusCompleted, existing.Status)
+			c.Equal(201, existing.ResponseStatus)
+			c.Equal(`{"ok":true}`, existing.ResponseBody)
+
+			// Vencida la clave se puede usar de nuevo y la purga la elimina
+			expired := now.Add(2 * time.Hour)
+			_, acquired, err = repo.Acquire(ctx, newRecord("b", expired), expired)
+			c.NoError(err)
+			c.True(acquired)
+			purged, err := repo.DeleteExpired(ctx, expired.Add(2*time.Hour))
+			c.NoError(err)
+			c.Equal(int64(1), purged)
+		})
+	})
+}
+
+func TestAnalyticsRepository_GetFunnelAggregates(t *testing.T) {
+	t.Run("Debería agregar el embudo por tipo de préstamo y día", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			ctx := context.Background()
+			repo := repositories.NewAnalyticsRepository(db)
+
+			now := time.Now()
+			filter := models.AnalyticsFilter{
+				TenantID: 1,
+				From:     now.Add(-24 * time.Hour),
+				To:       now.Add(24 * time.Hour),
+				Period:   models.AnalyticsPeriodDay,
+			}
+			aggregates, err := repo.GetFunnelAggregates(ctx, filter)
+			c.NoError(err)
+			c.NotEmpty(aggregates)
+
+			var started, approved, rejected int64
+			approvedAmount := decimal.Zero
+			for _, aggregate := range aggregates {
+				c.Equal(uint(1), aggregate.LoanTypeID)
+				c.Len(aggregate.Day[:10], 10)
+				started += aggregate.Started
+				approved += aggregate.Approved
+				rejected += aggregate.Rejected
+				approvedAmount = approvedAmount.Add(aggregate.ApprovedAmountSum)
+			}
+			c.Equal(int64(6), started)
+			c.Equal(int64(2), approved)
+			c.Equal(int64(1), rejected)
+			c.True(decimal.NewFromInt(13000000).Equal(approvedAmount))
+		})
+	})
+}
+
+func TestTenantRepository_GetByID(t *testing.T) {
+	t.Run("Debería leer la configuración JSON del tenant", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			ctx := context.Background()
+			repo := repositories.NewTenantRepository(db)
+
+			tenant, err := repo.GetByID(ctx, 1)
+			c.NoError(err)
+
+			cfg, err := tenant.ParseConfig()
+			c.NoError(err)
+			c.Equal(500, cfg.MinCreditScore)
+			c.Equal(72, cfg.OfferExpirationHours)
+		})
+	})
+}
+
+func TestLoanTypeRepository_GetByIDWithForms(t *testing.T) {
+	t.Run("Debería cargar la versión con su configuración y formularios", func(t *testing.T) {
+		forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+			c := require.New(t)
+			ctx := context.Background()
+			repo := repositories.NewLoanTypeRepository(db)
+
+			loanType, err := repo.GetByIDWithForms(ctx, 1)
+			c.NoError(err)
+			c.Equal(float64(100000), loanType.MinAmount)
+			c.NotEmpty(loanType.Versions)
+
+			cfg, err := loanType.Versions[0].ParseConfig()
+			c.NoError(err)
+			c.Equal(0.4, cfg.ApprovalRules.MaxDebtRatio)
+			c.NotEmpty(loanType.Versions[0].Forms)
+		})
+	})
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"loan-api/models"
 
 	"gorm.io/gorm"
@@ -8,9 +9,9 @@ import (
 
 // TenantRepository interface para operaciones de tenant
 type TenantRepository interface {
-	GetAllActive() ([]models.Tenant, error)
-	GetByCode(code string) (*models.Tenant, error)
-	GetByID(id uint) (*models.Tenant, error)
+	GetAllActive(ctx context.Context) ([]models.Tenant, error)
+	GetByCode(ctx context.Context, code string) (*models.Tenant, error)
+	GetByID(ctx context.Context, id uint) (*models.Tenant, error)
 }
 
 // tenantRepository implementación del repository
@@ -24,16 +25,16 @@ func NewTenantRepository(db *gorm.DB) TenantRepository {
 }
 
 // GetAllActive obtiene todos los tenants activos
-func (r *tenantRepository) GetAllActive() ([]models.Tenant, error) {
+func (r *tenantRepository) GetAllActive(ctx context.Context) ([]models.Tenant, error) {
 	var tenants []models.Tenant
-	err := r.db.Where("is_active = ?", true).Find(&tenants).Error
+	err := dbFromContext(ctx, r.db).Where("is_active = ?", true).Find(&tenants).Error
 	return tenants, err
 }
 
 // GetByCode obtiene un tenant por código
-func (r *tenantRepository) GetByCode(code string) (*models.Tenant, error) {
+func (r *tenantRepository) GetByCode(ctx context.Context, code string) (*models.Tenant, error) {
 	var tenant models.Tenant
-	err := r.db.Where("code = ? AND is_active = ?", code, true).First(&tenant).Error
+	err := dbFromContext(ctx, r.db).Where("code = ? AND is_active = ?", code, true).First(&tenant).Error
 	if err != nil {
 		return nil, err
 	}
@@ -41,9 +42,9 @@ func (r *tenantRepository) GetByCode(code string) (*models.Tenant, error) {
 }
 
 // GetByID obtiene un tenant por ID
-func (r *tenantRepository) GetByID(id uint) (*models.Tenant, error) {
+func (r *tenantRepository) GetByID(ctx context.Context, id uint) (*models.Tenant, error) {
 	var tenant models.Tenant
-	err := r.db.Where("id = ? AND is_active = ?", id, true).First(&tenant).Error
+	err := dbFromContext(ctx, r.db).Where("id = ? AND is_active = ?", id, true).First(&tenant).Error
 	if err != nil {
 		return nil, err
 	}
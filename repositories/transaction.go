@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// TransactionManager ejecuta varias operaciones de repositorio como una sola unidad de trabajo
+type TransactionManager interface {
+	// WithinTransaction ejecuta fn dentro de una transacción. Los repositorios que reciben el contexto
+	// entregado a fn usan esa transacción; si fn retorna error o entra en pánico no se confirma nada.
+	// Las llamadas anidadas reutilizan la transacción en curso mediante savepoints
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// transactionKey clave privada de la transacción en curso dentro del contexto
+type transactionKey struct{}
+
+// transactionManager implementación del manejador de transacciones
+type transactionManager struct {
+	db *gorm.DB
+}
+
+// NewTransactionManager crea una nueva instancia del manejador de transacciones
+func NewTransactionManager(db *gorm.DB) TransactionManager {
+	return &transactionManager{db: db}
+}
+
+// WithinTransaction ejecuta fn dentro de una transacción ligada al contexto
+func (m *transactionManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return dbFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, transactionKey{}, tx))
+	})
+}
+
+// dbFromContext retorna la transacción en curso del contexto o, si no hay una, la conexión del repositorio.
+// Todos los repositorios deben obtener la conexión por aquí para participar en la unidad de trabajo
+func dbFromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
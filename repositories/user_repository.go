@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 
 	"loan-api/app_error"
@@ -11,12 +12,12 @@ import (
 
 // UserRepository define la interfaz para operaciones de usuario
 type UserRepository interface {
-	Create(user *models.User) error
-	GetByID(id uint) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	GetByDocument(documentType models.DocumentType, documentNumber string) (*models.User, error)
-	ExistsByEmail(email string, tenantID uint) (bool, error)
-	ExistsByDocument(documentType models.DocumentType, documentNumber string) (bool, error)
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id uint) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByDocument(ctx context.Context, documentType models.DocumentType, documentNumber string) (*models.User, error)
+	ExistsByEmail(ctx context.Context, email string, tenantID uint) (bool, error)
+	ExistsByDocument(ctx context.Context, documentType models.DocumentType, documentNumber string) (bool, error)
 }
 
 // userRepository implementa UserRepository
@@ -32,8 +33,8 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 }
 
 // Create crea un nuevo usuario
-func (r *userRepository) Create(user *models.User) error {
-	if err := r.db.Create(user).Error; err != nil {
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	if err := dbFromContext(ctx, r.db).Create(user).Error; err != nil {
 		// Verificar si es un error de duplicado
 		if errors.Is(err, gorm.ErrDuplicatedKey) {
 			return app_error.ErrEmailExists
@@ -44,9 +45,9 @@ func (r *userRepository) Create(user *models.User) error {
 }
 
 // GetByID obtiene un usuario por su ID
-func (r *userRepository) GetByID(id uint) (*models.User, error) {
+func (r *userRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	if err := r.db.First(&user, id).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).First(&user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, app_error.ErrUserNotFound
 		}
@@ -56,9 +57,9 @@ func (r *userRepository) GetByID(id uint) (*models.User, error) {
 }
 
 // GetByEmail obtiene un usuario por su email
-func (r *userRepository) GetByEmail(email string) (*models.User, error) {
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, app_error.ErrUserNotFound
 		}
@@ -68,9 +69,9 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 }
 
 // GetByDocument obtiene un usuario por su tipo y número de documento
-func (r *userRepository) GetByDocument(documentType models.DocumentType, documentNumber string) (*models.User, error) {
+func (r *userRepository) GetByDocument(ctx context.Context, documentType models.DocumentType, documentNumber string) (*models.User, error) {
 	var user models.User
-	if err := r.db.Where("document_type = ? AND document_number = ?", documentType, documentNumber).First(&user).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Where("document_type = ? AND document_number = ?", documentType, documentNumber).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, app_error.ErrUserNotFound
 		}
@@ -80,18 +81,18 @@ func (r *userRepository) GetByDocument(documentType models.DocumentType, documen
 }
 
 // ExistsByEmail verifica si existe un usuario con el email dado
-func (r *userRepository) ExistsByEmail(email string, tenantID uint) (bool, error) {
+func (r *userRepository) ExistsByEmail(ctx context.Context, email string, tenantID uint) (bool, error) {
 	var count int64
-	if err := r.db.Model(&models.User{}).Where("email = ? AND tenant_id = ?", email, tenantID).Count(&count).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Model(&models.User{}).Where("email = ? AND tenant_id = ?", email, tenantID).Count(&count).Error; err != nil {
 		return false, app_error.NewDatabaseError("verificar email", err.Error())
 	}
 	return count > 0, nil
 }
 
 // ExistsByDocument verifica si existe un usuario con el documento dado
-func (r *userRepository) ExistsByDocument(documentType models.DocumentType, documentNumber string) (bool, error) {
+func (r *userRepository) ExistsByDocument(ctx context.Context, documentType models.DocumentType, documentNumber string) (bool, error) {
 	var count int64
-	if err := r.db.Model(&models.User{}).Where("document_type = ? AND document_number = ?", documentType, documentNumber).Count(&count).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Model(&models.User{}).Where("document_type = ? AND document_number = ?", documentType, documentNumber).Count(&count).Error; err != nil {
 		return false, app_error.NewDatabaseError("verificar documento", err.Error())
 	}
 	return count > 0, nil
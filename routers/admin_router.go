@@ -0,0 +1,51 @@
+package routers
+
+import (
+	"loan-api/controllers"
+	"loan-api/middlewares"
+	"loan-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRouter configura las rutas administrativas
+type AdminRouter struct {
+	adminController *controllers.AdminController
+	auth            gin.HandlerFunc
+}
+
+// NewAdminRouter crea una nueva instancia del router administrativo
+func NewAdminRouter(adminController *controllers.AdminController, auth gin.HandlerFunc) *AdminRouter {
+	return &AdminRouter{
+		adminController: adminController,
+		auth:            auth,
+	}
+}
+
+// Setup configura todas las rutas administrativas
+func (r *AdminRouter) Setup(router *gin.RouterGroup) {
+	// Grupo de rutas para el back-office; todas requieren autenticación
+	admin := router.Group("/admin")
+	admin.Use(r.auth)
+
+	// La búsqueda y exportación de préstamos están disponibles para analistas y administradores
+	reports := admin.Group("")
+	{
+		reports.Use(middlewares.RequireRole(models.UserRoleAnalyst, models.UserRoleAdmin))
+
+		reports.GET("/loans", r.adminController.SearchLoans)        // GET /api/v1/admin/loans - Buscar préstamos
+		reports.GET("/loans/export", r.adminController.ExportLoans) // GET /api/v1/admin/loans/export - Exportar préstamos
+	}
+
+	// Las demás rutas administrativas requieren rol de administrador
+	staff := admin.Group("")
+	{
+		staff.Use(middlewares.RequireRole(models.UserRoleAdmin))
+
+		staff.POST("/loans/:id/cancel", r.adminController.CancelLoan) // POST /api/v1/admin/loans/{id}/cancel - Cancelar préstamo
+
+		staff.GET("/jobs", r.adminController.GetJobs)           // GET /api/v1/admin/jobs - Tareas programadas
+		staff.GET("/jobs/runs", r.adminController.GetJobRuns)   // GET /api/v1/admin/jobs/runs - Historial de ejecuciones
+		staff.POST("/jobs/:name/run", r.adminController.RunJob) // POST /api/v1/admin/jobs/{name}/run - Ejecutar tarea
+	}
+}
@@ -0,0 +1,36 @@
+package routers
+
+import (
+	"loan-api/controllers"
+	"loan-api/middlewares"
+	"loan-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnalyticsRouter configura las rutas de analítica del portafolio
+type AnalyticsRouter struct {
+	analyticsController *controllers.AnalyticsController
+	auth                gin.HandlerFunc
+}
+
+// NewAnalyticsRouter crea una nueva instancia del router de analítica
+func NewAnalyticsRouter(analyticsController *controllers.AnalyticsController, auth gin.HandlerFunc) *AnalyticsRouter {
+	return &AnalyticsRouter{
+		analyticsController: analyticsController,
+		auth:                auth,
+	}
+}
+
+// Setup configura todas las rutas de analítica
+func (r *AnalyticsRouter) Setup(router *gin.RouterGroup) {
+	// Grupo de rutas para tableros del tenant
+	analytics := router.Group("/admin/analytics")
+	{
+		// La analítica requiere autenticación y rol de analista o administrador
+		analytics.Use(r.auth)
+		analytics.Use(middlewares.RequireRole(models.UserRoleAnalyst, models.UserRoleAdmin))
+
+		analytics.GET("/funnel", r.analyticsController.GetFunnel) // GET /api/v1/admin/analytics/funnel - Embudo de solicitudes
+	}
+}
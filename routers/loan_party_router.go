@@ -0,0 +1,39 @@
+package routers
+
+import (
+	"loan-api/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoanPartyRouter configura las rutas de codeudores y garantes
+type LoanPartyRouter struct {
+	loanPartyController *controllers.LoanPartyController
+	auth                gin.HandlerFunc
+	rateLimit           gin.HandlerFunc
+}
+
+// NewLoanPartyRouter crea una nueva instancia del router de codeudores y garantes. rateLimit
+// comparte el límite de las rutas de préstamos
+func NewLoanPartyRouter(loanPartyController *controllers.LoanPartyController, auth, rateLimit gin.HandlerFunc) *LoanPartyRouter {
+	return &LoanPartyRouter{
+		loanPartyController: loanPartyController,
+		auth:                auth,
+		rateLimit:           rateLimit,
+	}
+}
+
+// Setup configura todas las rutas de codeudores y garantes
+func (r *LoanPartyRouter) Setup(router *gin.RouterGroup) {
+	// Grupo de rutas para participantes de un préstamo
+	parties := router.Group("/loans/:id/parties")
+	{
+		// Todas las rutas de participantes requieren autenticación
+		parties.Use(r.auth, r.rateLimit)
+
+		parties.GET("", r.loanPartyController.GetParties)                 // GET /api/v1/loans/{id}/parties - Participantes del préstamo
+		parties.POST("", r.loanPartyController.InviteParty)               // POST /api/v1/loans/{id}/parties - Invitar codeudor o garante
+		parties.POST("/accept", r.loanPartyController.AcceptInvitation)   // POST /api/v1/loans/{id}/parties/accept - Aceptar invitación
+		parties.POST("/decline", r.loanPartyController.DeclineInvitation) // POST /api/v1/loans/{id}/parties/decline - Rechazar invitación
+	}
+}
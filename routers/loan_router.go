@@ -2,7 +2,6 @@ package routers
 
 import (
 	"loan-api/controllers"
-	"loan-api/middlewares"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,12 +9,20 @@ import (
 // LoanRouter configura las rutas relacionadas con préstamos
 type LoanRouter struct {
 	loanController *controllers.LoanController
+	auth           gin.HandlerFunc
+	rateLimit      gin.HandlerFunc
+	idempotency    gin.HandlerFunc
 }
 
-// NewLoanRouter crea una nueva instancia del router de préstamos
-func NewLoanRouter(loanController *controllers.LoanController) *LoanRouter {
+// NewLoanRouter crea una nueva instancia del router de préstamos. auth valida el token de acceso,
+// rateLimit limita las solicitudes de cada usuario e idempotency se aplica a los POST que los
+// clientes reintentan y que no deben repetir sus efectos
+func NewLoanRouter(loanController *controllers.LoanController, auth, rateLimit, idempotency gin.HandlerFunc) *LoanRouter {
 	return &LoanRouter{
 		loanController: loanController,
+		auth:           auth,
+		rateLimit:      rateLimit,
+		idempotency:    idempotency,
 	}
 }
 
@@ -25,12 +32,21 @@ func (r *LoanRouter) Setup(router *gin.RouterGroup) {
 	loans := router.Group("/loans")
 	{
 		// Todas las rutas de préstamos requieren autenticación
-		loans.Use(middlewares.AuthMiddleware())
+		loans.Use(r.auth, r.rateLimit)
 
-		loans.POST("", r.loanController.CreateLoan)                       // POST /api/v1/loans - Crear préstamo
-		loans.POST("/data", r.loanController.SaveLoanData)                // POST /api/v1/loans/data - Guardar datos del préstamo
-		loans.POST("/:id/decision", r.loanController.ProcessLoanDecision) // POST /api/v1/loans/{id}/decision - Procesar decisión final
-		loans.GET("/:id", r.loanController.GetLoan)                       // GET /api/v1/loans/{id} - Obtener préstamo por ID
-		loans.GET("/user", r.loanController.GetUserLoans)                 // GET /api/v1/loans/user - Obtener préstamos del usuario
+		loans.POST("", r.idempotency, r.loanController.CreateLoan)                       // POST /api/v1/loans - Crear préstamo
+		loans.POST("/data", r.loanController.SaveLoanData)                               // POST /api/v1/loans/data - Guardar datos del préstamo
+		loans.POST("/:id/decision", r.idempotency, r.loanController.ProcessLoanDecision) // POST /api/v1/loans/{id}/decision - Procesar decisión final
+		loans.GET("/:id", r.loanController.GetLoan)                                      // GET /api/v1/loans/{id} - Obtener préstamo por ID
+		loans.GET("/user", r.loanController.GetUserLoans)                                // GET /api/v1/loans/user - Obtener préstamos del usuario
+
+		loans.POST("/:id/cancel", r.loanController.CancelLoan) // POST /api/v1/loans/{id}/cancel - Cancelar préstamo
+
+		loans.GET("/:id/forms", r.loanController.GetLoanForms)              // GET /api/v1/loans/{id}/forms - Formularios con condiciones evaluadas
+		loans.PUT("/:id/forms/:formCode", r.loanController.ReplaceFormData) // PUT /api/v1/loans/{id}/forms/{formCode} - Reemplazar datos de un formulario
+		loans.PATCH("/:id/forms/:formCode", r.loanController.PatchFormData) // PATCH /api/v1/loans/{id}/forms/{formCode} - Modificar valores de un formulario
+
+		loans.POST("/:id/offers/:offerId/accept", r.loanController.AcceptOffer)   // POST /api/v1/loans/{id}/offers/{offerId}/accept - Aceptar contraoferta
+		loans.POST("/:id/offers/:offerId/decline", r.loanController.DeclineOffer) // POST /api/v1/loans/{id}/offers/{offerId}/decline - Rechazar contraoferta
 	}
 }
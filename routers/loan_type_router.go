@@ -2,7 +2,6 @@ package routers
 
 import (
 	"loan-api/controllers"
-	"loan-api/middlewares"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,12 +9,14 @@ import (
 // LoanTypeRouter configura las rutas relacionadas con tipos de préstamo
 type LoanTypeRouter struct {
 	loanTypeController *controllers.LoanTypeController
+	auth               gin.HandlerFunc
 }
 
 // NewLoanTypeRouter crea una nueva instancia del router de tipos de préstamo
-func NewLoanTypeRouter(loanTypeController *controllers.LoanTypeController) *LoanTypeRouter {
+func NewLoanTypeRouter(loanTypeController *controllers.LoanTypeController, auth gin.HandlerFunc) *LoanTypeRouter {
 	return &LoanTypeRouter{
 		loanTypeController: loanTypeController,
+		auth:               auth,
 	}
 }
 
@@ -25,7 +26,7 @@ func (r *LoanTypeRouter) Setup(router *gin.RouterGroup) {
 	loanTypes := router.Group("/loan-types")
 	{
 		// Todas las rutas de tipos de préstamo requieren autenticación
-		loanTypes.Use(middlewares.AuthMiddleware())
+		loanTypes.Use(r.auth)
 
 		loanTypes.GET("", r.loanTypeController.GetLoanTypesWithForms)   // GET /api/v1/loan-types - Obtener tipos con formularios
 		loanTypes.GET("/:code", r.loanTypeController.GetLoanTypeByCode) // GET /api/v1/loan-types/{code} - Obtener tipo por código
@@ -0,0 +1,42 @@
+package routers
+
+import (
+	"loan-api/controllers"
+	"loan-api/middlewares"
+	"loan-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewRouter configura las rutas de la cola de revisión manual
+type ReviewRouter struct {
+	reviewController *controllers.ReviewController
+	auth             gin.HandlerFunc
+}
+
+// NewReviewRouter crea una nueva instancia del router de revisión manual
+func NewReviewRouter(reviewController *controllers.ReviewController, auth gin.HandlerFunc) *ReviewRouter {
+	return &ReviewRouter{
+		reviewController: reviewController,
+		auth:             auth,
+	}
+}
+
+// Setup configura todas las rutas de revisión manual
+func (r *ReviewRouter) Setup(router *gin.RouterGroup) {
+	// Grupo de rutas para analistas
+	review := router.Group("/review")
+	{
+		// Todas las rutas de revisión requieren autenticación y rol de analista o administrador
+		review.Use(r.auth)
+		review.Use(middlewares.RequireRole(models.UserRoleAnalyst, models.UserRoleAdmin))
+
+		review.GET("/loans", r.reviewController.GetQueue)                          // GET /api/v1/review/loans - Cola de revisión
+		review.GET("/loans/:id/actions", r.reviewController.GetLoanActions)        // GET /api/v1/review/loans/{id}/actions - Historial de revisión
+		review.POST("/loans/:id/claim", r.reviewController.ClaimLoan)              // POST /api/v1/review/loans/{id}/claim - Tomar préstamo
+		review.POST("/loans/:id/release", r.reviewController.ReleaseLoan)          // POST /api/v1/review/loans/{id}/release - Liberar préstamo
+		review.POST("/loans/:id/approve", r.reviewController.ApproveLoan)          // POST /api/v1/review/loans/{id}/approve - Aprobar
+		review.POST("/loans/:id/reject", r.reviewController.RejectLoan)            // POST /api/v1/review/loans/{id}/reject - Rechazar
+		review.POST("/loans/:id/request-info", r.reviewController.RequestMoreInfo) // POST /api/v1/review/loans/{id}/request-info - Solicitar información
+	}
+}
@@ -9,12 +9,15 @@ import (
 // UserRouter configura las rutas relacionadas con usuarios
 type UserRouter struct {
 	userController *controllers.UserController
+	rateLimit      gin.HandlerFunc
 }
 
-// NewUserRouter crea una nueva instancia del router de usuarios
-func NewUserRouter(userController *controllers.UserController) *UserRouter {
+// NewUserRouter crea una nueva instancia del router de usuarios. rateLimit limita por IP las
+// solicitudes de autenticación
+func NewUserRouter(userController *controllers.UserController, rateLimit gin.HandlerFunc) *UserRouter {
 	return &UserRouter{
 		userController: userController,
+		rateLimit:      rateLimit,
 	}
 }
 
@@ -23,6 +26,8 @@ func (r *UserRouter) Setup(router *gin.RouterGroup) {
 	// Rutas de autenticación
 	auth := router.Group("/auth")
 	{
+		auth.Use(r.rateLimit)
+
 		auth.POST("/register", r.userController.RegisterUser) // Registro de usuario
 		auth.POST("/login", r.userController.Login)           // Login de usuario
 	}
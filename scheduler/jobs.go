@@ -0,0 +1,39 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"loan-api/config"
+	"loan-api/repositories"
+	"loan-api/services"
+)
+
+// Nombres de las tareas programadas
+const (
+	JobExpireStaleLoans     = "expire_stale_loans"
+	JobPurgeIdempotencyKeys = "purge_idempotency_keys"
+)
+
+// RegisterJobs registra las tareas periódicas de la aplicación
+func RegisterJobs(s *Scheduler, cfg config.Config, expirationService services.ExpirationService, idempotencyRepository repositories.IdempotencyRepository) error {
+	err := s.Register(JobExpireStaleLoans, cfg.ExpireStaleLoansSchedule, func(ctx context.Context) (string, error) {
+		expired, err := expirationService.ExpireStaleLoans(ctx, time.Now())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d solicitudes expiradas", expired), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.Register(JobPurgeIdempotencyKeys, cfg.PurgeIdempotencySchedule, func(ctx context.Context) (string, error) {
+		purged, err := idempotencyRepository.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d claves de idempotencia vencidas eliminadas", purged), nil
+	})
+}
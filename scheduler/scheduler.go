@@ -0,0 +1,272 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"loan-api/app_error"
+	"loan-api/models"
+	"loan-api/repositories"
+
+	"github.com/robfig/cron/v3"
+)
+
+// JobFunc ejecuta una tarea programada y retorna un resumen del resultado. Debe respetar la cancelación de ctx
+type JobFunc func(ctx context.Context) (string, error)
+
+// job representa una tarea registrada en el planificador
+type job struct {
+	name     string
+	spec     string
+	schedule cron.Schedule
+	run      JobFunc
+	// running evita ejecuciones simultáneas en la réplica, que el bloqueo no impide porque su dueño lo retoma
+	running atomic.Bool
+}
+
+// JobInfo representa una tarea registrada y su próxima ejecución
+type JobInfo struct {
+	Name    string     `json:"name"`
+	Spec    string     `json:"spec"`
+	NextRun *time.Time `json:"next_run,omitempty"`
+}
+
+// Scheduler ejecuta tareas periódicas en el proceso. Cada ejecución toma un bloqueo en base de datos
+// que se mantiene hasta la siguiente ejecución programada, para que con varias réplicas solo una
+// ejecute la tarea en cada periodo aunque sus relojes o arranques no coincidan
+type Scheduler struct {
+	cron     *cron.Cron
+	jobRepo  repositories.JobRepository
+	owner    string
+	leaseTTL time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	entries map[string]cron.EntryID
+}
+
+// NewScheduler crea un nuevo planificador de tareas. owner identifica la réplica que toma los bloqueos
+// (ver InstanceOwner) y now permite reemplazar el reloj en las pruebas
+func NewScheduler(jobRepo repositories.JobRepository, owner string, leaseTTL time.Duration, now func() time.Time) *Scheduler {
+	return &Scheduler{
+		cron:     cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger))),
+		jobRepo:  jobRepo,
+		owner:    owner,
+		leaseTTL: leaseTTL,
+		now:      now,
+		jobs:     make(map[string]*job),
+		entries:  make(map[string]cron.EntryID),
+	}
+}
+
+// Register registra una tarea con una expresión cron estándar (por ejemplo "0 * * * *" o "@every 1h")
+func (s *Scheduler) Register(name, spec string, run JobFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[name]; exists {
+		return fmt.Errorf("la tarea %s ya está registrada", name)
+	}
+
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("expresión cron inválida para la tarea %s: %w", name, err)
+	}
+
+	j := &job{name: name, spec: spec, schedule: schedule, run: run}
+	entryID := s.cron.Schedule(schedule, cron.FuncJob(func() {
+		_, err := s.execute(context.Background(), j)
+		if err != nil && !errors.Is(err, app_error.ErrJobLeaseHeld) && !errors.Is(err, app_error.ErrJobRunning) {
+			slog.Error("Scheduler: error al ejecutar la tarea", "job", name, "error", err)
+		}
+	}))
+
+	s.jobs[name] = j
+	s.entries[name] = entryID
+	return nil
+}
+
+// Start inicia la ejecución periódica de las tareas registradas
+func (s *Scheduler) Start() {
+	slog.Info("Scheduler: iniciando tareas", "jobs", len(s.jobs), "owner", s.owner)
+	s.cron.Start()
+}
+
+// Stop detiene el planificador y espera a que terminen las tareas en ejecución
+func (s *Scheduler) Stop() {
+	<-s.cron.Stop().Done()
+}
+
+// RunNow ejecuta una tarea registrada de inmediato, respetando el bloqueo entre réplicas
+func (s *Scheduler) RunNow(ctx context.Context, name string) (*models.JobRun, error) {
+	s.mu.Lock()
+	j, exists := s.jobs[name]
+	s.mu.Unlock()
+
+	if !exists {
+		return nil, app_error.ErrJobNotFound
+	}
+	return s.execute(ctx, j)
+}
+
+// Jobs obtiene las tareas registradas con su próxima ejecución
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]JobInfo, 0, len(s.jobs))
+	for name, j := range s.jobs {
+		info := JobInfo{Name: name, Spec: j.spec}
+		if next := s.cron.Entry(s.entries[name]).Next; !next.IsZero() {
+			info.NextRun = &next
+		}
+		jobs = append(jobs, info)
+	}
+	return jobs
+}
+
+// GetRuns obtiene el historial de ejecuciones, opcionalmente filtrado por tarea
+func (s *Scheduler) GetRuns(ctx context.Context, jobName string, limit int) ([]models.JobRun, error) {
+	runs, err := s.jobRepo.GetRuns(ctx, jobName, limit)
+	if err != nil {
+		return nil, app_error.NewDatabaseError("obtener historial de tareas", err.Error())
+	}
+	return runs, nil
+}
+
+// execute toma el bloqueo de la tarea, la ejecuta y registra el resultado en el historial. El bloqueo
+// se renueva mientras la tarea corre y al terminar se conserva hasta la siguiente ejecución programada
+func (s *Scheduler) execute(ctx context.Context, j *job) (*models.JobRun, error) {
+	// Una ejecución manual no puede correr junto a la programada (ni a otra manual) en la misma réplica
+	if !j.running.CompareAndSwap(false, true) {
+		return nil, app_error.ErrJobRunning
+	}
+	defer j.running.Store(false)
+
+	startedAt := s.now()
+	nextRun := j.schedule.Next(startedAt)
+
+	// El registro del resultado y la liberación del bloqueo deben completarse aunque ctx se cancele
+	bookkeeping := context.WithoutCancel(ctx)
+
+	acquired, err := s.jobRepo.AcquireLease(ctx, j.name, s.owner, startedAt, startedAt.Add(s.leaseTTL))
+	if err != nil {
+		return nil, app_error.NewDatabaseError("tomar bloqueo de tarea", err.Error())
+	}
+	if !acquired {
+		// Otra réplica está ejecutando la tarea
+		return nil, app_error.ErrJobLeaseHeld
+	}
+	defer func() {
+		// Liberar al terminar permitiría que otra réplica repita la tarea en el mismo periodo
+		lockedUntil := nextRun
+		if now := s.now(); now.After(lockedUntil) {
+			lockedUntil = now
+		}
+		if err := s.jobRepo.ReleaseLease(bookkeeping, j.name, s.owner, lockedUntil); err != nil {
+			slog.ErrorContext(bookkeeping, "Scheduler: error al liberar el bloqueo", "job", j.name, "error", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopRenewal := s.renewLease(ctx, bookkeeping, j.name, cancel)
+	defer stopRenewal()
+
+	run := &models.JobRun{
+		JobName:   j.name,
+		Owner:     s.owner,
+		Status:    models.JobRunStatusRunning,
+		StartedAt: startedAt,
+	}
+	if err := s.jobRepo.CreateRun(ctx, run); err != nil {
+		return nil, app_error.NewDatabaseError("registrar ejecución de tarea", err.Error())
+	}
+
+	result, runErr := safeRun(ctx, j.run)
+
+	finishedAt := s.now()
+	run.FinishedAt = &finishedAt
+	run.Result = result
+	run.Status = models.JobRunStatusSuccess
+	if runErr != nil {
+		run.Status = models.JobRunStatusFailed
+		run.Error = runErr.Error()
+	}
+
+	if err := s.jobRepo.UpdateRun(bookkeeping, run); err != nil {
+		return nil, app_error.NewDatabaseError("registrar ejecución de tarea", err.Error())
+	}
+
+	slog.InfoContext(ctx, "Scheduler: tarea finalizada", "job", j.name, "status", run.Status, "duration", finishedAt.Sub(startedAt))
+	return run, nil
+}
+
+// renewLease extiende el bloqueo de la tarea cada mitad de leaseTTL mientras corre, para que una ejecución
+// más larga que leaseTTL no lo pierda. Si otra réplica lo tomó, cancela la ejecución. Retorna la función
+// que detiene la renovación
+func (s *Scheduler) renewLease(ctx, bookkeeping context.Context, jobName string, cancel context.CancelFunc) func() {
+	if s.leaseTTL <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.leaseTTL / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				renewed, err := s.jobRepo.RenewLease(bookkeeping, jobName, s.owner, s.now().Add(s.leaseTTL))
+				if err != nil {
+					slog.ErrorContext(bookkeeping, "Scheduler: error al renovar el bloqueo", "job", jobName, "error", err)
+					continue
+				}
+				if !renewed {
+					slog.ErrorContext(bookkeeping, "Scheduler: otra réplica tomó el bloqueo; se cancela la tarea", "job", jobName)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// safeRun ejecuta la tarea convirtiendo un panic en error para no detener el planificador
+func safeRun(ctx context.Context, run JobFunc) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx)
+}
+
+// InstanceOwner identifica la réplica que toma los bloqueos por su host y proceso
+func InstanceOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
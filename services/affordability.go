@@ -0,0 +1,99 @@
+package services
+
+import (
+	"loan-api/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Valores por defecto cuando la versión del tipo de préstamo no configura la capacidad de pago
+const (
+	defaultMaxDebtRatio = 0.4
+	defaultTermMonths   = 12
+	defaultAnnualRate   = 0.24
+	minLoanAmount       = 100000
+
+	defaultOfferExpirationHours = 48
+)
+
+// calculateAffordability calcula la capacidad de pago del solicitante a partir de sus ingresos y gastos
+func calculateAffordability(monthlyIncome, monthlyExpenses, requestedAmount decimal.Decimal, cfg models.LoanTypeVersionConfig) models.AffordabilityBreakdown {
+	maxDebtRatio := decimal.NewFromFloat(cfg.ApprovalRules.MaxDebtRatio)
+	if maxDebtRatio.LessThanOrEqual(decimal.Zero) {
+		maxDebtRatio = decimal.NewFromFloat(defaultMaxDebtRatio)
+	}
+
+	termMonths := cfg.Affordability.TermMonths
+	if termMonths <= 0 {
+		termMonths = defaultTermMonths
+	}
+
+	annualRate := decimal.NewFromFloat(cfg.Affordability.AnnualRate)
+	if annualRate.LessThanOrEqual(decimal.Zero) {
+		annualRate = decimal.NewFromFloat(defaultAnnualRate)
+	}
+
+	// 1. Ingreso disponible después de gastos
+	disposableIncome := monthlyIncome.Sub(monthlyExpenses)
+
+	// 2. Relación deuda/ingreso actual
+	debtToIncomeRatio := decimal.Zero
+	if monthlyIncome.GreaterThan(decimal.Zero) {
+		debtToIncomeRatio = monthlyExpenses.Div(monthlyIncome).Round(4)
+	}
+
+	// 3. Cuota máxima: limitada por el porcentaje máximo del ingreso y por el ingreso disponible
+	maxMonthlyPayment := decimal.Min(monthlyIncome.Mul(maxDebtRatio), disposableIncome)
+	if maxMonthlyPayment.LessThan(decimal.Zero) {
+		maxMonthlyPayment = decimal.Zero
+	}
+	maxMonthlyPayment = maxMonthlyPayment.Round(2)
+
+	// 4. Capital máximo financiable con la cuota máxima, el plazo y la tasa configurados
+	maxPrincipal := presentValue(maxMonthlyPayment, annualRate.Div(decimal.NewFromInt(12)), termMonths)
+
+	return models.AffordabilityBreakdown{
+		MonthlyIncome:     monthlyIncome,
+		MonthlyExpenses:   monthlyExpenses,
+		DisposableIncome:  disposableIncome,
+		DebtToIncomeRatio: debtToIncomeRatio,
+		MaxDebtRatio:      maxDebtRatio,
+		MaxMonthlyPayment: maxMonthlyPayment,
+		TermMonths:        termMonths,
+		AnnualRate:        annualRate,
+		MaxPrincipal:      maxPrincipal,
+		RequestedAmount:   requestedAmount,
+	}
+}
+
+// presentValue calcula el valor presente de una serie de cuotas fijas (sistema francés)
+func presentValue(payment, monthlyRate decimal.Decimal, termMonths int) decimal.Decimal {
+	if payment.LessThanOrEqual(decimal.Zero) || termMonths <= 0 {
+		return decimal.Zero
+	}
+
+	if monthlyRate.IsZero() {
+		return payment.Mul(decimal.NewFromInt(int64(termMonths))).Round(2)
+	}
+
+	// PV = cuota * (1 - (1 + i)^-n) / i
+	factor := decimal.NewFromInt(1).Add(monthlyRate).Pow(decimal.NewFromInt(int64(termMonths)))
+	discount := decimal.NewFromInt(1).Sub(decimal.NewFromInt(1).Div(factor))
+	return payment.Mul(discount).Div(monthlyRate).Round(2)
+}
+
+// monthlyPayment calcula la cuota fija mensual de un capital (sistema francés)
+func monthlyPayment(principal, monthlyRate decimal.Decimal, termMonths int) decimal.Decimal {
+	if principal.LessThanOrEqual(decimal.Zero) || termMonths <= 0 {
+		return decimal.Zero
+	}
+
+	if monthlyRate.IsZero() {
+		return principal.Div(decimal.NewFromInt(int64(termMonths))).Round(2)
+	}
+
+	// cuota = capital * i / (1 - (1 + i)^-n)
+	factor := decimal.NewFromInt(1).Add(monthlyRate).Pow(decimal.NewFromInt(int64(termMonths)))
+	discount := decimal.NewFromInt(1).Sub(decimal.NewFromInt(1).Div(factor))
+	return principal.Mul(monthlyRate).Div(discount).Round(2)
+}
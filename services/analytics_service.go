@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"loan-api/app_error"
+	"loan-api/models"
+	"loan-api/repositories"
+
+	"github.com/shopspring/decimal"
+)
+
+// AnalyticsService interface para el servicio de analítica del portafolio
+type AnalyticsService interface {
+	GetFunnel(ctx context.Context, filter models.AnalyticsFilter) (*models.AnalyticsResponse, error)
+}
+
+// analyticsService implementación del servicio
+type analyticsService struct {
+	analyticsRepo repositories.AnalyticsRepository
+	loanTypeRepo  repositories.LoanTypeRepository
+	cacheTTL      time.Duration
+	now           func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]analyticsCacheEntry
+}
+
+// analyticsCacheEntry representa un resultado calculado y su vencimiento
+type analyticsCacheEntry struct {
+	response  *models.AnalyticsResponse
+	expiresAt time.Time
+}
+
+// NewAnalyticsService crea una nueva instancia del servicio. Los resultados se guardan en memoria durante cacheTTL;
+// un TTL de cero desactiva la caché
+func NewAnalyticsService(analyticsRepo repositories.AnalyticsRepository, loanTypeRepo repositories.LoanTypeRepository, cacheTTL time.Duration) AnalyticsService {
+	return &analyticsService{
+		analyticsRepo: analyticsRepo,
+		loanTypeRepo:  loanTypeRepo,
+		cacheTTL:      cacheTTL,
+		now:           time.Now,
+		cache:         make(map[string]analyticsCacheEntry),
+	}
+}
+
+// GetFunnel obtiene el embudo de solicitudes del tenant con totales, series de tiempo y motivos de rechazo,
+// en total y por tipo de préstamo
+func (s *analyticsService) GetFunnel(ctx context.Context, filter models.AnalyticsFilter) (*models.AnalyticsResponse, error) {
+	key := fmt.Sprintf("%d:%d:%s:%s:%s", filter.TenantID, filter.LoanTypeID, filter.From.Format("2006-01-02"), filter.To.Format("2006-01-02"), filter.Period)
+	if response := s.getCached(key); response != nil {
+		return response, nil
+	}
+
+	loanTypes, err := s.loanTypeRepo.GetByTenantID(ctx, filter.TenantID)
+	if err != nil {
+		return nil, app_error.NewDatabaseError("obtener tipos de préstamo", err.Error())
+	}
+	if filter.LoanTypeID != 0 {
+		loanTypes = filterLoanTypes(loanTypes, filter.LoanTypeID)
+		if len(loanTypes) == 0 {
+			return nil, app_error.ErrLoanTypeNotFound
+		}
+	}
+
+	aggregates, err := s.analyticsRepo.GetFunnelAggregates(ctx, filter)
+	if err != nil {
+		return nil, app_error.NewDatabaseError("calcular embudo", err.Error())
+	}
+
+	reasons, err := s.analyticsRepo.GetRejectionReasons(ctx, filter)
+	if err != nil {
+		return nil, app_error.NewDatabaseError("calcular motivos de rechazo", err.Error())
+	}
+
+	periods := analyticsPeriods(filter)
+	total := newFunnelAccumulator(periods)
+	byLoanType := make(map[uint]*funnelAccumulator, len(loanTypes))
+	for _, loanType := range loanTypes {
+		byLoanType[loanType.ID] = newFunnelAccumulator(periods)
+	}
+
+	for _, aggregate := range aggregates {
+		day, err := time.Parse("2006-01-02", aggregate.Day[:min(len(aggregate.Day), 10)])
+		if err != nil {
+			return nil, app_error.NewBusinessError("Error al calcular la analítica", err.Error())
+		}
+		periodStart := analyticsPeriodStart(day, filter.Period).Format("2006-01-02")
+
+		total.add(periodStart, aggregate)
+		if accumulator, found := byLoanType[aggregate.LoanTypeID]; found {
+			accumulator.add(periodStart, aggregate)
+		}
+	}
+
+	for _, reason := range reasons {
+		total.addReason(reason)
+		if accumulator, found := byLoanType[reason.LoanTypeID]; found {
+			accumulator.addReason(reason)
+		}
+	}
+
+	response := &models.AnalyticsResponse{
+		From:             filter.From.Format("2006-01-02"),
+		To:               filter.To.AddDate(0, 0, -1).Format("2006-01-02"),
+		Period:           filter.Period,
+		Totals:           total.totals.metrics(),
+		Series:           total.series(periods),
+		RejectionReasons: total.rejectionReasons(),
+		LoanTypes:        make([]models.LoanTypeAnalytics, 0, len(loanTypes)),
+		GeneratedAt:      s.now(),
+	}
+	for _, loanType := range loanTypes {
+		accumulator := byLoanType[loanType.ID]
+		response.LoanTypes = append(response.LoanTypes, models.LoanTypeAnalytics{
+			LoanTypeID:       loanType.ID,
+			LoanTypeName:     loanType.Name,
+			Totals:           accumulator.totals.metrics(),
+			Series:           accumulator.series(periods),
+			RejectionReasons: accumulator.rejectionReasons(),
+		})
+	}
+
+	s.setCached(key, response)
+	return response, nil
+}
+
+// getCached obtiene un resultado vigente de la caché
+func (s *analyticsService) getCached(key string) *models.AnalyticsResponse {
+	if s.cacheTTL <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, found := s.cache[key]
+	if !found || !s.now().Before(entry.expiresAt) {
+		return nil
+	}
+	return entry.response
+}
+
+// setCached guarda un resultado en la caché y descarta los vencidos
+func (s *analyticsService) setCached(key string, response *models.AnalyticsResponse) {
+	if s.cacheTTL <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for cachedKey, entry := range s.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(s.cache, cachedKey)
+		}
+	}
+	s.cache[key] = analyticsCacheEntry{response: response, expiresAt: now.Add(s.cacheTTL)}
+}
+
+// filterLoanTypes obtiene el tipo de préstamo indicado de la lista
+func filterLoanTypes(loanTypes []models.LoanType, loanTypeID uint) []models.LoanType {
+	for _, loanType := range loanTypes {
+		if loanType.ID == loanTypeID {
+			return []models.LoanType{loanType}
+		}
+	}
+	return nil
+}
+
+// analyticsPeriodStart obtiene el inicio del período al que pertenece un día
+func analyticsPeriodStart(day time.Time, period models.AnalyticsPeriod) time.Time {
+	switch period {
+	case models.AnalyticsPeriodWeek:
+		// Las semanas inician el lunes
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case models.AnalyticsPeriodMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	}
+	return day
+}
+
+// analyticsPeriods obtiene el inicio de cada período del rango, para incluir en las series los períodos sin solicitudes
+func analyticsPeriods(filter models.AnalyticsFilter) []string {
+	periods := make([]string, 0)
+	seen := make(map[string]bool)
+	for day := filter.From; day.Before(filter.To); day = day.AddDate(0, 0, 1) {
+		periodStart := analyticsPeriodStart(day, filter.Period).Format("2006-01-02")
+		if !seen[periodStart] {
+			seen[periodStart] = true
+			periods = append(periods, periodStart)
+		}
+	}
+	return periods
+}
+
+// funnelCounts acumula las sumas del embudo
+type funnelCounts struct {
+	started, onProgress, completed, approved, rejected, cancelled, expired int64
+	scoreSum, scoreCount                                                   int64
+	approvedAmountSum                                                      decimal.Decimal
+}
+
+// add suma los totales de un agregado diario
+func (f *funnelCounts) add(aggregate models.FunnelAggregate) {
+	f.started += aggregate.Started
+	f.onProgress += aggregate.OnProgress
+	f.completed += aggregate.Completed
+	f.approved += aggregate.Approved
+	f.rejected += aggregate.Rejected
+	f.cancelled += aggregate.Cancelled
+	f.expired += aggregate.Expired
+	f.scoreSum += aggregate.ScoreSum
+	f.scoreCount += aggregate.ScoreCount
+	f.approvedAmountSum = f.approvedAmountSum.Add(aggregate.ApprovedAmountSum)
+}
+
+// metrics calcula las métricas del embudo a partir de las sumas
+func (f funnelCounts) metrics() models.FunnelMetrics {
+	metrics := models.FunnelMetrics{
+		Started:    f.started,
+		OnProgress: f.onProgress,
+		Completed:  f.completed,
+		Approved:   f.approved,
+		Rejected:   f.rejected,
+		Cancelled:  f.cancelled,
+		Expired:    f.expired,
+		Conversion: models.FunnelConversion{
+			StartedToOnProgress:   conversionRate(f.onProgress, f.started),
+			OnProgressToCompleted: conversionRate(f.completed, f.onProgress),
+			CompletedToApproved:   conversionRate(f.approved, f.completed),
+			CompletedToRejected:   conversionRate(f.rejected, f.completed),
+			StartedToApproved:     conversionRate(f.approved, f.started),
+		},
+		AverageApprovedAmount: decimal.Zero,
+	}
+
+	if f.scoreCount > 0 {
+		averageScore := math.Round(float64(f.scoreSum)/float64(f.scoreCount)*100) / 100
+		metrics.AverageScore = &averageScore
+	}
+	if f.approved > 0 {
+		metrics.AverageApprovedAmount = f.approvedAmountSum.Div(decimal.NewFromInt(f.approved)).Round(2)
+	}
+
+	return metrics
+}
+
+// conversionRate calcula la proporción entre dos etapas redondeada a 4 decimales
+func conversionRate(reached, base int64) float64 {
+	if base == 0 {
+		return 0
+	}
+	return math.Round(float64(reached)/float64(base)*10000) / 10000
+}
+
+// funnelAccumulator acumula el embudo en total, por período y los motivos de rechazo
+type funnelAccumulator struct {
+	totals   funnelCounts
+	byPeriod map[string]*funnelCounts
+	reasons  map[string]int64
+}
+
+// newFunnelAccumulator crea un acumulador con los períodos del rango
+func newFunnelAccumulator(periods []string) *funnelAccumulator {
+	accumulator := &funnelAccumulator{
+		byPeriod: make(map[string]*funnelCounts, len(periods)),
+		reasons:  make(map[string]int64),
+	}
+	for _, period := range periods {
+		accumulator.byPeriod[period] = &funnelCounts{}
+	}
+	return accumulator
+}
+
+// add suma un agregado diario al total y a su período
+func (a *funnelAccumulator) add(periodStart string, aggregate models.FunnelAggregate) {
+	a.totals.add(aggregate)
+	if counts, found := a.byPeriod[periodStart]; found {
+		counts.add(aggregate)
+	}
+}
+
+// addReason suma los rechazos de un motivo
+func (a *funnelAccumulator) addReason(reason models.RejectionReasonAggregate) {
+	a.reasons[reason.Reason] += reason.Count
+}
+
+// series obtiene las métricas de cada período en orden
+func (a *funnelAccumulator) series(periods []string) []models.AnalyticsPoint {
+	series := make([]models.AnalyticsPoint, len(periods))
+	for i, period := range periods {
+		series[i] = models.AnalyticsPoint{PeriodStart: period, FunnelMetrics: a.byPeriod[period].metrics()}
+	}
+	return series
+}
+
+// rejectionReasons obtiene los motivos de rechazo de mayor a menor cantidad
+func (a *funnelAccumulator) rejectionReasons() []models.RejectionReasonCount {
+	reasons := make([]models.RejectionReasonCount, 0, len(a.reasons))
+	for reason, count := range a.reasons {
+		reasons = append(reasons, models.RejectionReasonCount{Reason: reason, Count: count})
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasons[i].Count != reasons[j].Count {
+			return reasons[i].Count > reasons[j].Count
+		}
+		return reasons[i].Reason < reasons[j].Reason
+	})
+	return reasons
+}
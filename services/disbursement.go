@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/shopspring/decimal"
+)
+
+// DisbursementProvider realiza el desembolso de los préstamos aprobados con el proveedor de pagos
+type DisbursementProvider interface {
+	// Disburse desembolsa el monto al solicitante. Retorna false si el proveedor rechazó el desembolso
+	Disburse(ctx context.Context, userID uint, amount decimal.Decimal) (bool, error)
+}
+
+// simulatedDisbursementProvider proveedor de desembolsos simulado
+type simulatedDisbursementProvider struct{}
+
+// NewSimulatedDisbursementProvider crea el proveedor de desembolsos simulado
+func NewSimulatedDisbursementProvider() DisbursementProvider {
+	return &simulatedDisbursementProvider{}
+}
+
+// Disburse simula el desembolso del préstamo. Retorna error si se vence el plazo
+// antes de llamar al proveedor, para no registrar como fallido un desembolso que no se intentó
+func (p *simulatedDisbursementProvider) Disburse(ctx context.Context, userID uint, amount decimal.Decimal) (bool, error) {
+	// Simulación de desembolso más realista
+	// En un escenario real, esto sería una llamada a un servicio de pagos/bancario con ctx
+	ctx, cancel := withProviderDeadline(ctx)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	// 1. Verificar que el monto sea válido
+	if amount.LessThanOrEqual(decimal.NewFromFloat(0)) {
+		return false, nil // Falla: monto inválido
+	}
+
+	// 2. Simulación basada en el ID del usuario para consistencia en pruebas
+	userIDStr := strconv.Itoa(int(userID))
+	lastDigit := userIDStr[len(userIDStr)-1:]
+
+	// 3. Simular fallos de desembolso ocasionales (10% de probabilidad)
+	if lastDigit == "0" {
+		return false, nil // Simular falla del sistema bancario
+	}
+
+	// 4. Simular límites de desembolso diario
+	if amount.GreaterThan(decimal.NewFromFloat(50000000)) { // 50 millones
+		return false, nil // Excede límite diario de desembolso
+	}
+
+	// 5. Desembolso exitoso para todos los otros casos
+	// En un escenario real, aquí se haría:
+	// - Llamada al API bancario
+	// - Registro de la transacción
+	// - Notificación al usuario
+	return true, nil
+}
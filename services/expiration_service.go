@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"loan-api/app_error"
+	"loan-api/repositories"
+)
+
+// ExpirationService interface para el vencimiento de solicitudes inactivas
+type ExpirationService interface {
+	ExpireStaleLoans(ctx context.Context, now time.Time) (int64, error)
+}
+
+// expirationService implementación del servicio
+type expirationService struct {
+	loanRepo     repositories.LoanRepository
+	loanTypeRepo repositories.LoanTypeRepository
+}
+
+// NewExpirationService crea una nueva instancia del servicio
+func NewExpirationService(loanRepo repositories.LoanRepository, loanTypeRepo repositories.LoanTypeRepository) ExpirationService {
+	return &expirationService{
+		loanRepo:     loanRepo,
+		loanTypeRepo: loanTypeRepo,
+	}
+}
+
+// ExpireStaleLoans pasa a expirado las solicitudes pendientes o en progreso sin cambios durante más tiempo
+// que el TTL de su tipo de préstamo (los tipos sin TTL no expiran) y las que esperaban respuesta a
+// contraofertas que ya vencieron todas
+func (s *expirationService) ExpireStaleLoans(ctx context.Context, now time.Time) (int64, error) {
+	loanTypes, err := s.loanTypeRepo.GetWithApplicationTTL(ctx)
+	if err != nil {
+		return 0, app_error.NewDatabaseError("obtener tipos de préstamo", err.Error())
+	}
+
+	var total int64
+	for _, loanType := range loanTypes {
+		idleSince := now.Add(-time.Duration(loanType.ApplicationTTLHours) * time.Hour)
+		observation := fmt.Sprintf("Solicitud expirada por inactividad: sin cambios en %d horas", loanType.ApplicationTTLHours)
+
+		expired, err := s.loanRepo.ExpireStale(ctx, loanType.ID, idleSince, observation)
+		if err != nil {
+			return total, app_error.NewDatabaseError("expirar solicitudes", err.Error())
+		}
+		total += expired
+	}
+
+	expired, err := s.loanRepo.ExpireOffers(ctx, now, "Solicitud expirada: las contraofertas vencieron sin respuesta")
+	if err != nil {
+		return total, app_error.NewDatabaseError("expirar contraofertas", err.Error())
+	}
+	total += expired
+
+	return total, nil
+}
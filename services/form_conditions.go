@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"strconv"
+
+	"loan-api/app_error"
+	"loan-api/models"
+)
+
+// formAnswers representa las respuestas del préstamo por código de input e índice
+type formAnswers map[string]map[uint]string
+
+// buildFormAnswers construye las respuestas a partir de los datos del préstamo
+func buildFormAnswers(data []models.LoanData) formAnswers {
+	answers := make(formAnswers)
+	for _, item := range data {
+		if answers[item.Key] == nil {
+			answers[item.Key] = make(map[uint]string)
+		}
+		answers[item.Key][item.Index] = item.Value
+	}
+	return answers
+}
+
+// answerAt obtiene la función de consulta de respuestas para un elemento.
+// Se usa la respuesta del mismo índice y, si no existe, la del índice 0
+func (a formAnswers) answerAt(index uint) func(code string) string {
+	return func(code string) string {
+		values := a[code]
+		if value, exists := values[index]; exists {
+			return value
+		}
+		return values[0]
+	}
+}
+
+// loanDataID identifica un dato del préstamo por formulario, campo e índice
+func loanDataID(item models.LoanData) string {
+	return strconv.FormatUint(uint64(item.FormID), 10) + ":" + entryKey(item.Key, item.Index)
+}
+
+// mergeLoanData obtiene los datos resultantes de aplicar los cambios a los datos guardados
+func mergeLoanData(existing []models.LoanData, saved []models.LoanData, removed func(item models.LoanData) bool) []models.LoanData {
+	savedIDs := make(map[string]bool, len(saved))
+	for _, item := range saved {
+		savedIDs[loanDataID(item)] = true
+	}
+
+	result := make([]models.LoanData, 0, len(existing)+len(saved))
+	for _, item := range existing {
+		if savedIDs[loanDataID(item)] || (removed != nil && removed(item)) {
+			continue
+		}
+		result = append(result, item)
+	}
+	return append(result, saved...)
+}
+
+// inputLookup permite encontrar el input configurado de un dato del préstamo
+type inputLookup struct {
+	byForm map[uint]map[string]*models.LoanTypeVersionFormInput
+	byCode map[string]*models.LoanTypeVersionFormInput
+}
+
+// newInputLookup indexa los inputs por formulario y código, y por código para datos sin formulario conocido
+func newInputLookup(forms []models.LoanTypeForm) inputLookup {
+	lookup := inputLookup{
+		byForm: make(map[uint]map[string]*models.LoanTypeVersionFormInput),
+		byCode: make(map[string]*models.LoanTypeVersionFormInput),
+	}
+	for i := range forms {
+		form := &forms[i]
+		lookup.byForm[form.ID] = make(map[string]*models.LoanTypeVersionFormInput, len(form.FormInputs))
+		for j := range form.FormInputs {
+			input := &form.FormInputs[j]
+			lookup.byForm[form.ID][input.Code] = input
+			if _, exists := lookup.byCode[input.Code]; !exists {
+				lookup.byCode[input.Code] = input
+			}
+		}
+	}
+	return lookup
+}
+
+// find obtiene el input de un dato del préstamo o nil si no corresponde a ninguno
+func (l inputLookup) find(item models.LoanData) *models.LoanTypeVersionFormInput {
+	if input, found := l.byForm[item.FormID][item.Key]; found {
+		return input
+	}
+	return l.byCode[item.Key]
+}
+
+// applyInputConditions valida que los datos recibidos correspondan a inputs visibles según las
+// respuestas resultantes y obtiene los datos guardados que quedaron ocultos para eliminarlos
+func applyInputConditions(forms []models.LoanTypeForm, result []models.LoanData, saved []models.LoanData) ([]models.LoanData, error) {
+	answers := buildFormAnswers(result)
+	lookup := newInputLookup(forms)
+
+	savedIDs := make(map[string]bool, len(saved))
+	for _, item := range saved {
+		savedIDs[loanDataID(item)] = true
+
+		input := lookup.find(item)
+		if input == nil {
+			continue
+		}
+		if visible, _ := input.EvaluateState(answers.answerAt(item.Index)); !visible {
+			return nil, app_error.NewValidationError(item.Key, "el campo no aplica según las respuestas del formulario")
+		}
+	}
+
+	hidden := make([]models.LoanData, 0)
+	for _, item := range result {
+		if savedIDs[loanDataID(item)] {
+			continue
+		}
+		input := lookup.find(item)
+		if input == nil {
+			continue
+		}
+		if visible, _ := input.EvaluateState(answers.answerAt(item.Index)); !visible {
+			hidden = append(hidden, item)
+		}
+	}
+
+	return hidden, nil
+}
+
+// deleteHiddenData elimina los datos guardados de inputs que quedaron ocultos
+func (s *loanService) deleteHiddenData(ctx context.Context, loanID uint, hidden []models.LoanData) error {
+	keysByForm := make(map[uint][]models.FormDataKeyRequest)
+	for _, item := range hidden {
+		keysByForm[item.FormID] = append(keysByForm[item.FormID], models.FormDataKeyRequest{Key: item.Key, Index: item.Index})
+	}
+
+	for formID, keys := range keysByForm {
+		if err := s.loanRepo.DeleteLoanData(ctx, loanID, formID, keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"loan-api/app_error"
+	"loan-api/models"
+	"loan-api/repositories"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// exportBatchSize cantidad de préstamos que se cargan en memoria por lote al exportar
+const exportBatchSize = 500
+
+// exportBaseHeaders columnas fijas de la exportación de préstamos
+var exportBaseHeaders = []string{
+	"loan_id", "loan_type", "applicant_name", "applicant_email", "document_type", "document_number",
+	"status", "amount_approved", "credit_score", "identity_verified", "identity_match", "created_at", "updated_at",
+}
+
+// LoanExportService interface para el servicio de exportación de préstamos
+type LoanExportService interface {
+	ExportLoans(ctx context.Context, w io.Writer, requestedBy uint, format models.ExportFormat, filter models.LoanExportFilter) error
+}
+
+// loanExportService implementación del servicio
+type loanExportService struct {
+	exportRepo   repositories.LoanExportRepository
+	loanTypeRepo repositories.LoanTypeRepository
+	now          func() time.Time
+}
+
+// NewLoanExportService crea una nueva instancia del servicio
+func NewLoanExportService(exportRepo repositories.LoanExportRepository, loanTypeRepo repositories.LoanTypeRepository) LoanExportService {
+	return &loanExportService{
+		exportRepo:   exportRepo,
+		loanTypeRepo: loanTypeRepo,
+		now:          time.Now,
+	}
+}
+
+// ExportLoans escribe los préstamos del tenant en el formato indicado lote por lote y registra la exportación.
+// Los errores de validación se devuelven antes de escribir cualquier contenido
+func (s *loanExportService) ExportLoans(ctx context.Context, w io.Writer, requestedBy uint, format models.ExportFormat, filter models.LoanExportFilter) error {
+	if format != models.ExportFormatCSV && format != models.ExportFormatXLSX {
+		return app_error.ErrExportFormatInvalid
+	}
+
+	columns, err := s.buildExportColumns(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	filters, err := json.Marshal(filter)
+	if err != nil {
+		return app_error.NewBusinessError("Filtros de exportación inválidos", err.Error())
+	}
+
+	export := &models.LoanExport{
+		TenantID:    filter.TenantID,
+		RequestedBy: requestedBy,
+		Format:      format,
+		Filters:     string(filters),
+		Status:      models.LoanExportStatusRunning,
+		StartedAt:   s.now(),
+	}
+	if err := s.exportRepo.Create(ctx, export); err != nil {
+		return app_error.NewDatabaseError("registrar exportación", err.Error())
+	}
+
+	rowCount, exportErr := s.writeLoans(ctx, w, format, filter, columns)
+
+	finishedAt := s.now()
+	export.RowCount = rowCount
+	export.FinishedAt = &finishedAt
+	export.Status = models.LoanExportStatusSuccess
+	if exportErr != nil {
+		export.Status = models.LoanExportStatusFailed
+		export.Error = exportErr.Error()
+	}
+	if err := s.exportRepo.Update(ctx, export); err != nil && exportErr == nil {
+		return app_error.NewDatabaseError("registrar exportación", err.Error())
+	}
+
+	return exportErr
+}
+
+// writeLoans escribe el encabezado y las filas de los préstamos, y obtiene la cantidad de filas escritas
+func (s *loanExportService) writeLoans(ctx context.Context, w io.Writer, format models.ExportFormat, filter models.LoanExportFilter, columns exportColumns) (int, error) {
+	writer, err := newExportWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+	defer writer.Close()
+
+	if err := writer.WriteRow(append(append([]string{}, exportBaseHeaders...), columns.headers...)); err != nil {
+		return 0, err
+	}
+
+	rowCount := 0
+	var afterID uint
+	for {
+		loans, err := s.exportRepo.GetLoansBatch(ctx, filter, afterID, exportBatchSize)
+		if err != nil {
+			return rowCount, err
+		}
+
+		for _, loan := range loans {
+			if err := writer.WriteRow(buildExportRow(loan, columns)); err != nil {
+				return rowCount, err
+			}
+			rowCount++
+		}
+
+		if len(loans) < exportBatchSize {
+			break
+		}
+		afterID = loans[len(loans)-1].ID
+	}
+
+	return rowCount, writer.Flush()
+}
+
+// exportColumns representa las columnas de datos de los formularios de la exportación
+type exportColumns struct {
+	headers   []string
+	positions map[string]int  // Posición de cada columna por "formulario.input"
+	formCodes map[uint]string // Código de cada formulario por ID
+}
+
+// buildExportColumns construye una columna por cada input de los formularios de los tipos de préstamo exportados
+func (s *loanExportService) buildExportColumns(ctx context.Context, filter models.LoanExportFilter) (exportColumns, error) {
+	var loanTypes []models.LoanType
+	if filter.LoanTypeID != 0 {
+		loanType, err := s.loanTypeRepo.GetByIDWithForms(ctx, filter.LoanTypeID)
+		if err != nil || loanType.TenantID != filter.TenantID {
+			return exportColumns{}, app_error.ErrLoanTypeNotFound
+		}
+		loanTypes = []models.LoanType{*loanType}
+	} else {
+		tenantLoanTypes, err := s.loanTypeRepo.GetByTenantID(ctx, filter.TenantID)
+		if err != nil {
+			return exportColumns{}, app_error.NewDatabaseError("obtener tipos de préstamo", err.Error())
+		}
+
+		ids := make([]uint, len(tenantLoanTypes))
+		for i, loanType := range tenantLoanTypes {
+			ids[i] = loanType.ID
+		}
+
+		loanTypes, err = s.loanTypeRepo.GetByIDsWithForms(ctx, ids)
+		if err != nil {
+			return exportColumns{}, app_error.NewDatabaseError("obtener tipos de préstamo", err.Error())
+		}
+	}
+
+	columns := exportColumns{
+		positions: make(map[string]int),
+		formCodes: make(map[uint]string),
+	}
+	for _, loanType := range loanTypes {
+		forms := defaultVersionForms(loanType)
+		sort.SliceStable(forms, func(i, j int) bool { return forms[i].Order < forms[j].Order })
+
+		for _, form := range forms {
+			columns.formCodes[form.ID] = form.Code
+			for _, input := range form.FormInputs {
+				header := form.Code + "." + input.Code
+				if _, exists := columns.positions[header]; !exists {
+					columns.positions[header] = len(columns.headers)
+					columns.headers = append(columns.headers, header)
+				}
+			}
+		}
+	}
+
+	return columns, nil
+}
+
+// buildExportRow construye la fila de un préstamo con sus datos pivotados en las columnas de los formularios.
+// Los elementos de formularios repetibles se unen en una misma celda en el orden de su índice
+func buildExportRow(loan models.Loan, columns exportColumns) []string {
+	row := []string{
+		strconv.FormatUint(uint64(loan.ID), 10),
+		loan.LoanType.Code,
+		loan.User.Name,
+		loan.User.Email,
+		string(loan.User.DocumentType),
+		loan.User.DocumentNumber,
+		loan.Status,
+		loan.AmountApproved.String(),
+		"",
+		"",
+		string(loan.IdentityMatch),
+		loan.CreatedAt.Format(time.RFC3339),
+		loan.UpdatedAt.Format(time.RFC3339),
+	}
+	if loan.CreditScore != nil {
+		row[8] = strconv.Itoa(*loan.CreditScore)
+	}
+	if loan.IdentityVerified != nil {
+		row[9] = strconv.FormatBool(*loan.IdentityVerified)
+	}
+
+	values := make(map[int]map[uint]string)
+	for _, item := range loan.Data {
+		position, found := columns.positions[columns.formCodes[item.FormID]+"."+item.Key]
+		if !found {
+			continue
+		}
+		if values[position] == nil {
+			values[position] = make(map[uint]string)
+		}
+		values[position][item.Index] = item.Value
+	}
+
+	dataRow := make([]string, len(columns.headers))
+	for position, byIndex := range values {
+		indexes := make([]uint, 0, len(byIndex))
+		for index := range byIndex {
+			indexes = append(indexes, index)
+		}
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+		cell := make([]string, len(indexes))
+		for i, index := range indexes {
+			cell[i] = byIndex[index]
+		}
+		dataRow[position] = strings.Join(cell, "; ")
+	}
+
+	return append(row, dataRow...)
+}
+
+// exportWriter escribe las filas de una exportación en un formato de archivo
+type exportWriter interface {
+	WriteRow(values []string) error
+	Flush() error // Envía el contenido pendiente al finalizar la exportación
+	Close() error // Libera los recursos, incluso si la exportación se interrumpe
+}
+
+// newExportWriter crea el escritor del formato indicado
+func newExportWriter(w io.Writer, format models.ExportFormat) (exportWriter, error) {
+	if format == models.ExportFormatXLSX {
+		return newXLSXExportWriter(w)
+	}
+	return &csvExportWriter{writer: csv.NewWriter(w)}, nil
+}
+
+// csvExportWriter escribe las filas en CSV directamente sobre la respuesta
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+// WriteRow escribe una fila del CSV
+func (e *csvExportWriter) WriteRow(values []string) error {
+	return e.writer.Write(values)
+}
+
+// Flush envía las filas pendientes del CSV
+func (e *csvExportWriter) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// Close no requiere liberar recursos en CSV
+func (e *csvExportWriter) Close() error {
+	return nil
+}
+
+// xlsxExportWriter escribe las filas con el stream writer de excelize, que mantiene en disco
+// las filas que exceden su buffer, y envía el libro al finalizar
+type xlsxExportWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+// newXLSXExportWriter crea el libro con una hoja de préstamos
+func newXLSXExportWriter(w io.Writer) (*xlsxExportWriter, error) {
+	file := excelize.NewFile()
+	sheet := file.GetSheetName(0)
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxExportWriter{w: w, file: file, stream: stream}, nil
+}
+
+// WriteRow escribe una fila de la hoja
+func (e *xlsxExportWriter) WriteRow(values []string) error {
+	e.row++
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+
+	row := make([]interface{}, len(values))
+	for i, value := range values {
+		row[i] = value
+	}
+	return e.stream.SetRow(cell, row)
+}
+
+// Flush finaliza la hoja y escribe el libro en la respuesta
+func (e *xlsxExportWriter) Flush() error {
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.w)
+}
+
+// Close elimina los archivos temporales del libro
+func (e *xlsxExportWriter) Close() error {
+	return e.file.Close()
+}
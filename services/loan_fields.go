@@ -0,0 +1,129 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"loan-api/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// dateLayouts formatos de fecha aceptados al tipar los datos del préstamo
+var dateLayouts = []string{"2006-01-02", time.RFC3339, "02/01/2006", "2006/01/02"}
+
+// buildLoanFields construye la vista tipada de los datos del préstamo cruzando cada dato
+// con el input del formulario al que pertenece
+func buildLoanFields(data []models.LoanData, forms []models.LoanTypeForm) models.LoanFields {
+	type formInputRef struct {
+		form       *models.LoanTypeForm
+		input      *models.LoanTypeVersionFormInput
+		repeatable bool
+	}
+
+	// Indexar los inputs por formulario y código, y por código para datos sin formulario conocido
+	byForm := make(map[uint]map[string]formInputRef)
+	byCode := make(map[string]formInputRef)
+	for i := range forms {
+		form := &forms[i]
+		formConfig, _ := form.ParseConfig()
+		byForm[form.ID] = make(map[string]formInputRef, len(form.FormInputs))
+		for j := range form.FormInputs {
+			ref := formInputRef{form: form, input: &form.FormInputs[j], repeatable: formConfig.Repeatable}
+			byForm[form.ID][ref.input.Code] = ref
+			if _, exists := byCode[ref.input.Code]; !exists {
+				byCode[ref.input.Code] = ref
+			}
+		}
+	}
+
+	simple := make(map[string]models.LoanFormFields)
+	repeated := make(map[string]map[uint]models.LoanFormFields)
+	for _, item := range data {
+		ref, found := byForm[item.FormID][item.Key]
+		if !found {
+			if ref, found = byCode[item.Key]; !found {
+				continue // Datos que no corresponden a un input configurado solo se exponen en la lista cruda
+			}
+		}
+
+		value := typedFieldValue(*ref.input, item.Value)
+		formCode := ref.form.Code
+
+		if !ref.repeatable {
+			if simple[formCode] == nil {
+				simple[formCode] = make(models.LoanFormFields)
+			}
+			simple[formCode][item.Key] = value
+			continue
+		}
+
+		if repeated[formCode] == nil {
+			repeated[formCode] = make(map[uint]models.LoanFormFields)
+		}
+		if repeated[formCode][item.Index] == nil {
+			repeated[formCode][item.Index] = make(models.LoanFormFields)
+		}
+		repeated[formCode][item.Index][item.Key] = value
+	}
+
+	if len(simple) == 0 && len(repeated) == 0 {
+		return nil
+	}
+
+	fields := make(models.LoanFields, len(simple)+len(repeated))
+	for formCode, values := range simple {
+		fields[formCode] = values
+	}
+	for formCode, items := range repeated {
+		indexes := make([]uint, 0, len(items))
+		for index := range items {
+			indexes = append(indexes, index)
+		}
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+		values := make([]models.LoanFormFields, len(indexes))
+		for i, index := range indexes {
+			values[i] = items[index]
+		}
+		fields[formCode] = values
+	}
+
+	return fields
+}
+
+// typedFieldValue convierte el valor guardado según el tipo del input.
+// Si el valor no corresponde al tipo se devuelve tal como fue guardado
+func typedFieldValue(input models.LoanTypeVersionFormInput, value string) interface{} {
+	trimmed := strings.TrimSpace(value)
+
+	switch input.InputType {
+	case "number":
+		if number, err := decimal.NewFromString(trimmed); err == nil {
+			return number
+		}
+	case "date":
+		for _, layout := range dateLayouts {
+			if date, err := time.Parse(layout, trimmed); err == nil {
+				return date.Format("2006-01-02")
+			}
+		}
+	case "checkbox", "boolean":
+		if checked, err := strconv.ParseBool(trimmed); err == nil {
+			return checked
+		}
+	case "select":
+		option := models.SelectOption{Value: value, Label: value}
+		for _, candidate := range input.ParseOptions() {
+			if candidate.Value == value {
+				option.Label = candidate.Label
+				break
+			}
+		}
+		return option
+	}
+
+	return value
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"loan-api/app_error"
+	"loan-api/models"
+	"loan-api/repositories"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// LoanPartyService interface para el servicio de codeudores y garantes
+type LoanPartyService interface {
+	InviteParty(ctx context.Context, userID, loanID uint, request models.InviteLoanPartyRequest) (*models.LoanPartyResponse, error)
+	AcceptInvitation(ctx context.Context, userID, loanID uint, request models.AcceptLoanPartyRequest) (*models.LoanResponse, error)
+	DeclineInvitation(ctx context.Context, userID, loanID uint) (*models.LoanResponse, error)
+	GetParties(ctx context.Context, userID, loanID uint) ([]models.LoanPartyResponse, error)
+}
+
+// loanPartyService implementación del servicio
+type loanPartyService struct {
+	*loanService
+	partyRepo repositories.LoanPartyRepository
+	now       func() time.Time
+}
+
+// NewLoanPartyService crea una nueva instancia del servicio
+func NewLoanPartyService(loanRepo repositories.LoanRepository, userRepo repositories.UserRepository, loanTypeRepo repositories.LoanTypeRepository, tenantRepo repositories.TenantRepository, partyRepo repositories.LoanPartyRepository) LoanPartyService {
+	return &loanPartyService{
+		loanService: &loanService{
+			loanRepo:     loanRepo,
+			userRepo:     userRepo,
+			loanTypeRepo: loanTypeRepo,
+			tenantRepo:   tenantRepo,
+		},
+		partyRepo: partyRepo,
+		now:       time.Now,
+	}
+}
+
+// InviteParty invita a otro usuario registrado del tenant a participar en el préstamo como codeudor o garante
+func (s *loanPartyService) InviteParty(ctx context.Context, userID, loanID uint, request models.InviteLoanPartyRequest) (*models.LoanPartyResponse, error) {
+	loan, err := s.getOpenLoan(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Solo el solicitante principal puede invitar
+	if loan.UserID != userID {
+		return nil, app_error.ErrForbidden
+	}
+
+	if !request.Role.IsInvitable() {
+		return nil, app_error.NewValidationError("role", "el rol debe ser co_applicant o guarantor")
+	}
+
+	email := strings.TrimSpace(request.Email)
+	if email == "" {
+		return nil, app_error.NewValidationError("email", "el email del invitado es obligatorio")
+	}
+
+	invitee, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil || invitee.TenantID != loan.User.TenantID {
+		return nil, app_error.ErrPartyInviteeNotFound
+	}
+
+	if invitee.ID == userID {
+		return nil, app_error.NewValidationError("email", "no puede invitarse a sí mismo")
+	}
+
+	party, err := s.partyRepo.GetByLoanAndUser(ctx, loanID, invitee.ID)
+	switch {
+	case err == nil && party.Status != models.LoanPartyStatusDeclined:
+		return nil, app_error.ErrPartyAlreadyInvited
+	case err == nil:
+		// Una invitación rechazada puede enviarse de nuevo
+		*party = models.LoanParty{
+			ID:        party.ID,
+			LoanID:    loanID,
+			UserID:    invitee.ID,
+			CreatedAt: party.CreatedAt,
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		party = &models.LoanParty{LoanID: loanID, UserID: invitee.ID}
+	default:
+		return nil, app_error.NewDatabaseError("obtener participante", err.Error())
+	}
+
+	party.Role = request.Role
+	party.Status = models.LoanPartyStatusInvited
+	party.InvitedBy = &userID
+
+	if party.ID == 0 {
+		err = s.partyRepo.Create(ctx, party)
+	} else {
+		err = s.partyRepo.Update(ctx, party)
+	}
+	if err != nil {
+		return nil, app_error.NewDatabaseError("invitar participante", err.Error())
+	}
+
+	party.User = *invitee
+	response := models.BuildLoanPartiesResponse([]models.LoanParty{*party})[0]
+	return &response, nil
+}
+
+// AcceptInvitation acepta la invitación con el consentimiento del invitado y valida su score e identidad
+func (s *loanPartyService) AcceptInvitation(ctx context.Context, userID, loanID uint, request models.AcceptLoanPartyRequest) (*models.LoanResponse, error) {
+	party, err := s.getPendingInvitation(ctx, userID, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !request.Consent {
+		return nil, app_error.ErrPartyConsentRequired
+	}
+
+	if request.MonthlyIncome.IsNegative() || request.MonthlyExpenses.IsNegative() {
+		return nil, app_error.NewValidationError("monthly_income", "los ingresos y gastos no pueden ser negativos")
+	}
+
+	// Consultar el score y verificar la identidad del invitado con los datos declarados
+	creditScore, err := s.simulateCreditScore(ctx, request.DocumentType, request.DocumentNumber)
+	if err != nil {
+		return nil, app_error.NewBusinessError("Error al consultar el score crediticio", err.Error())
+	}
+
+	identityMatch, err := s.verifyIdentity(ctx, userID, request.DocumentType, request.DocumentNumber, request.FullName)
+	if err != nil {
+		return nil, app_error.NewBusinessError("Error al verificar la identidad", err.Error())
+	}
+	identityVerified := identityMatch == models.IdentityMatchFull
+
+	now := s.now()
+	party.Status = models.LoanPartyStatusAccepted
+	party.MonthlyIncome = request.MonthlyIncome
+	party.MonthlyExpenses = request.MonthlyExpenses
+	party.CreditScore = &creditScore
+	party.IdentityVerified = &identityVerified
+	party.IdentityMatch = identityMatch
+	party.ConsentedAt = &now
+	party.RespondedAt = &now
+
+	if err := s.partyRepo.Update(ctx, party); err != nil {
+		return nil, app_error.NewDatabaseError("aceptar invitación", err.Error())
+	}
+
+	return s.GetLoanByID(ctx, loanID)
+}
+
+// DeclineInvitation rechaza la invitación a participar en el préstamo
+func (s *loanPartyService) DeclineInvitation(ctx context.Context, userID, loanID uint) (*models.LoanResponse, error) {
+	party, err := s.getPendingInvitation(ctx, userID, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	party.Status = models.LoanPartyStatusDeclined
+	party.RespondedAt = &now
+
+	if err := s.partyRepo.Update(ctx, party); err != nil {
+		return nil, app_error.NewDatabaseError("rechazar invitación", err.Error())
+	}
+
+	return s.GetLoanByID(ctx, loanID)
+}
+
+// GetParties obtiene los participantes del préstamo para el solicitante o cualquiera de los invitados
+func (s *loanPartyService) GetParties(ctx context.Context, userID, loanID uint) ([]models.LoanPartyResponse, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, app_error.ErrLoanNotFound
+	}
+
+	if loan.UserID != userID {
+		if _, err := s.partyRepo.GetByLoanAndUser(ctx, loanID, userID); err != nil {
+			return nil, app_error.ErrForbidden
+		}
+	}
+
+	parties, err := s.partyRepo.GetByLoanID(ctx, loanID)
+	if err != nil {
+		return nil, app_error.NewDatabaseError("obtener participantes", err.Error())
+	}
+
+	response := models.BuildLoanPartiesResponse(parties)
+	if response == nil {
+		response = []models.LoanPartyResponse{}
+	}
+	return response, nil
+}
+
+// getOpenLoan obtiene un préstamo que aún no tiene decisión
+func (s *loanPartyService) getOpenLoan(ctx context.Context, loanID uint) (*models.Loan, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, app_error.ErrLoanNotFound
+	}
+
+	switch models.LoanStatus(loan.Status) {
+	case models.LoanStatusPending, models.LoanStatusOnProgress, models.LoanStatusCompleted:
+		return loan, nil
+	}
+	return nil, app_error.ErrPartyLoanNotOpen
+}
+
+// getPendingInvitation obtiene la invitación pendiente del usuario en un préstamo sin decisión
+func (s *loanPartyService) getPendingInvitation(ctx context.Context, userID, loanID uint) (*models.LoanParty, error) {
+	if _, err := s.getOpenLoan(ctx, loanID); err != nil {
+		return nil, err
+	}
+
+	party, err := s.partyRepo.GetByLoanAndUser(ctx, loanID, userID)
+	if err != nil || party.Role == models.LoanPartyRolePrimary {
+		return nil, app_error.ErrPartyInvitationNotFound
+	}
+
+	if party.Status != models.LoanPartyStatusInvited {
+		return nil, app_error.ErrPartyInvitationNotPending
+	}
+
+	return party, nil
+}
+
+// checkLoanParties verifica que no haya invitaciones pendientes y que hayan aceptado los roles requeridos
+func checkLoanParties(parties []models.LoanParty, cfg models.PartiesConfig) error {
+	accepted := make(map[models.LoanPartyRole]bool)
+	for _, party := range parties {
+		if party.Status == models.LoanPartyStatusInvited {
+			return app_error.ErrPartyInvitationsPending
+		}
+		if party.Status == models.LoanPartyStatusAccepted {
+			accepted[party.Role] = true
+		}
+	}
+
+	for _, role := range cfg.RequiredRoles {
+		if !accepted[role] {
+			return app_error.NewBusinessError("Faltan participantes requeridos", "el tipo de préstamo requiere un participante aceptado con rol "+string(role))
+		}
+	}
+	return nil
+}
+
+// evaluateLoanParties verifica la identidad y el score de los codeudores y garantes aceptados
+func evaluateLoanParties(parties []models.LoanParty, cfg models.PartiesConfig) (bool, string) {
+	for _, party := range parties {
+		if party.Role == models.LoanPartyRolePrimary || party.Status != models.LoanPartyStatusAccepted {
+			continue
+		}
+
+		if party.IdentityVerified == nil || !*party.IdentityVerified {
+			return false, "Préstamo rechazado: no se pudo verificar la identidad de un " + partyRoleLabel(party.Role)
+		}
+
+		if cfg.MinPartyScore > 0 && (party.CreditScore == nil || *party.CreditScore < cfg.MinPartyScore) {
+			return false, "Préstamo rechazado: score crediticio insuficiente de un " + partyRoleLabel(party.Role)
+		}
+	}
+	return true, ""
+}
+
+// combinePartyIncomes suma a los ingresos y gastos del solicitante los de los participantes
+// aceptados cuyos roles están configurados para combinar ingresos
+func combinePartyIncomes(parties []models.LoanParty, cfg models.PartiesConfig, monthlyIncome, monthlyExpenses decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	combine := make(map[models.LoanPartyRole]bool, len(cfg.CombineIncomeRoles))
+	for _, role := range cfg.CombineIncomeRoles {
+		combine[role] = true
+	}
+
+	for _, party := range parties {
+		if party.Role == models.LoanPartyRolePrimary || party.Status != models.LoanPartyStatusAccepted || !combine[party.Role] {
+			continue
+		}
+		monthlyIncome = monthlyIncome.Add(party.MonthlyIncome)
+		monthlyExpenses = monthlyExpenses.Add(party.MonthlyExpenses)
+	}
+	return monthlyIncome, monthlyExpenses
+}
+
+// loanPartyRole obtiene el rol del usuario en el préstamo
+func loanPartyRole(loan models.Loan, userID uint) models.LoanPartyRole {
+	if loan.UserID == userID {
+		return models.LoanPartyRolePrimary
+	}
+	for _, party := range loan.Parties {
+		if party.UserID == userID {
+			return party.Role
+		}
+	}
+	return ""
+}
+
+// partyRoleLabel obtiene el nombre del rol para las observaciones
+func partyRoleLabel(role models.LoanPartyRole) string {
+	if role == models.LoanPartyRoleGuarantor {
+		return "garante"
+	}
+	return "codeudor"
+}
@@ -1,7 +1,10 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"loan-api/app_error"
 	"loan-api/models"
 	"loan-api/repositories"
 	"math/rand"
@@ -14,11 +17,19 @@ import (
 
 // LoanService interface para el servicio de préstamos
 type LoanService interface {
-	CreateLoan(userID uint, request models.CreateLoanRequest) (*models.LoanResponse, error)
-	SaveLoanData(request models.SaveLoanDataRequest) error
-	ProcessLoanDecision(loanID uint) (*models.LoanResponse, error)
-	GetLoanByID(id uint) (*models.LoanResponse, error)
-	GetLoansByUserID(userID uint) ([]models.LoanResponse, error)
+	CreateLoan(ctx context.Context, userID uint, request models.CreateLoanRequest) (*models.LoanResponse, error)
+	SaveLoanData(ctx context.Context, request models.SaveLoanDataRequest) error
+	ReplaceFormData(ctx context.Context, userID, loanID uint, formCode string, request models.ReplaceFormDataRequest) (*models.LoanResponse, error)
+	PatchFormData(ctx context.Context, userID, loanID uint, formCode string, request models.PatchFormDataRequest) (*models.LoanResponse, error)
+	GetLoanForms(ctx context.Context, userID, loanID uint) ([]models.LoanTypeFormResponse, error)
+	ProcessLoanDecision(ctx context.Context, loanID uint) (*models.LoanResponse, error)
+	GetLoanByID(ctx context.Context, id uint) (*models.LoanResponse, error)
+	GetLoansByUserID(ctx context.Context, opts models.LoanQueryOptions) ([]models.LoanResponse, int64, error)
+	SearchLoans(ctx context.Context, filter models.LoanSearchFilter) ([]models.LoanSearchItem, uint, error)
+	CancelLoan(ctx context.Context, userID, loanID uint, reason string) (*models.LoanResponse, error)
+	CancelLoanAsStaff(ctx context.Context, tenantID, loanID, staffID uint, reason string) (*models.LoanResponse, error)
+	AcceptOffer(ctx context.Context, userID, loanID, offerID uint) (*models.LoanResponse, error)
+	DeclineOffer(ctx context.Context, userID, loanID, offerID uint) (*models.LoanResponse, error)
 }
 
 // loanService implementación del servicio
@@ -26,48 +37,72 @@ type loanService struct {
 	loanRepo     repositories.LoanRepository
 	userRepo     repositories.UserRepository
 	loanTypeRepo repositories.LoanTypeRepository
+	tenantRepo   repositories.TenantRepository
+	txManager    repositories.TransactionManager
+	disbursement DisbursementProvider
 }
 
 // NewLoanService crea una nueva instancia del servicio
-func NewLoanService(loanRepo repositories.LoanRepository, userRepo repositories.UserRepository, loanTypeRepo repositories.LoanTypeRepository) LoanService {
+func NewLoanService(loanRepo repositories.LoanRepository, userRepo repositories.UserRepository, loanTypeRepo repositories.LoanTypeRepository, tenantRepo repositories.TenantRepository, txManager repositories.TransactionManager, disbursement DisbursementProvider) LoanService {
 	return &loanService{
 		loanRepo:     loanRepo,
 		userRepo:     userRepo,
 		loanTypeRepo: loanTypeRepo,
+		tenantRepo:   tenantRepo,
+		txManager:    txManager,
+		disbursement: disbursement,
 	}
 }
 
 // CreateLoan crea un nuevo préstamo
-func (s *loanService) CreateLoan(userID uint, request models.CreateLoanRequest) (*models.LoanResponse, error) {
+func (s *loanService) CreateLoan(ctx context.Context, userID uint, request models.CreateLoanRequest) (*models.LoanResponse, error) {
 	// Validar que el usuario existe
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, errors.New("usuario no encontrado")
 	}
 
 	// Validar que el tipo de préstamo existe
-	loanType, err := s.loanTypeRepo.GetByIDWithForms(request.LoanTypeID)
+	loanType, err := s.loanTypeRepo.GetByIDWithForms(ctx, request.LoanTypeID)
 	if err != nil {
 		return nil, errors.New("tipo de préstamo no encontrado")
 	}
 
-	// Crear el préstamo
+	// Crear el préstamo con el solicitante como participante principal
+	now := time.Now()
 	loan := &models.Loan{
 		LoanTypeID:     request.LoanTypeID,
 		UserID:         userID,
 		Status:         "pending",
 		Observation:    "",
 		AmountApproved: decimal.NewFromFloat(0),
+		Parties: []models.LoanParty{
+			{
+				UserID:      userID,
+				Role:        models.LoanPartyRolePrimary,
+				Status:      models.LoanPartyStatusAccepted,
+				ConsentedAt: &now,
+				RespondedAt: &now,
+			},
+		},
 	}
 
-	if err := s.loanRepo.Create(loan); err != nil {
-		return nil, errors.New("error al crear el préstamo")
-	}
+	// El préstamo solo se confirma si también puede leerse completo con sus relaciones
+	var createdLoan *models.Loan
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.loanRepo.Create(ctx, loan); err != nil {
+			return errors.New("error al crear el préstamo")
+		}
 
-	// Obtener el préstamo creado con todas sus relaciones
-	createdLoan, err := s.loanRepo.GetByID(loan.ID)
+		// Obtener el préstamo creado con todas sus relaciones
+		createdLoan, err = s.loanRepo.GetByID(ctx, loan.ID)
+		if err != nil {
+			return errors.New("error al obtener el préstamo creado")
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, errors.New("error al obtener el préstamo creado")
+		return nil, err
 	}
 
 	// Construir la respuesta
@@ -75,82 +110,336 @@ func (s *loanService) CreateLoan(userID uint, request models.CreateLoanRequest)
 	return &response, nil
 }
 
-// SaveLoanData guarda los datos de un préstamo
-func (s *loanService) SaveLoanData(request models.SaveLoanDataRequest) error {
+// SaveLoanData guarda los datos de un préstamo, actualizando solo las entradas recibidas
+func (s *loanService) SaveLoanData(ctx context.Context, request models.SaveLoanDataRequest) error {
 	// Validar que el préstamo existe
-	loan, err := s.loanRepo.GetByID(request.LoanID)
+	loan, err := s.loanRepo.GetByID(ctx, request.LoanID)
 	if err != nil {
 		return errors.New("préstamo no encontrado")
 	}
 
+	if err := s.validateEditableLoan(loan); err != nil {
+		return err
+	}
+
+	loanType, err := s.loanTypeRepo.GetByIDWithForms(ctx, loan.LoanTypeID)
+	if err != nil {
+		return errors.New("tipo de préstamo no encontrado")
+	}
+
+	// Preparar los datos recibidos
+	touchedKeys := make([]string, len(request.Data))
+	loanDataList := make([]models.LoanData, len(request.Data))
+	for i, dataItem := range request.Data {
+		touchedKeys[i] = dataItem.Key
+		loanDataList[i] = models.LoanData{
+			LoanID: request.LoanID,
+			FormID: dataItem.FormID,
+			Key:    dataItem.Key,
+			Value:  dataItem.Value,
+			Index:  dataItem.Index,
+		}
+	}
+
+	// Validar los índices de cada formulario con los datos resultantes
+	forms := defaultVersionForms(*loanType)
+	for _, form := range forms {
+		entries := collectFormEntries(loan.Data, form.ID)
+		received := false
+		for _, data := range loanDataList {
+			if data.FormID == form.ID {
+				entries.add([]models.LoanData{data})
+				received = true
+			}
+		}
+		if !received {
+			continue
+		}
+		if err := validateFormIndexes(form, entries); err != nil {
+			return err
+		}
+	}
+
+	// Validar las condiciones de los inputs con las respuestas resultantes
+	hidden, err := applyInputConditions(forms, mergeLoanData(loan.Data, loanDataList, nil), loanDataList)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range hidden {
+		touchedKeys = append(touchedKeys, item.Key)
+	}
+
+	// Los datos y el estado resultante se confirman juntos o no se confirma nada
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Guardar los datos sin afectar los de otros formularios
+		if err := s.loanRepo.SaveLoanData(ctx, loanDataList); err != nil {
+			return errors.New("error al guardar los datos del préstamo")
+		}
+
+		if err := s.deleteHiddenData(ctx, loan.ID, hidden); err != nil {
+			return errors.New("error al eliminar los datos que ya no aplican")
+		}
+
+		return s.refreshLoanStatus(ctx, loan, touchedKeys)
+	})
+}
+
+// ReplaceFormData reemplaza todos los datos de un formulario del préstamo
+func (s *loanService) ReplaceFormData(ctx context.Context, userID, loanID uint, formCode string, request models.ReplaceFormDataRequest) (*models.LoanResponse, error) {
+	loan, form, forms, err := s.getEditableLoanForm(ctx, userID, loanID, formCode)
+	if err != nil {
+		return nil, err
+	}
+
+	loanDataList, touchedKeys, err := s.buildFormData(loan.ID, *form, request.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(formEntries)
+	entries.add(loanDataList)
+	if err := validateFormIndexes(*form, entries); err != nil {
+		return nil, err
+	}
+
+	// Los valores que no vienen en la solicitud se eliminan del formulario
+	for _, existing := range loan.Data {
+		if existing.FormID == form.ID {
+			touchedKeys = append(touchedKeys, existing.Key)
+		}
+	}
+
+	result := mergeLoanData(loan.Data, loanDataList, func(item models.LoanData) bool {
+		return item.FormID == form.ID
+	})
+	hidden, err := applyInputConditions(forms, result, loanDataList)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range hidden {
+		touchedKeys = append(touchedKeys, item.Key)
+	}
+
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.loanRepo.ReplaceFormData(ctx, loan.ID, form.ID, loanDataList); err != nil {
+			return app_error.NewDatabaseError("reemplazar datos del formulario", err.Error())
+		}
+
+		if err := s.deleteHiddenData(ctx, loan.ID, hidden); err != nil {
+			return app_error.NewDatabaseError("eliminar datos que ya no aplican", err.Error())
+		}
+
+		return s.refreshLoanStatus(ctx, loan, touchedKeys)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetLoanByID(ctx, loan.ID)
+}
+
+// PatchFormData actualiza valores puntuales de un formulario y elimina los indicados explícitamente
+func (s *loanService) PatchFormData(ctx context.Context, userID, loanID uint, formCode string, request models.PatchFormDataRequest) (*models.LoanResponse, error) {
+	loan, form, forms, err := s.getEditableLoanForm(ctx, userID, loanID, formCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(request.Data) == 0 && len(request.Delete) == 0 {
+		return nil, app_error.NewValidationError("data", "debe enviar valores a actualizar o eliminar")
+	}
+
+	loanDataList, touchedKeys, err := s.buildFormData(loan.ID, *form, request.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range request.Delete {
+		touchedKeys = append(touchedKeys, key.Key)
+	}
+
+	entries := collectFormEntries(loan.Data, form.ID)
+	entries.remove(request.Delete)
+	entries.add(loanDataList)
+	if err := validateFormIndexes(*form, entries); err != nil {
+		return nil, err
+	}
+
+	deleted := make(map[string]bool, len(request.Delete))
+	for _, key := range request.Delete {
+		deleted[entryKey(key.Key, key.Index)] = true
+	}
+	result := mergeLoanData(loan.Data, loanDataList, func(item models.LoanData) bool {
+		return item.FormID == form.ID && deleted[entryKey(item.Key, item.Index)]
+	})
+	hidden, err := applyInputConditions(forms, result, loanDataList)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range hidden {
+		touchedKeys = append(touchedKeys, item.Key)
+	}
+
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.loanRepo.SaveLoanData(ctx, loanDataList); err != nil {
+			return app_error.NewDatabaseError("guardar datos del formulario", err.Error())
+		}
+
+		if len(request.Delete) > 0 {
+			if err := s.loanRepo.DeleteLoanData(ctx, loan.ID, form.ID, request.Delete); err != nil {
+				return app_error.NewDatabaseError("eliminar datos del formulario", err.Error())
+			}
+		}
+
+		if err := s.deleteHiddenData(ctx, loan.ID, hidden); err != nil {
+			return app_error.NewDatabaseError("eliminar datos que ya no aplican", err.Error())
+		}
+
+		return s.refreshLoanStatus(ctx, loan, touchedKeys)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetLoanByID(ctx, loan.ID)
+}
+
+// GetLoanForms obtiene los formularios del préstamo con la visibilidad y obligatoriedad de cada input
+// evaluadas con las respuestas guardadas. En formularios repetibles se evalúa el primer elemento
+func (s *loanService) GetLoanForms(ctx context.Context, userID, loanID uint) ([]models.LoanTypeFormResponse, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, app_error.ErrLoanNotFound
+	}
+
+	if loan.UserID != userID {
+		return nil, app_error.ErrForbidden
+	}
+
+	loanType, err := s.loanTypeRepo.GetByIDWithForms(ctx, loan.LoanTypeID)
+	if err != nil {
+		return nil, app_error.NewDatabaseError("obtener tipo de préstamo", err.Error())
+	}
+
+	forms := defaultVersionForms(*loanType)
+	answers := buildFormAnswers(loan.Data)
+
+	response := buildFormsResponse(forms)
+	for i, form := range forms {
+		for j, input := range form.FormInputs {
+			visible, required := input.EvaluateState(answers.answerAt(0))
+			response[i].FormInputs[j].Visible = &visible
+			response[i].FormInputs[j].Required = &required
+		}
+	}
+
+	return response, nil
+}
+
+// validateEditableLoan verifica que el préstamo acepte cambios en sus datos
+func (s *loanService) validateEditableLoan(loan *models.Loan) error {
+	// Un préstamo cancelado no puede reabrirse
+	if loan.Status == string(models.LoanStatusCancelled) {
+		return app_error.ErrLoanCancelled
+	}
+
 	// Validar que el préstamo está en estado pendiente o en progreso
 	if loan.Status != "pending" && loan.Status != "on_progress" {
 		return errors.New("solo se pueden actualizar préstamos en estado pendiente o en progreso")
 	}
+	return nil
+}
 
-	// Extraer datos necesarios para validaciones
-	documentType := s.extractLoanDataValue(request.Data, "document_type")
-	documentNumber := s.extractLoanDataValue(request.Data, "document_number")
-	fullName := s.extractLoanDataValue(request.Data, "full_name")
+// getEditableLoanForm obtiene el préstamo del solicitante, el formulario indicado y todos los
+// formularios de la versión por defecto
+func (s *loanService) getEditableLoanForm(ctx context.Context, userID, loanID uint, formCode string) (*models.Loan, *models.LoanTypeForm, []models.LoanTypeForm, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, nil, nil, app_error.ErrLoanNotFound
+	}
 
-	// Procesar validaciones solo si tenemos los datos necesarios
-	var creditScore *int
-	var identityVerified *bool
+	if loan.UserID != userID {
+		return nil, nil, nil, app_error.ErrForbidden
+	}
 
-	if documentType != "" && documentNumber != "" {
-		// 1. Simulación del score crediticio
-		score, err := s.simulateCreditScore(documentType, documentNumber)
-		if err != nil {
-			return errors.New("error al consultar el score crediticio: " + err.Error())
+	if err := s.validateEditableLoan(loan); err != nil {
+		if _, ok := app_error.IsAppError(err); ok {
+			return nil, nil, nil, err
 		}
-		creditScore = &score
+		return nil, nil, nil, app_error.NewBusinessError("Estado de préstamo inválido", err.Error())
+	}
 
-		// 2. Verificación de identidad
-		if fullName != "" {
-			verified, err := s.verifyIdentity(loan.UserID, documentType, documentNumber, fullName)
-			if err != nil {
-				// Solo falla si hay errores técnicos (datos insuficientes, problemas de BD, etc.)
-				return errors.New("error al verificar la identidad: " + err.Error())
-			}
-			// Si no hay error técnico, usar el resultado de la verificación (true/false)
-			identityVerified = &verified
+	loanType, err := s.loanTypeRepo.GetByIDWithForms(ctx, loan.LoanTypeID)
+	if err != nil {
+		return nil, nil, nil, app_error.NewDatabaseError("obtener tipo de préstamo", err.Error())
+	}
+
+	forms := defaultVersionForms(*loanType)
+	for i := range forms {
+		if forms[i].Code == formCode {
+			return loan, &forms[i], forms, nil
 		}
 	}
 
-	// Actualizar los campos de validación del préstamo
-	if creditScore != nil {
-		loan.CreditScore = creditScore
+	return nil, nil, nil, app_error.ErrFormNotFound
+}
+
+// buildFormData convierte los valores recibidos en datos del préstamo, validando que pertenezcan al formulario
+func (s *loanService) buildFormData(loanID uint, form models.LoanTypeForm, items []models.FormDataItemRequest) ([]models.LoanData, []string, error) {
+	inputs := make(map[string]bool, len(form.FormInputs))
+	for _, input := range form.FormInputs {
+		inputs[input.Code] = true
 	}
-	if identityVerified != nil {
-		loan.IdentityVerified = identityVerified
+
+	touchedKeys := make([]string, 0, len(items))
+	loanDataList := make([]models.LoanData, 0, len(items))
+	for _, item := range items {
+		if !inputs[item.Key] {
+			return nil, nil, app_error.NewValidationError(item.Key, "el campo no pertenece al formulario "+form.Code)
+		}
+		touchedKeys = append(touchedKeys, item.Key)
+		loanDataList = append(loanDataList, models.LoanData{
+			LoanID: loanID,
+			FormID: form.ID,
+			Key:    item.Key,
+			Value:  item.Value,
+			Index:  item.Index,
+		})
 	}
+	return loanDataList, touchedKeys, nil
+}
 
-	// Eliminar datos existentes
-	if err := s.loanRepo.DeleteLoanDataByLoanID(request.LoanID); err != nil {
-		return errors.New("error al eliminar datos anteriores")
+// refreshLoanStatus recalcula las validaciones y el estado del préstamo después de guardar datos.
+// El score y la identidad solo se consultan de nuevo si cambió alguno de los datos que los alimentan.
+// El estado se guarda con la versión del préstamo validado antes de guardar los datos, por lo que
+// falla con conflicto si otra operación lo modificó mientras tanto
+func (s *loanService) refreshLoanStatus(ctx context.Context, validated *models.Loan, touchedKeys []string) error {
+	// Obtener el préstamo con todos sus datos ya guardados
+	loan, err := s.loanRepo.GetByID(ctx, validated.ID)
+	if err != nil {
+		return errors.New("error al obtener el préstamo")
 	}
+	loan.Version = validated.Version
 
-	// Preparar los nuevos datos
-	loanDataList := make([]models.LoanData, len(request.Data))
-	for i, dataItem := range request.Data {
-		loanDataList[i] = models.LoanData{
-			LoanID:    request.LoanID,
-			FormID:    dataItem.FormID,
-			Key:       dataItem.Key,
-			Value:     dataItem.Value,
-			Index:     dataItem.Index,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+	if touchesValidationData(touchedKeys) {
+		if err := s.runValidations(ctx, loan); err != nil {
+			return err
 		}
 	}
 
-	// Guardar los nuevos datos
-	if err := s.loanRepo.SaveLoanData(loanDataList); err != nil {
-		return errors.New("error al guardar los datos del préstamo")
+	// Usar las validaciones ya realizadas en guardados anteriores
+	var creditScore *int
+	if loan.CreditScore != nil && *loan.CreditScore > 0 {
+		creditScore = loan.CreditScore
+	}
+	var identityVerified *bool
+	if loan.IdentityMatch != "" {
+		identityVerified = loan.IdentityVerified
 	}
 
-	// DESPUÉS de guardar los datos, determinar el nuevo estado basado en la configuración real
-	newStatus, err := s.determineNewLoanStatusFromDB(request.LoanID, loan.LoanTypeID, creditScore, identityVerified)
+	// Determinar el nuevo estado basado en la configuración real
+	newStatus, err := s.determineNewLoanStatus(ctx, *loan, creditScore, identityVerified)
 	if err != nil {
 		return errors.New("error al determinar el estado del préstamo: " + err.Error())
 	}
@@ -160,27 +449,83 @@ func (s *loanService) SaveLoanData(request models.SaveLoanDataRequest) error {
 	loan.Observation = s.generateStatusObservation(newStatus, creditScore, identityVerified)
 
 	// Guardar los cambios finales
-	if err := s.loanRepo.Update(loan); err != nil {
+	if err := s.loanRepo.Update(ctx, loan); err != nil {
+		if errors.Is(err, app_error.ErrLoanConflict) {
+			return err
+		}
 		return errors.New("error al actualizar el estado del préstamo")
 	}
 
 	return nil
 }
 
+// runValidations consulta el score crediticio y verifica la identidad con los datos guardados del préstamo
+func (s *loanService) runValidations(ctx context.Context, loan *models.Loan) error {
+	documentType := s.extractStoredValue(*loan, "document_type")
+	documentNumber := s.extractStoredValue(*loan, "document_number")
+	fullName := s.extractStoredValue(*loan, "full_name")
+
+	if documentType == "" || documentNumber == "" {
+		return nil
+	}
+
+	// 1. Simulación del score crediticio
+	score, err := s.simulateCreditScore(ctx, documentType, documentNumber)
+	if err != nil {
+		return fmt.Errorf("error al consultar el score crediticio: %w", err)
+	}
+	loan.CreditScore = &score
+
+	// 2. Verificación de identidad
+	if fullName != "" {
+		match, err := s.verifyIdentity(ctx, loan.UserID, documentType, documentNumber, fullName)
+		if err != nil {
+			// Solo falla si hay errores técnicos (datos insuficientes, problemas de BD, etc.)
+			return fmt.Errorf("error al verificar la identidad: %w", err)
+		}
+		// Si no hay error técnico, usar el resultado de la verificación
+		verified := match == models.IdentityMatchFull
+		loan.IdentityVerified = &verified
+		loan.IdentityMatch = match
+	}
+
+	return nil
+}
+
+// touchesValidationData verifica si alguno de los campos modificados alimenta el score o la verificación de identidad
+func touchesValidationData(keys []string) bool {
+	for _, key := range keys {
+		if key == "document_type" || key == "document_number" || key == "full_name" {
+			return true
+		}
+	}
+	return false
+}
+
+// extractStoredValue obtiene el valor de un campo guardado en el préstamo
+func (s *loanService) extractStoredValue(loan models.Loan, key string) string {
+	for _, data := range loan.Data {
+		if data.Key == key {
+			return data.Value
+		}
+	}
+	return ""
+}
+
 // GetLoanByID obtiene un préstamo por ID
-func (s *loanService) GetLoanByID(id uint) (*models.LoanResponse, error) {
-	loan, err := s.loanRepo.GetByID(id)
+func (s *loanService) GetLoanByID(ctx context.Context, id uint) (*models.LoanResponse, error) {
+	loan, err := s.loanRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, errors.New("préstamo no encontrado")
 	}
 
 	// Obtener usuario y tipo de préstamo para la respuesta completa
-	user, err := s.userRepo.GetByID(loan.UserID)
+	user, err := s.userRepo.GetByID(ctx, loan.UserID)
 	if err != nil {
 		return nil, errors.New("usuario no encontrado")
 	}
 
-	loanType, err := s.loanTypeRepo.GetByIDWithForms(loan.LoanTypeID)
+	loanType, err := s.loanTypeRepo.GetByIDWithForms(ctx, loan.LoanTypeID)
 	if err != nil {
 		return nil, errors.New("tipo de préstamo no encontrado")
 	}
@@ -189,37 +534,90 @@ func (s *loanService) GetLoanByID(id uint) (*models.LoanResponse, error) {
 	return &response, nil
 }
 
-// GetLoansByUserID obtiene todos los préstamos de un usuario
-func (s *loanService) GetLoansByUserID(userID uint) ([]models.LoanResponse, error) {
-	loans, err := s.loanRepo.GetByUserID(userID)
+// GetLoansByUserID obtiene una página de los préstamos de un usuario y el total que cumple los filtros
+func (s *loanService) GetLoansByUserID(ctx context.Context, opts models.LoanQueryOptions) ([]models.LoanResponse, int64, error) {
+	loans, total, err := s.loanRepo.GetByUserID(ctx, opts)
 	if err != nil {
-		return nil, errors.New("error al obtener préstamos del usuario")
+		return nil, 0, errors.New("error al obtener préstamos del usuario")
+	}
+
+	// Cargar en lote los tipos de préstamo de la página
+	loanTypeIDs := make([]uint, 0, len(loans))
+	seen := make(map[uint]bool, len(loans))
+	for _, loan := range loans {
+		if !seen[loan.LoanTypeID] {
+			seen[loan.LoanTypeID] = true
+			loanTypeIDs = append(loanTypeIDs, loan.LoanTypeID)
+		}
 	}
 
-	// Obtener usuario una sola vez
-	user, err := s.userRepo.GetByID(userID)
+	loanTypes, err := s.loanTypeRepo.GetByIDsWithForms(ctx, loanTypeIDs)
 	if err != nil {
-		return nil, errors.New("usuario no encontrado")
+		return nil, 0, errors.New("error al obtener los tipos de préstamo")
+	}
+	loanTypesByID := make(map[uint]models.LoanType, len(loanTypes))
+	for _, loanType := range loanTypes {
+		loanTypesByID[loanType.ID] = loanType
 	}
 
-	response := make([]models.LoanResponse, len(loans))
-	for i, loan := range loans {
-		// Obtener tipo de préstamo para cada préstamo
-		loanType, err := s.loanTypeRepo.GetByIDWithForms(loan.LoanTypeID)
-		if err != nil {
+	response := make([]models.LoanResponse, 0, len(loans))
+	for _, loan := range loans {
+		loanType, found := loanTypesByID[loan.LoanTypeID]
+		if !found {
 			continue // Saltar préstamos con tipos no encontrados
 		}
 
-		response[i] = s.buildLoanResponse(loan, *user, *loanType)
+		// En los préstamos donde participa como codeudor o garante se muestra el solicitante principal
+		loanResponse := s.buildLoanResponse(loan, loan.User, loanType)
+		loanResponse.Role = loanPartyRole(loan, opts.UserID)
+		response = append(response, loanResponse)
 	}
 
-	return response, nil
+	return response, total, nil
+}
+
+// SearchLoans busca préstamos del tenant para el back-office y obtiene el cursor de la siguiente página,
+// que es 0 cuando no hay más resultados
+func (s *loanService) SearchLoans(ctx context.Context, filter models.LoanSearchFilter) ([]models.LoanSearchItem, uint, error) {
+	// Se consulta un elemento adicional para saber si existe una página siguiente
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	items, err := s.loanRepo.Search(ctx, filter)
+	if err != nil {
+		return nil, 0, app_error.NewDatabaseError("buscar préstamos", err.Error())
+	}
+
+	var nextCursor uint
+	if len(items) > limit {
+		items = items[:limit]
+		nextCursor = items[limit-1].ID
+	}
+	if items == nil {
+		items = []models.LoanSearchItem{}
+	}
+
+	return items, nextCursor, nil
+}
+
+// providerTimeout es el tiempo máximo de una llamada a un proveedor externo (buró, identidad, desembolso)
+const providerTimeout = 10 * time.Second
+
+// withProviderDeadline limita una llamada a un proveedor externo al plazo de la solicitud
+// o a providerTimeout, el que venza primero
+func withProviderDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, providerTimeout)
 }
 
 // simulateCreditScore simula la consulta de score crediticio basado en el tipo y número de documento
-func (s *loanService) simulateCreditScore(documentType, documentNumber string) (int, error) {
+func (s *loanService) simulateCreditScore(ctx context.Context, documentType, documentNumber string) (int, error) {
 	// Simulación basada en el número de documento para tener resultados consistentes
-	// En un escenario real, esto sería una llamada a un servicio externo
+	// En un escenario real, esto sería una llamada a un servicio externo con ctx
+	ctx, cancel := withProviderDeadline(ctx)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
 
 	// Generar un score basado en el hash del número de documento para consistencia
 	score := 300 // Score mínimo
@@ -258,66 +656,61 @@ func (s *loanService) simulateCreditScore(documentType, documentNumber string) (
 }
 
 // verifyIdentity verifica la identidad del solicitante comparando con los datos del registro
-func (s *loanService) verifyIdentity(userID uint, documentType, documentNumber, fullName string) (bool, error) {
+func (s *loanService) verifyIdentity(ctx context.Context, userID uint, documentType, documentNumber, fullName string) (models.IdentityMatch, error) {
+	ctx, cancel := withProviderDeadline(ctx)
+	defer cancel()
+
 	// Validaciones básicas - error técnico si faltan datos
 	if documentType == "" || documentNumber == "" || fullName == "" {
-		return false, errors.New("datos insuficientes para verificación de identidad")
+		return models.IdentityMatchNone, errors.New("datos insuficientes para verificación de identidad")
 	}
 
 	// Obtener los datos del usuario registrado - error técnico si no se puede obtener
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return false, errors.New("error al obtener datos del usuario registrado")
+		return models.IdentityMatchNone, fmt.Errorf("error al obtener datos del usuario registrado: %w", err)
 	}
 
 	// Las siguientes son verificaciones de identidad, no errores técnicos
-	// Si fallan, retornan false pero no error
+	// Si fallan, retornan sin coincidencia pero no error
 
 	// Verificar que el tipo de documento coincida
 	if string(user.DocumentType) != documentType {
-		return false, nil // Fallo de verificación: tipo de documento no coincide
+		return models.IdentityMatchNone, nil // Fallo de verificación: tipo de documento no coincide
 	}
 
 	// Verificar que el número de documento coincida
 	if user.DocumentNumber != documentNumber {
-		return false, nil // Fallo de verificación: número de documento no coincide
+		return models.IdentityMatchNone, nil // Fallo de verificación: número de documento no coincide
 	}
 
 	// Verificar que el nombre del registro esté contenido en el nombre completo
 	userNameNormalized := strings.ToLower(strings.TrimSpace(user.Name))
 	inputNameNormalized := strings.ToLower(strings.TrimSpace(fullName))
 
-	if !strings.Contains(inputNameNormalized, userNameNormalized) {
-		return false, nil // Fallo de verificación: nombre no está contenido
+	if strings.Contains(inputNameNormalized, userNameNormalized) {
+		// Todas las verificaciones pasaron exitosamente
+		return models.IdentityMatchFull, nil
 	}
 
-	// Todas las verificaciones pasaron exitosamente
-	return true, nil
-}
-
-// extractLoanDataValue extrae un valor específico de los datos del préstamo
-func (s *loanService) extractLoanDataValue(data []models.LoanDataItemRequest, key string) string {
-	for _, item := range data {
-		if item.Key == key {
-			return item.Value
+	// Coincidencia parcial: el documento coincide y al menos una palabra del nombre registrado está presente
+	inputWords := strings.Fields(inputNameNormalized)
+	for _, word := range strings.Fields(userNameNormalized) {
+		for _, inputWord := range inputWords {
+			if word == inputWord {
+				return models.IdentityMatchPartial, nil
+			}
 		}
 	}
-	return ""
+
+	return models.IdentityMatchNone, nil // Fallo de verificación: nombre no está contenido
 }
 
 // buildLoanResponse construye la respuesta del préstamo
 func (s *loanService) buildLoanResponse(loan models.Loan, user models.User, loanType models.LoanType) models.LoanResponse {
-	// Construir datos del préstamo
-	dataResponse := make([]models.LoanDataResponse, len(loan.Data))
-	for i, data := range loan.Data {
-		dataResponse[i] = models.LoanDataResponse{
-			ID:     data.ID,
-			FormID: data.FormID,
-			Key:    data.Key,
-			Value:  data.Value,
-			Index:  data.Index,
-		}
-	}
+	// Construir datos del préstamo, agrupando los formularios repetibles
+	forms := defaultVersionForms(loanType)
+	dataResponse, groups := buildLoanDataResponse(loan.Data, forms)
 
 	// Construir respuesta del usuario
 	userResponse := models.UserResponse{
@@ -342,32 +735,44 @@ func (s *loanService) buildLoanResponse(loan models.Loan, user models.User, loan
 	}
 
 	return models.LoanResponse{
-		ID:               loan.ID,
-		LoanTypeID:       loan.LoanTypeID,
-		LoanType:         loanTypeResponse,
-		UserID:           loan.UserID,
-		User:             userResponse,
-		Status:           loan.Status,
-		Observation:      loan.Observation,
-		AmountApproved:   loan.AmountApproved,
-		CreditScore:      loan.CreditScore,
-		IdentityVerified: loan.IdentityVerified,
-		Data:             dataResponse,
-		CreatedAt:        loan.CreatedAt,
-		UpdatedAt:        loan.UpdatedAt,
+		ID:                 loan.ID,
+		LoanTypeID:         loan.LoanTypeID,
+		LoanType:           loanTypeResponse,
+		UserID:             loan.UserID,
+		User:               userResponse,
+		Status:             loan.Status,
+		Observation:        loan.Observation,
+		AmountApproved:     loan.AmountApproved,
+		CreditScore:        loan.CreditScore,
+		IdentityVerified:   loan.IdentityVerified,
+		IdentityMatch:      loan.IdentityMatch,
+		Affordability:      loan.GetAffordability(),
+		Pricing:            loan.GetPricing(),
+		CancelledBy:        loan.CancelledBy,
+		CancelledByRole:    loan.CancelledByRole,
+		CancelledAt:        loan.CancelledAt,
+		CancellationReason: loan.CancellationReason,
+		Fields:             buildLoanFields(loan.Data, forms),
+		Data:               dataResponse,
+		Groups:             groups,
+		Offers:             models.BuildLoanOffersResponse(loan.Offers),
+		Parties:            models.BuildLoanPartiesResponse(loan.Parties),
+		CreatedAt:          loan.CreatedAt,
+		UpdatedAt:          loan.UpdatedAt,
 	}
 }
 
 // ProcessLoanDecision evalúa el préstamo y toma la decisión final de aprobación/rechazo
-func (s *loanService) ProcessLoanDecision(loanID uint) (*models.LoanResponse, error) {
-	loan, err := s.loanRepo.GetByID(loanID)
+func (s *loanService) ProcessLoanDecision(ctx context.Context, loanID uint) (*models.LoanResponse, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
 	if err != nil {
 		return nil, errors.New("préstamo no encontrado")
 	}
 
-	// Validar que el préstamo esté en estado completed (listo para evaluación)
-	if loan.Status != "completed" {
-		return nil, errors.New("solo se pueden evaluar préstamos en estado completado")
+	// Validar que el préstamo esté en estado completed (listo para evaluación). Una decisión concurrente
+	// que ya lo cambió de estado también termina aquí
+	if loan.Status != string(models.LoanStatusCompleted) {
+		return nil, app_error.ErrLoanNotDecidable
 	}
 
 	// Validar que se hayan completado las validaciones previas
@@ -379,51 +784,94 @@ func (s *loanService) ProcessLoanDecision(loanID uint) (*models.LoanResponse, er
 		return nil, errors.New("el préstamo debe tener la verificación de identidad procesada")
 	}
 
+	// Obtener la configuración de la versión por defecto del tipo de préstamo
+	versionConfig, err := s.getDefaultVersionConfig(ctx, loan.LoanTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los codeudores y garantes invitados deben haber respondido antes de la decisión
+	if err := checkLoanParties(loan.Parties, versionConfig.Parties); err != nil {
+		return nil, err
+	}
+
 	// Obtener información adicional necesaria
 	requestedAmount := s.extractLoanDataFromLoan(*loan, "requested_amount")
 	monthlyIncome := s.extractLoanDataFromLoan(*loan, "monthly_income")
+	monthlyExpenses := s.extractLoanDataFromLoan(*loan, "monthly_expenses")
+	monthlyIncome, monthlyExpenses = combinePartyIncomes(loan.Parties, versionConfig.Parties, monthlyIncome, monthlyExpenses)
 
-	// Aplicar reglas de negocio para la decisión
-	decision, reason := s.evaluateLoanApproval(*loan.CreditScore, *loan.IdentityVerified, requestedAmount, monthlyIncome)
-
-	// Actualizar el estado del préstamo
-	loan.Status = decision
-	loan.Observation = reason
+	// Calcular la capacidad de pago y guardar el desglose con el préstamo
+	affordability := calculateAffordability(monthlyIncome, monthlyExpenses, requestedAmount, versionConfig)
+	if err := loan.SetAffordability(affordability); err != nil {
+		return nil, errors.New("error al guardar el cálculo de capacidad de pago")
+	}
 
-	// Si es aprobado, calcular monto aprobado y simular desembolso
-	if decision == "approved" {
-		approvedAmount := s.calculateApprovedAmount(requestedAmount, decimal.NewFromInt(int64(*loan.CreditScore)), monthlyIncome)
-		loan.AmountApproved = approvedAmount
+	// Las contraofertas y el estado final se confirman juntos o no se confirma nada
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Reservar el préstamo guardando el cálculo de capacidad de pago antes de llamar a los proveedores:
+		// si otra decisión concurrente ya lo modificó falla aquí con conflicto, sin desembolsar dos veces
+		if err := s.loanRepo.Update(ctx, loan); err != nil {
+			if errors.Is(err, app_error.ErrLoanConflict) {
+				return err
+			}
+			return errors.New("error al actualizar el estado del préstamo")
+		}
 
-		// Simular desembolso
-		disbursementSuccess := s.simulateDisbursement(loan.UserID, approvedAmount)
-		if !disbursementSuccess {
-			// Si falla el desembolso, rechazar el préstamo
-			loan.Status = "rejected"
-			loan.Observation = "Préstamo aprobado pero falló el desembolso. Contacte soporte."
+		// Verificar si la solicitud requiere revisión manual antes de la decisión automática
+		if needsReview, reviewReason := s.requiresManualReview(*loan, requestedAmount, versionConfig.ManualReview); needsReview {
+			loan.Status = string(models.LoanStatusManualReview)
+			loan.Observation = reviewReason
+		} else if partiesOK, partiesReason := evaluateLoanParties(loan.Parties, versionConfig.Parties); !partiesOK {
+			loan.Status = string(models.LoanStatusRejected)
+			loan.Observation = partiesReason
 		} else {
-			loan.Observation += " - Desembolso realizado exitosamente"
+			// Aplicar reglas de negocio para la decisión
+			decision, reason := s.evaluateLoanApproval(*loan.CreditScore, *loan.IdentityVerified, requestedAmount, affordability)
+
+			// Actualizar el estado del préstamo
+			loan.Status = decision
+			loan.Observation = reason
+
+			// Si es aprobado, aprobar el monto solicitado para desembolso o generar contraofertas si excede la capacidad de pago
+			if decision == "approved" {
+				if err := s.approveOrOffer(ctx, loan, requestedAmount, affordability, versionConfig.Offers); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Guardar los cambios
+		if err := s.loanRepo.Update(ctx, loan); err != nil {
+			if errors.Is(err, app_error.ErrLoanConflict) {
+				return err
+			}
+			return errors.New("error al actualizar el estado del préstamo")
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Guardar los cambios
-	if err := s.loanRepo.Update(loan); err != nil {
-		return nil, errors.New("error al actualizar el estado del préstamo")
+	// El desembolso se realiza con el estado ya confirmado, sin mantener la transacción abierta
+	if err := s.disburse(ctx, loan); err != nil {
+		return nil, err
 	}
 
 	// Obtener préstamo actualizado para la respuesta
-	updatedLoan, err := s.loanRepo.GetByID(loanID)
+	updatedLoan, err := s.loanRepo.GetByID(ctx, loanID)
 	if err != nil {
 		return nil, errors.New("error al obtener el préstamo actualizado")
 	}
 
 	// Construir respuesta
-	user, err := s.userRepo.GetByID(updatedLoan.UserID)
+	user, err := s.userRepo.GetByID(ctx, updatedLoan.UserID)
 	if err != nil {
 		return nil, errors.New("error al obtener usuario")
 	}
 
-	loanType, err := s.loanTypeRepo.GetByIDWithForms(updatedLoan.LoanTypeID)
+	loanType, err := s.loanTypeRepo.GetByIDWithForms(ctx, updatedLoan.LoanTypeID)
 	if err != nil {
 		return nil, errors.New("error al obtener tipo de préstamo")
 	}
@@ -443,8 +891,8 @@ func (s *loanService) extractLoanDataFromLoan(loan models.Loan, key string) deci
 	return decimal.NewFromFloat(0)
 }
 
-// evaluateLoanApproval evalúa la aprobación del préstamo basado en el score y la verificación de identidad
-func (s *loanService) evaluateLoanApproval(creditScore int, identityVerified bool, requestedAmount, monthlyIncome decimal.Decimal) (string, string) {
+// evaluateLoanApproval evalúa la aprobación del préstamo basado en el score, la verificación de identidad y la capacidad de pago
+func (s *loanService) evaluateLoanApproval(creditScore int, identityVerified bool, requestedAmount decimal.Decimal, affordability models.AffordabilityBreakdown) (string, string) {
 	// 1. Verificar que la identidad esté verificada (requisito obligatorio)
 	if !identityVerified {
 		return "rejected", "Préstamo rechazado: verificación de identidad fallida"
@@ -455,18 +903,22 @@ func (s *loanService) evaluateLoanApproval(creditScore int, identityVerified boo
 		return "rejected", "Préstamo rechazado: score crediticio muy bajo (" + strconv.Itoa(creditScore) + ")"
 	}
 
-	// 3. Verificar capacidad de pago (máximo 50% del ingreso mensual)
-	maxAmount := monthlyIncome.Mul(decimal.NewFromFloat(0.5))
-	if requestedAmount.GreaterThan(maxAmount) && creditScore < 650 {
+	// 3. Verificar que exista ingreso disponible para cubrir una cuota
+	if affordability.MaxMonthlyPayment.LessThanOrEqual(decimal.Zero) {
+		return "rejected", "Préstamo rechazado: sin capacidad de pago (ingreso disponible " + affordability.DisposableIncome.StringFixed(2) + ")"
+	}
+
+	// 4. Verificar capacidad de pago (monto máximo financiable según ingresos y gastos)
+	if requestedAmount.GreaterThan(affordability.MaxPrincipal) && creditScore < 650 {
 		return "rejected", "Préstamo rechazado: monto solicitado excede capacidad de pago y score insuficiente"
 	}
 
-	// 4. Verificar monto mínimo
-	if requestedAmount.LessThan(decimal.NewFromFloat(100000)) {
+	// 5. Verificar monto mínimo
+	if requestedAmount.LessThan(decimal.NewFromFloat(minLoanAmount)) {
 		return "rejected", "Préstamo rechazado: monto mínimo no alcanzado"
 	}
 
-	// 5. Evaluación por rangos de score
+	// 6. Evaluación por rangos de score
 	var reason string
 	switch {
 	case creditScore >= 700:
@@ -483,73 +935,499 @@ func (s *loanService) evaluateLoanApproval(creditScore int, identityVerified boo
 }
 
 // calculateApprovedAmount calcula el monto aprobado del préstamo
-func (s *loanService) calculateApprovedAmount(requestedAmount, creditScore, monthlyIncome decimal.Decimal) decimal.Decimal {
-	// Implementa la lógica para calcular el monto aprobado del préstamo basado en el score y el ingreso mensual
-	// Este es un ejemplo básico, puedes implementar una lógica más robusta basada en tus reglas de negocio
-
-	// Ejemplo: Si el monto solicitado es menor o igual al 50% del ingreso mensual, aprobar el monto completo
-	maxAmount := monthlyIncome.Mul(decimal.NewFromFloat(0.5))
-	if requestedAmount.LessThanOrEqual(maxAmount) {
+func (s *loanService) calculateApprovedAmount(requestedAmount decimal.Decimal, affordability models.AffordabilityBreakdown) decimal.Decimal {
+	// Si el monto solicitado cabe dentro del capital máximo financiable, aprobar el monto completo
+	if requestedAmount.LessThanOrEqual(affordability.MaxPrincipal) {
 		return requestedAmount
 	}
 
-	// Si el monto solicitado excede el 50% del ingreso, aprobar solo el monto máximo
-	return maxAmount
+	// Si el monto solicitado excede la capacidad de pago, aprobar solo el capital máximo
+	return affordability.MaxPrincipal
+}
+
+// approve fija la tarifa del préstamo y lo aprueba por el monto indicado. El préstamo queda en
+// desembolso: disburse llama al proveedor una vez confirmado ese estado
+func (s *loanService) approve(ctx context.Context, loan *models.Loan, approvedAmount decimal.Decimal, termMonths int) error {
+	priced, err := s.priceLoan(ctx, loan, approvedAmount, termMonths)
+	if err != nil {
+		return err
+	}
+	if !priced {
+		loan.Status = "rejected"
+		loan.Observation = "Préstamo rechazado: no existe un tramo de tarifa para el perfil de riesgo"
+		return nil
+	}
+
+	loan.Status = string(models.LoanStatusDisbursing)
+	loan.AmountApproved = approvedAmount
+	return nil
+}
+
+// disburse realiza el desembolso de un préstamo cuyo estado en desembolso ya fue guardado y registra
+// el resultado. Debe llamarse fuera de transacciones: si el resultado no se puede guardar, el préstamo
+// permanece en desembolso y no puede volver a decidirse ni desembolsarse. Si el proveedor no responde,
+// el préstamo vuelve a completado para que la decisión pueda reintentarse
+func (s *loanService) disburse(ctx context.Context, loan *models.Loan) error {
+	if loan.Status != string(models.LoanStatusDisbursing) {
+		return nil
+	}
+
+	disbursementSuccess, err := s.disbursement.Disburse(ctx, loan.UserID, loan.AmountApproved)
+	if err != nil {
+		return s.releaseDisbursement(ctx, loan, err)
+	}
+	if !disbursementSuccess {
+		// Si falla el desembolso, rechazar el préstamo
+		loan.Status = string(models.LoanStatusRejected)
+		loan.Observation = "Préstamo aprobado pero falló el desembolso. Contacte soporte."
+	} else {
+		loan.Status = string(models.LoanStatusApproved)
+		loan.Observation += " - Desembolso realizado exitosamente"
+	}
+
+	if err := s.loanRepo.Update(ctx, loan); err != nil {
+		if errors.Is(err, app_error.ErrLoanConflict) {
+			return err
+		}
+		return errors.New("error al registrar el resultado del desembolso")
+	}
+	return nil
+}
+
+// releaseDisbursement devuelve a completado un préstamo cuyo desembolso no se pudo realizar por un error
+// del proveedor. Se guarda aunque la solicitud haya vencido, ya que el plazo suele ser la causa del error
+func (s *loanService) releaseDisbursement(ctx context.Context, loan *models.Loan, providerErr error) error {
+	loan.Status = string(models.LoanStatusCompleted)
+	loan.AmountApproved = decimal.Zero
+	loan.Observation = "No se pudo realizar el desembolso con el proveedor; la decisión puede reintentarse"
+
+	if err := s.loanRepo.Update(context.WithoutCancel(ctx), loan); err != nil {
+		return fmt.Errorf("error al realizar el desembolso: %w; no se pudo liberar el préstamo: %v", providerErr, err)
+	}
+	return app_error.NewAppError(app_error.ErrDisbursementFailed.Code, app_error.ErrDisbursementFailed.Message, providerErr.Error())
+}
+
+// priceLoan selecciona el tramo de riesgo y congela la tarifa en el préstamo.
+// Retorna false si la versión define tramos y ninguno aplica
+func (s *loanService) priceLoan(ctx context.Context, loan *models.Loan, amount decimal.Decimal, termMonths int) (bool, error) {
+	pricing, priced, err := s.quoteLoan(ctx, *loan, amount, termMonths)
+	if err != nil || pricing == nil {
+		return priced, err
+	}
+
+	if err := loan.SetPricing(*pricing); err != nil {
+		return false, errors.New("error al guardar la tarifa del préstamo")
+	}
+	return true, nil
+}
+
+// quoteLoan calcula, sin guardarla, la tarifa del tramo de riesgo que aplica al préstamo por el monto y
+// plazo indicados. Retorna nil si la versión no define tramos y false si los define y ninguno aplica
+func (s *loanService) quoteLoan(ctx context.Context, loan models.Loan, amount decimal.Decimal, termMonths int) (*models.LoanPricing, bool, error) {
+	versionConfig, err := s.getDefaultVersionConfig(ctx, loan.LoanTypeID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Sin tramos configurados el préstamo no lleva tarifa
+	if len(versionConfig.Pricing.Tiers) == 0 {
+		return nil, true, nil
+	}
+
+	if termMonths <= 0 {
+		termMonths = defaultTermMonths
+	}
+
+	score := 0
+	if loan.CreditScore != nil {
+		score = *loan.CreditScore
+	}
+
+	tier := selectPricingTier(versionConfig.Pricing.Tiers, score, amount, termMonths)
+	if tier == nil {
+		return nil, false, nil
+	}
+
+	tenantConfig, err := s.getTenantConfig(ctx, loan.LoanType.TenantID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	pricing := calculatePricing(*tier, amount, termMonths, tenantConfig.UsuryRateEA)
+	return &pricing, true, nil
+}
+
+// approveOrOffer aprueba el monto solicitado si cabe en la capacidad de pago, o genera contraofertas en caso contrario
+func (s *loanService) approveOrOffer(ctx context.Context, loan *models.Loan, requestedAmount decimal.Decimal, affordability models.AffordabilityBreakdown, cfg models.OffersConfig) error {
+	approvedAmount := s.calculateApprovedAmount(requestedAmount, affordability)
+	if approvedAmount.GreaterThanOrEqual(requestedAmount) {
+		return s.approve(ctx, loan, approvedAmount, affordability.TermMonths)
+	}
+
+	// El monto solicitado excede la capacidad de pago: ofrecer montos menores en lugar de aprobarlos sin consentimiento
+	offers, err := s.buildOffers(ctx, *loan, requestedAmount, affordability, cfg)
+	if err != nil {
+		return err
+	}
+	if len(offers) == 0 {
+		loan.Status = "rejected"
+		loan.Observation = "Préstamo rechazado: la capacidad de pago no alcanza el monto mínimo"
+		return nil
+	}
+
+	expiresAt, err := s.getOfferExpiration(ctx, loan.LoanType.TenantID)
+	if err != nil {
+		return err
+	}
+	for i := range offers {
+		offers[i].ExpiresAt = expiresAt
+	}
+
+	if err := s.loanRepo.CreateOffers(ctx, offers); err != nil {
+		return errors.New("error al guardar las contraofertas")
+	}
+
+	loan.Status = string(models.LoanStatusOfferPending)
+	loan.Observation = loan.Observation + " - Monto solicitado excede la capacidad de pago, se generaron " + strconv.Itoa(len(offers)) + " contraofertas"
+	return nil
+}
+
+// buildOffers genera una contraoferta por cada plazo configurado con el capital máximo financiable. La cuota
+// ofrecida es la que se cobrará al aceptarla: la del tramo de riesgo que aplica al monto y plazo, con seguro
+func (s *loanService) buildOffers(ctx context.Context, loan models.Loan, requestedAmount decimal.Decimal, affordability models.AffordabilityBreakdown, cfg models.OffersConfig) ([]models.LoanOffer, error) {
+	terms := cfg.Terms
+	if len(terms) == 0 {
+		terms = []int{affordability.TermMonths}
+	}
+
+	monthlyRate := affordability.AnnualRate.Div(decimal.NewFromInt(12))
+	seen := make(map[string]bool)
+
+	var offers []models.LoanOffer
+	for _, term := range terms {
+		amount := decimal.Min(presentValue(affordability.MaxMonthlyPayment, monthlyRate, term), requestedAmount)
+		if amount.LessThan(decimal.NewFromFloat(minLoanAmount)) || seen[amount.String()] {
+			continue
+		}
+
+		pricing, priced, err := s.quoteLoan(ctx, loan, amount, term)
+		if err != nil {
+			return nil, err
+		}
+		// Sin tramo para el monto y plazo la aceptación se rechazaría: no se ofrece
+		if !priced {
+			continue
+		}
+		payment := monthlyPayment(amount, monthlyRate, term)
+		if pricing != nil {
+			payment = pricing.TotalMonthlyPayment
+		}
+		seen[amount.String()] = true
+
+		offers = append(offers, models.LoanOffer{
+			LoanID:         loan.ID,
+			Amount:         amount,
+			TermMonths:     term,
+			MonthlyPayment: payment,
+			Status:         models.LoanOfferStatusPending,
+		})
+	}
+	return offers, nil
+}
+
+// getOfferExpiration calcula el vencimiento de las contraofertas según la configuración del tenant
+func (s *loanService) getOfferExpiration(ctx context.Context, tenantID uint) (time.Time, error) {
+	cfg, err := s.getTenantConfig(ctx, tenantID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	hours := defaultOfferExpirationHours
+	if cfg.OfferExpirationHours > 0 {
+		hours = cfg.OfferExpirationHours
+	}
+
+	return time.Now().Add(time.Duration(hours) * time.Hour), nil
+}
+
+// getTenantConfig obtiene la configuración del tenant
+func (s *loanService) getTenantConfig(ctx context.Context, tenantID uint) (models.TenantConfig, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return models.TenantConfig{}, errors.New("tenant no encontrado")
+	}
+
+	cfg, err := tenant.ParseConfig()
+	if err != nil {
+		return models.TenantConfig{}, errors.New("configuración inválida del tenant")
+	}
+	return cfg, nil
+}
+
+// AcceptOffer acepta una contraoferta del solicitante y procede al desembolso
+func (s *loanService) AcceptOffer(ctx context.Context, userID, loanID, offerID uint) (*models.LoanResponse, error) {
+	loan, offer, err := s.getPendingOffer(ctx, userID, loanID, offerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// La respuesta a las contraofertas y la aprobación se confirman juntas: si el préstamo cambió
+	// (por ejemplo, expiró) la versión no coincide y no se confirma nada
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		offer.Status = models.LoanOfferStatusAccepted
+		offer.RespondedAt = &now
+		if err := s.loanRepo.UpdateOffer(ctx, offer); err != nil {
+			return app_error.NewDatabaseError("aceptar contraoferta", err.Error())
+		}
+
+		// Las demás contraofertas quedan descartadas
+		if err := s.loanRepo.DeclinePendingOffers(ctx, loanID, offerID, now); err != nil {
+			return app_error.NewDatabaseError("descartar contraofertas", err.Error())
+		}
+
+		loan.Offers = nil
+		loan.Observation = "Contraoferta aceptada por el solicitante: " + offer.Amount.StringFixed(2) + " a " + strconv.Itoa(offer.TermMonths) + " meses"
+		if err := s.approve(ctx, loan, offer.Amount, offer.TermMonths); err != nil {
+			return err
+		}
+
+		if err := s.loanRepo.Update(ctx, loan); err != nil {
+			if errors.Is(err, app_error.ErrLoanConflict) {
+				return err
+			}
+			return app_error.NewDatabaseError("actualizar préstamo", err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// El desembolso se realiza con la aceptación ya confirmada, sin mantener la transacción abierta
+	if err := s.disburse(ctx, loan); err != nil {
+		return nil, err
+	}
+
+	return s.GetLoanByID(ctx, loanID)
 }
 
-// simulateDisbursement simula el desembolso del préstamo
-func (s *loanService) simulateDisbursement(userID uint, amount decimal.Decimal) bool {
-	// Simulación de desembolso más realista
-	// En un escenario real, esto sería una llamada a un servicio de pagos/bancario
+// DeclineOffer rechaza una contraoferta; si no quedan contraofertas vigentes, el préstamo se rechaza
+func (s *loanService) DeclineOffer(ctx context.Context, userID, loanID, offerID uint) (*models.LoanResponse, error) {
+	loan, offer, err := s.getPendingOffer(ctx, userID, loanID, offerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// La respuesta y el rechazo del préstamo se confirman juntos
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		offer.Status = models.LoanOfferStatusDeclined
+		offer.RespondedAt = &now
+		if err := s.loanRepo.UpdateOffer(ctx, offer); err != nil {
+			return app_error.NewDatabaseError("rechazar contraoferta", err.Error())
+		}
+
+		// Verificar si quedan otras contraofertas vigentes
+		for _, other := range loan.Offers {
+			if other.ID != offerID && other.Status == models.LoanOfferStatusPending && !other.IsExpired(now) {
+				return nil
+			}
+		}
 
-	// 1. Verificar que el monto sea válido
-	if amount.LessThanOrEqual(decimal.NewFromFloat(0)) {
-		return false // Falla: monto inválido
+		loan.Offers = nil
+		loan.Status = "rejected"
+		loan.Observation = "Préstamo rechazado: el solicitante no aceptó las contraofertas"
+		if err := s.loanRepo.Update(ctx, loan); err != nil {
+			if errors.Is(err, app_error.ErrLoanConflict) {
+				return err
+			}
+			return app_error.NewDatabaseError("actualizar préstamo", err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 2. Simulación basada en el ID del usuario para consistencia en pruebas
-	userIDStr := strconv.Itoa(int(userID))
-	lastDigit := userIDStr[len(userIDStr)-1:]
+	return s.GetLoanByID(ctx, loanID)
+}
+
+// CancelLoan cancela un préstamo a solicitud de su propietario
+func (s *loanService) CancelLoan(ctx context.Context, userID, loanID uint, reason string) (*models.LoanResponse, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, app_error.ErrLoanNotFound
+	}
 
-	// 3. Simular fallos de desembolso ocasionales (10% de probabilidad)
-	if lastDigit == "0" {
-		return false // Simular falla del sistema bancario
+	if loan.UserID != userID {
+		return nil, app_error.ErrForbidden
 	}
 
-	// 4. Simular límites de desembolso diario
-	if amount.GreaterThan(decimal.NewFromFloat(50000000)) { // 50 millones
-		return false // Excede límite diario de desembolso
+	if err := s.cancel(ctx, loan, userID, models.CancellerRoleApplicant, reason, "Préstamo cancelado por el solicitante: "); err != nil {
+		return nil, err
 	}
 
-	// 5. Desembolso exitoso para todos los otros casos
-	// En un escenario real, aquí se haría:
-	// - Llamada al API bancario
-	// - Registro de la transacción
-	// - Notificación al usuario
-	return true
+	return s.GetLoanByID(ctx, loanID)
 }
 
-// determineNewLoanStatusFromDB determina el nuevo estado del préstamo basado en la configuración real de formularios
-func (s *loanService) determineNewLoanStatusFromDB(loanID, loanTypeID uint, creditScore *int, identityVerified *bool) (string, error) {
-	// Obtener el préstamo actualizado con todos sus datos
-	loan, err := s.loanRepo.GetByID(loanID)
+// CancelLoanAsStaff cancela un préstamo del tenant registrando el miembro del staff que lo cancela
+func (s *loanService) CancelLoanAsStaff(ctx context.Context, tenantID, loanID, staffID uint, reason string) (*models.LoanResponse, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
 	if err != nil {
-		return "", errors.New("error al obtener el préstamo")
+		return nil, app_error.ErrLoanNotFound
+	}
+
+	if loan.LoanType.TenantID != tenantID {
+		return nil, app_error.ErrLoanNotFound
+	}
+
+	if err := s.cancel(ctx, loan, staffID, models.CancellerRoleStaff, reason, "Préstamo cancelado por el staff: "); err != nil {
+		return nil, err
+	}
+
+	return s.GetLoanByID(ctx, loanID)
+}
+
+// cancel valida el estado del préstamo y lo marca como cancelado, descartando contraofertas y asignaciones vigentes
+func (s *loanService) cancel(ctx context.Context, loan *models.Loan, cancelledBy uint, role models.CancellerRole, reason, observationPrefix string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return app_error.ErrCancelReasonEmpty
+	}
+
+	if !models.LoanStatus(loan.Status).IsCancellable() {
+		return app_error.ErrLoanNotCancellable
+	}
+
+	now := time.Now()
+	if loan.Status == string(models.LoanStatusOfferPending) {
+		if err := s.loanRepo.DeclinePendingOffers(ctx, loan.ID, 0, now); err != nil {
+			return app_error.NewDatabaseError("descartar contraofertas", err.Error())
+		}
 	}
 
+	loan.Offers = nil
+	loan.Status = string(models.LoanStatusCancelled)
+	loan.Observation = observationPrefix + reason
+	loan.CancelledBy = &cancelledBy
+	loan.CancelledByRole = role
+	loan.CancelledAt = &now
+	loan.CancellationReason = reason
+	loan.ReviewClaimedBy = nil
+	loan.ReviewClaimedAt = nil
+	loan.ReviewClaimExpiresAt = nil
+
+	if err := s.loanRepo.Update(ctx, loan); err != nil {
+		if errors.Is(err, app_error.ErrLoanConflict) {
+			return err
+		}
+		return app_error.NewDatabaseError("cancelar préstamo", err.Error())
+	}
+	return nil
+}
+
+// getPendingOffer obtiene una contraoferta vigente de un préstamo del solicitante
+func (s *loanService) getPendingOffer(ctx context.Context, userID, loanID, offerID uint) (*models.Loan, *models.LoanOffer, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, nil, app_error.ErrLoanNotFound
+	}
+
+	if loan.UserID != userID {
+		return nil, nil, app_error.ErrForbidden
+	}
+
+	if loan.Status != string(models.LoanStatusOfferPending) {
+		return nil, nil, app_error.NewBusinessError("Estado de préstamo inválido", "el préstamo no tiene contraofertas pendientes")
+	}
+
+	offer, err := s.loanRepo.GetOfferByID(ctx, loanID, offerID)
+	if err != nil {
+		return nil, nil, app_error.ErrOfferNotFound
+	}
+
+	if offer.Status != models.LoanOfferStatusPending {
+		return nil, nil, app_error.ErrOfferNotPending
+	}
+
+	if offer.IsExpired(time.Now()) {
+		offer.Status = models.LoanOfferStatusExpired
+		if err := s.loanRepo.UpdateOffer(ctx, offer); err != nil {
+			return nil, nil, app_error.NewDatabaseError("vencer contraoferta", err.Error())
+		}
+		return nil, nil, app_error.ErrOfferExpired
+	}
+
+	return loan, offer, nil
+}
+
+// requiresManualReview determina si la solicitud debe pasar a revisión manual según la configuración
+func (s *loanService) requiresManualReview(loan models.Loan, requestedAmount decimal.Decimal, cfg models.ManualReviewConfig) (bool, string) {
+	if !cfg.Enabled {
+		return false, ""
+	}
+
+	// 1. Coincidencia parcial de identidad
+	if cfg.OnIdentityPartialMatch && loan.IdentityMatch == models.IdentityMatchPartial {
+		return true, "Solicitud enviada a revisión manual: coincidencia parcial de identidad"
+	}
+
+	// Sin identidad verificada no hay revisión posible, la decisión automática la rechaza
+	if loan.IdentityVerified == nil || !*loan.IdentityVerified {
+		return false, ""
+	}
+
+	// 2. Score dentro de la banda gris
+	if loan.CreditScore != nil && cfg.ScoreMax > 0 && *loan.CreditScore >= cfg.ScoreMin && *loan.CreditScore <= cfg.ScoreMax {
+		return true, "Solicitud enviada a revisión manual: score crediticio en banda de revisión (" + strconv.Itoa(*loan.CreditScore) + ")"
+	}
+
+	// 3. Montos altos
+	if cfg.AmountThreshold > 0 && requestedAmount.GreaterThanOrEqual(decimal.NewFromFloat(cfg.AmountThreshold)) {
+		return true, "Solicitud enviada a revisión manual: monto solicitado superior al umbral de revisión"
+	}
+
+	return false, ""
+}
+
+// getDefaultVersionConfig obtiene la configuración de la versión por defecto de un tipo de préstamo
+func (s *loanService) getDefaultVersionConfig(ctx context.Context, loanTypeID uint) (models.LoanTypeVersionConfig, error) {
+	loanType, err := s.loanTypeRepo.GetByIDWithForms(ctx, loanTypeID)
+	if err != nil {
+		return models.LoanTypeVersionConfig{}, errors.New("tipo de préstamo no encontrado")
+	}
+
+	for _, version := range loanType.Versions {
+		if !version.IsDefault {
+			continue
+		}
+		cfg, err := version.ParseConfig()
+		if err != nil {
+			return models.LoanTypeVersionConfig{}, errors.New("configuración inválida en la versión del tipo de préstamo")
+		}
+		return cfg, nil
+	}
+
+	// Sin versión por defecto se usan los valores por defecto del cálculo
+	return models.LoanTypeVersionConfig{}, nil
+}
+
+// determineNewLoanStatus determina el nuevo estado del préstamo basado en la configuración real de formularios
+func (s *loanService) determineNewLoanStatus(ctx context.Context, loan models.Loan, creditScore *int, identityVerified *bool) (string, error) {
 	// Si no hay datos guardados, mantener pending
 	if len(loan.Data) == 0 {
 		return "pending", nil
 	}
 
 	// Obtener la configuración de formularios para este loan type
-	loanType, err := s.loanTypeRepo.GetByIDWithForms(loanTypeID)
+	loanType, err := s.loanTypeRepo.GetByIDWithForms(ctx, loan.LoanTypeID)
 	if err != nil {
 		return "", errors.New("error al obtener configuración de formularios")
 	}
 
 	// Verificar si todos los campos requeridos están completos
-	allRequiredFieldsComplete := s.checkAllRequiredFieldsComplete(*loan, *loanType)
+	allRequiredFieldsComplete := s.checkAllRequiredFieldsComplete(loan, *loanType)
 
 	// Determinar estado basado en completitud de campos y validaciones
 	if allRequiredFieldsComplete && creditScore != nil && identityVerified != nil {
@@ -571,6 +1449,7 @@ func (s *loanService) checkAllRequiredFieldsComplete(loan models.Loan, loanType
 		}
 		savedData[data.Key][data.Index] = data.Value
 	}
+	answers := buildFormAnswers(loan.Data)
 
 	// Verificar cada formulario y sus inputs requeridos
 	for _, version := range loanType.Versions {
@@ -579,12 +1458,22 @@ func (s *loanService) checkAllRequiredFieldsComplete(loan models.Loan, loanType
 		}
 
 		for _, form := range version.Forms {
+			// Los formularios repetibles se verifican por elemento
+			formConfig, err := form.ParseConfig()
+			if err == nil && formConfig.Repeatable {
+				if !isRepeatableFormComplete(loan.Data, form, formConfig) {
+					return false
+				}
+				continue
+			}
+
 			if !form.IsRequired {
 				continue // Solo verificar formularios requeridos
 			}
 
 			for _, input := range form.FormInputs {
-				if !input.IsRequired {
+				// Los inputs ocultos no son requeridos; otros pueden serlo según las respuestas
+				if _, required := input.EvaluateState(answers.answerAt(0)); !required {
 					continue // Solo verificar inputs requeridos
 				}
 
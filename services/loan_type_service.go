@@ -1,15 +1,16 @@
 package services
 
 import (
+	"context"
 	"loan-api/models"
 	"loan-api/repositories"
 )
 
 // LoanTypeService interface para el servicio de tipos de préstamo
 type LoanTypeService interface {
-	GetLoanTypesWithForms(tenantID uint) ([]models.LoanTypeResponse, error)
-	GetLoanTypeByCode(tenantID uint, code string) (*models.LoanTypeResponse, error)
-	GetLoanTypeByID(id uint) (*models.LoanTypeResponse, error)
+	GetLoanTypesWithForms(ctx context.Context, tenantID uint) ([]models.LoanTypeResponse, error)
+	GetLoanTypeByCode(ctx context.Context, tenantID uint, code string) (*models.LoanTypeResponse, error)
+	GetLoanTypeByID(ctx context.Context, id uint) (*models.LoanTypeResponse, error)
 }
 
 // loanTypeService implementación del servicio
@@ -25,8 +26,8 @@ func NewLoanTypeService(loanTypeRepo repositories.LoanTypeRepository) LoanTypeSe
 }
 
 // GetLoanTypesWithForms obtiene todos los tipos de préstamo con formularios por tenant
-func (s *loanTypeService) GetLoanTypesWithForms(tenantID uint) ([]models.LoanTypeResponse, error) {
-	loanTypes, err := s.loanTypeRepo.GetActiveByTenantID(tenantID)
+func (s *loanTypeService) GetLoanTypesWithForms(ctx context.Context, tenantID uint) ([]models.LoanTypeResponse, error) {
+	loanTypes, err := s.loanTypeRepo.GetActiveByTenantID(ctx, tenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -40,8 +41,8 @@ func (s *loanTypeService) GetLoanTypesWithForms(tenantID uint) ([]models.LoanTyp
 }
 
 // GetLoanTypeByCode obtiene un tipo de préstamo por código y tenant
-func (s *loanTypeService) GetLoanTypeByCode(tenantID uint, code string) (*models.LoanTypeResponse, error) {
-	loanType, err := s.loanTypeRepo.GetByTenantIDAndCode(tenantID, code)
+func (s *loanTypeService) GetLoanTypeByCode(ctx context.Context, tenantID uint, code string) (*models.LoanTypeResponse, error) {
+	loanType, err := s.loanTypeRepo.GetByTenantIDAndCode(ctx, tenantID, code)
 	if err != nil {
 		return nil, err
 	}
@@ -51,8 +52,8 @@ func (s *loanTypeService) GetLoanTypeByCode(tenantID uint, code string) (*models
 }
 
 // GetLoanTypeByID obtiene un tipo de préstamo por ID con formularios
-func (s *loanTypeService) GetLoanTypeByID(id uint) (*models.LoanTypeResponse, error) {
-	loanType, err := s.loanTypeRepo.GetByIDWithForms(id)
+func (s *loanTypeService) GetLoanTypeByID(ctx context.Context, id uint) (*models.LoanTypeResponse, error) {
+	loanType, err := s.loanTypeRepo.GetByIDWithForms(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -79,7 +80,7 @@ func (s *loanTypeService) buildLoanTypeResponse(loanType models.LoanType) models
 			ID:          version.ID,
 			Version:     version.Version,
 			Description: version.Description,
-			Forms:       s.buildFormsResponse(version.Forms),
+			Forms:       buildFormsResponse(version.Forms),
 		}
 	}
 
@@ -87,9 +88,11 @@ func (s *loanTypeService) buildLoanTypeResponse(loanType models.LoanType) models
 }
 
 // buildFormsResponse construye la respuesta de formularios
-func (s *loanTypeService) buildFormsResponse(forms []models.LoanTypeForm) []models.LoanTypeFormResponse {
+func buildFormsResponse(forms []models.LoanTypeForm) []models.LoanTypeFormResponse {
 	response := make([]models.LoanTypeFormResponse, len(forms))
 	for i, form := range forms {
+		// Una configuración inválida se trata como formulario simple
+		formConfig, _ := form.ParseConfig()
 		response[i] = models.LoanTypeFormResponse{
 			ID:          form.ID,
 			Label:       form.Label,
@@ -97,16 +100,21 @@ func (s *loanTypeService) buildFormsResponse(forms []models.LoanTypeForm) []mode
 			Description: form.Description,
 			Order:       form.Order,
 			IsRequired:  form.IsRequired,
-			FormInputs:  s.buildFormInputsResponse(form.FormInputs),
+			Repeatable:  formConfig.Repeatable,
+			MinItems:    formConfig.MinItems,
+			MaxItems:    formConfig.MaxItems,
+			FormInputs:  buildFormInputsResponse(form.FormInputs),
 		}
 	}
 	return response
 }
 
 // buildFormInputsResponse construye la respuesta de inputs de formulario
-func (s *loanTypeService) buildFormInputsResponse(inputs []models.LoanTypeVersionFormInput) []models.LoanTypeVersionFormInputResponse {
+func buildFormInputsResponse(inputs []models.LoanTypeVersionFormInput) []models.LoanTypeVersionFormInputResponse {
 	response := make([]models.LoanTypeVersionFormInputResponse, len(inputs))
 	for i, input := range inputs {
+		// Las condiciones se exponen para que el frontend muestre u oculte el input
+		inputConfig, _ := input.ParseConfig()
 		response[i] = models.LoanTypeVersionFormInputResponse{
 			ID:              input.ID,
 			Label:           input.Label,
@@ -114,10 +122,12 @@ func (s *loanTypeService) buildFormInputsResponse(inputs []models.LoanTypeVersio
 			InputType:       input.InputType,
 			Placeholder:     input.Placeholder,
 			DefaultValue:    input.DefaultValue,
-			ValidationRules: input.ValidationRules,
-			Options:         input.Options,
+			ValidationRules: string(input.ValidationRules),
+			Options:         string(input.Options),
 			Order:           input.Order,
 			IsRequired:      input.IsRequired,
+			VisibleWhen:     inputConfig.VisibleWhen,
+			RequiredWhen:    inputConfig.RequiredWhen,
 		}
 	}
 	return response
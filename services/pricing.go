@@ -0,0 +1,131 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"loan-api/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Parámetros de la búsqueda de la tasa interna de retorno usada para la APR
+const (
+	aprMaxIterations = 200
+	aprTolerance     = 1e-10
+)
+
+// selectPricingTier obtiene el primer tramo de riesgo que aplica al score, monto y plazo
+func selectPricingTier(tiers []models.PricingTier, score int, amount decimal.Decimal, termMonths int) *models.PricingTier {
+	amountFloat, _ := amount.Float64()
+	for i := range tiers {
+		if tiers[i].Matches(score, amountFloat, termMonths) {
+			return &tiers[i]
+		}
+	}
+	return nil
+}
+
+// calculatePricing calcula la tasa efectiva anual, la APR y los costos del préstamo para un tramo,
+// limitando la tasa a la usura del tenant cuando está configurada
+func calculatePricing(tier models.PricingTier, amount decimal.Decimal, termMonths int, usuryRateEA float64) models.LoanPricing {
+	nominalAnnualRate := tier.NominalAnnualRate
+	cappedByUsury := false
+
+	// 1. Comisión de estudio y seguro mensual, que no dependen de la tasa
+	originationFee := amount.Mul(decimal.NewFromFloat(tier.OriginationFeeRate)).Round(2)
+	monthlyInsurance := amount.Mul(decimal.NewFromFloat(tier.InsuranceRate)).Round(2)
+
+	// 2. El costo efectivo anual, con comisión y seguro incluidos, no puede superar la tasa de usura:
+	// se reduce la tasa nominal hasta que lo cumpla
+	allInRate := func(rate float64) float64 {
+		return effectiveAnnualRate(allInMonthlyRate(amount, originationFee, monthlyInsurance, rate, termMonths))
+	}
+	if usuryRateEA > 0 && allInRate(nominalAnnualRate) > usuryRateEA {
+		nominalAnnualRate = maxNominalRateWithin(allInRate, nominalAnnualRate, usuryRateEA)
+		cappedByUsury = true
+	}
+	monthlyRate := nominalAnnualRate / 12
+
+	// 3. Cuota fija más seguro
+	installment := monthlyPayment(amount, decimal.NewFromFloat(monthlyRate), termMonths)
+	totalMonthlyPayment := installment.Add(monthlyInsurance)
+
+	// 4. La APR incluye comisión y seguro
+	apr := allInMonthlyRate(amount, originationFee, monthlyInsurance, nominalAnnualRate, termMonths) * 12
+
+	pricing := models.LoanPricing{
+		Tier:                tier.Name,
+		Amount:              amount,
+		TermMonths:          termMonths,
+		NominalAnnualRate:   decimal.NewFromFloat(nominalAnnualRate).Round(6),
+		MonthlyRate:         decimal.NewFromFloat(monthlyRate).Round(6),
+		EffectiveAnnualRate: decimal.NewFromFloat(effectiveAnnualRate(monthlyRate)).Round(6),
+		APR:                 decimal.NewFromFloat(apr).Round(6),
+		OriginationFee:      originationFee,
+		MonthlyInsurance:    monthlyInsurance,
+		MonthlyPayment:      installment,
+		TotalMonthlyPayment: totalMonthlyPayment,
+		CappedByUsury:       cappedByUsury,
+		PricedAt:            time.Now(),
+	}
+	if usuryRateEA > 0 {
+		pricing.UsuryRateEA = decimal.NewFromFloat(usuryRateEA)
+	}
+	return pricing
+}
+
+// allInMonthlyRate calcula la tasa mensual que incluye comisión y seguro: tasa interna del flujo neto
+// recibido frente a las cuotas pagadas con la tasa nominal indicada
+func allInMonthlyRate(amount, originationFee, monthlyInsurance decimal.Decimal, nominalAnnualRate float64, termMonths int) float64 {
+	installment := monthlyPayment(amount, decimal.NewFromFloat(nominalAnnualRate/12), termMonths)
+	netDisbursed, _ := amount.Sub(originationFee).Float64()
+	totalPayment, _ := installment.Add(monthlyInsurance).Float64()
+	return internalRateOfReturn(netDisbursed, totalPayment, termMonths)
+}
+
+// maxNominalRateWithin busca por bisección la mayor tasa nominal, con seis decimales, cuyo costo efectivo
+// no supera el límite. Si la comisión y el seguro por sí solos lo superan, retorna cero
+func maxNominalRateWithin(allInRate func(float64) float64, maxRate, limit float64) float64 {
+	low, high := 0.0, maxRate
+	for i := 0; i < aprMaxIterations && high-low > aprTolerance; i++ {
+		mid := (low + high) / 2
+		if allInRate(mid) <= limit {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	// Truncar para que la tasa guardada no quede por encima del límite al redondearla
+	return math.Floor(low*1e6) / 1e6
+}
+
+// effectiveAnnualRate convierte una tasa mensual vencida a efectiva anual: (1 + i)^12 - 1
+func effectiveAnnualRate(monthlyRate float64) float64 {
+	return math.Pow(1+monthlyRate, 12) - 1
+}
+
+// internalRateOfReturn calcula por bisección la tasa mensual que iguala el monto recibido con las cuotas pagadas
+func internalRateOfReturn(principal, payment float64, termMonths int) float64 {
+	if principal <= 0 || payment <= 0 || termMonths <= 0 || payment*float64(termMonths) <= principal {
+		return 0
+	}
+
+	presentValueAt := func(rate float64) float64 {
+		return payment * (1 - math.Pow(1+rate, -float64(termMonths))) / rate
+	}
+
+	low, high := 1e-9, 1.0
+	for i := 0; i < aprMaxIterations; i++ {
+		mid := (low + high) / 2
+		if presentValueAt(mid) > principal {
+			low = mid
+		} else {
+			high = mid
+		}
+		if high-low < aprTolerance {
+			break
+		}
+	}
+	return (low + high) / 2
+}
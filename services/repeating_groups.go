@@ -0,0 +1,189 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"loan-api/app_error"
+	"loan-api/models"
+)
+
+// formEntries representa los datos de un formulario indexados por campo e índice
+type formEntries map[string]uint
+
+// entryKey construye la clave única de un dato del formulario
+func entryKey(key string, index uint) string {
+	return key + ":" + strconv.FormatUint(uint64(index), 10)
+}
+
+// collectFormEntries obtiene las entradas guardadas de un formulario
+func collectFormEntries(data []models.LoanData, formID uint) formEntries {
+	entries := make(formEntries)
+	for _, item := range data {
+		if item.FormID == formID {
+			entries[entryKey(item.Key, item.Index)] = item.Index
+		}
+	}
+	return entries
+}
+
+// add incorpora nuevas entradas al formulario
+func (e formEntries) add(data []models.LoanData) {
+	for _, item := range data {
+		e[entryKey(item.Key, item.Index)] = item.Index
+	}
+}
+
+// remove elimina las entradas indicadas del formulario
+func (e formEntries) remove(keys []models.FormDataKeyRequest) {
+	for _, key := range keys {
+		delete(e, entryKey(key.Key, key.Index))
+	}
+}
+
+// indexes obtiene los índices distintos de las entradas ordenados de menor a mayor
+func (e formEntries) indexes() []uint {
+	seen := make(map[uint]bool)
+	indexes := make([]uint, 0)
+	for _, index := range e {
+		if !seen[index] {
+			seen[index] = true
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	return indexes
+}
+
+// validateFormIndexes verifica que los índices de un formulario sean consecutivos y respeten sus límites
+func validateFormIndexes(form models.LoanTypeForm, entries formEntries) error {
+	formConfig, err := form.ParseConfig()
+	if err != nil {
+		return app_error.NewBusinessError("Configuración de formulario inválida", err.Error())
+	}
+
+	indexes := entries.indexes()
+
+	// Los formularios simples solo admiten el índice 0
+	if !formConfig.Repeatable {
+		if len(indexes) > 0 && indexes[len(indexes)-1] != 0 {
+			return app_error.NewValidationError("index", "el formulario "+form.Code+" no admite múltiples elementos")
+		}
+		return nil
+	}
+
+	for i, index := range indexes {
+		if index != uint(i) {
+			return app_error.NewValidationError("index", "los índices del formulario "+form.Code+" deben ser consecutivos desde 0")
+		}
+	}
+
+	if formConfig.MaxItems > 0 && len(indexes) > formConfig.MaxItems {
+		return app_error.NewValidationError("index", fmt.Sprintf("el formulario %s admite máximo %d elementos", form.Code, formConfig.MaxItems))
+	}
+
+	return nil
+}
+
+// defaultVersionForms obtiene los formularios de la versión por defecto del tipo de préstamo
+func defaultVersionForms(loanType models.LoanType) []models.LoanTypeForm {
+	for _, version := range loanType.Versions {
+		if version.IsDefault {
+			return version.Forms
+		}
+	}
+	return nil
+}
+
+// isRepeatableFormComplete verifica que un formulario repetible tenga los elementos mínimos
+// y que cada elemento tenga sus inputs requeridos
+func isRepeatableFormComplete(data []models.LoanData, form models.LoanTypeForm, formConfig models.LoanTypeFormConfig) bool {
+	items := make(map[uint]map[string]string)
+	for _, item := range data {
+		if item.FormID != form.ID {
+			continue
+		}
+		if items[item.Index] == nil {
+			items[item.Index] = make(map[string]string)
+		}
+		items[item.Index][item.Key] = item.Value
+	}
+
+	answers := buildFormAnswers(data)
+
+	minItems := formConfig.MinItems
+	if form.IsRequired && minItems < 1 {
+		minItems = 1
+	}
+	if len(items) < minItems {
+		return false
+	}
+
+	for index, values := range items {
+		for _, input := range form.FormInputs {
+			_, required := input.EvaluateState(answers.answerAt(index))
+			if required && strings.TrimSpace(values[input.Code]) == "" {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// buildLoanDataResponse separa los datos planos de los elementos de formularios repetibles
+func buildLoanDataResponse(data []models.LoanData, forms []models.LoanTypeForm) ([]models.LoanDataResponse, models.LoanDataGroups) {
+	repeatableForms := make(map[uint]string)
+	for _, form := range forms {
+		if formConfig, err := form.ParseConfig(); err == nil && formConfig.Repeatable {
+			repeatableForms[form.ID] = form.Code
+		}
+	}
+
+	dataResponse := make([]models.LoanDataResponse, 0, len(data))
+	itemsByForm := make(map[string]map[uint]models.LoanDataGroupItem)
+	for _, item := range data {
+		formCode, repeatable := repeatableForms[item.FormID]
+		if !repeatable {
+			dataResponse = append(dataResponse, models.LoanDataResponse{
+				ID:     item.ID,
+				FormID: item.FormID,
+				Key:    item.Key,
+				Value:  item.Value,
+				Index:  item.Index,
+			})
+			continue
+		}
+
+		if itemsByForm[formCode] == nil {
+			itemsByForm[formCode] = make(map[uint]models.LoanDataGroupItem)
+		}
+		if itemsByForm[formCode][item.Index] == nil {
+			itemsByForm[formCode][item.Index] = make(models.LoanDataGroupItem)
+		}
+		itemsByForm[formCode][item.Index][item.Key] = item.Value
+	}
+
+	if len(itemsByForm) == 0 {
+		return dataResponse, nil
+	}
+
+	// Los elementos se devuelven en el orden de su índice
+	groups := make(models.LoanDataGroups, len(itemsByForm))
+	for formCode, items := range itemsByForm {
+		indexes := make([]uint, 0, len(items))
+		for index := range items {
+			indexes = append(indexes, index)
+		}
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+		groups[formCode] = make([]models.LoanDataGroupItem, len(indexes))
+		for i, index := range indexes {
+			groups[formCode][i] = items[index]
+		}
+	}
+
+	return dataResponse, groups
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"loan-api/app_error"
+	"loan-api/models"
+	"loan-api/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ReviewService interface para el servicio de revisión manual de préstamos
+type ReviewService interface {
+	GetQueue(ctx context.Context, filter models.ReviewQueueFilter) ([]models.ReviewQueueItemResponse, error)
+	ClaimLoan(ctx context.Context, tenantID, loanID, analystID uint) (*models.LoanResponse, error)
+	ReleaseLoan(ctx context.Context, tenantID, loanID, analystID uint) error
+	ApproveLoan(ctx context.Context, tenantID, loanID, analystID uint, comment string) (*models.LoanResponse, error)
+	RejectLoan(ctx context.Context, tenantID, loanID, analystID uint, comment string) (*models.LoanResponse, error)
+	RequestMoreInfo(ctx context.Context, tenantID, loanID, analystID uint, comment string) (*models.LoanResponse, error)
+	GetLoanActions(ctx context.Context, tenantID, loanID uint) ([]models.LoanReviewAction, error)
+}
+
+// reviewService implementación del servicio
+type reviewService struct {
+	*loanService
+	reviewRepo repositories.LoanReviewRepository
+	claimTTL   time.Duration
+	now        func() time.Time
+}
+
+// NewReviewService crea una nueva instancia del servicio
+func NewReviewService(loanRepo repositories.LoanRepository, userRepo repositories.UserRepository, loanTypeRepo repositories.LoanTypeRepository, tenantRepo repositories.TenantRepository, reviewRepo repositories.LoanReviewRepository, txManager repositories.TransactionManager, disbursement DisbursementProvider, claimTTL time.Duration) ReviewService {
+	return &reviewService{
+		loanService: &loanService{
+			loanRepo:     loanRepo,
+			userRepo:     userRepo,
+			loanTypeRepo: loanTypeRepo,
+			tenantRepo:   tenantRepo,
+			txManager:    txManager,
+			disbursement: disbursement,
+		},
+		reviewRepo: reviewRepo,
+		claimTTL:   claimTTL,
+		now:        time.Now,
+	}
+}
+
+// GetQueue obtiene la cola de préstamos en revisión manual del tenant
+func (s *reviewService) GetQueue(ctx context.Context, filter models.ReviewQueueFilter) ([]models.ReviewQueueItemResponse, error) {
+	loans, err := s.reviewRepo.GetQueue(ctx, filter, s.now())
+	if err != nil {
+		return nil, app_error.NewDatabaseError("obtener cola de revisión", err.Error())
+	}
+
+	response := make([]models.ReviewQueueItemResponse, len(loans))
+	for i, loan := range loans {
+		item := models.ReviewQueueItemResponse{
+			ID:              loan.ID,
+			LoanTypeID:      loan.LoanTypeID,
+			LoanTypeName:    loan.LoanType.Name,
+			UserID:          loan.UserID,
+			UserName:        loan.User.Name,
+			Status:          loan.Status,
+			Observation:     loan.Observation,
+			CreditScore:     loan.CreditScore,
+			IdentityMatch:   loan.IdentityMatch,
+			RequestedAmount: s.extractLoanDataFromLoan(loan, "requested_amount"),
+			CreatedAt:       loan.CreatedAt,
+			UpdatedAt:       loan.UpdatedAt,
+		}
+		// Solo exponer asignaciones vigentes
+		if loan.HasActiveReviewClaim(s.now()) {
+			item.ReviewClaimedBy = loan.ReviewClaimedBy
+			item.ReviewClaimExpiresAt = loan.ReviewClaimExpiresAt
+		}
+		response[i] = item
+	}
+
+	return response, nil
+}
+
+// ClaimLoan asigna un préstamo en revisión al analista
+func (s *reviewService) ClaimLoan(ctx context.Context, tenantID, loanID, analystID uint) (*models.LoanResponse, error) {
+	loan, err := s.getTenantLoan(ctx, tenantID, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	if loan.Status != string(models.LoanStatusManualReview) {
+		return nil, app_error.NewBusinessError("Estado de préstamo inválido", "solo se pueden tomar préstamos en revisión manual")
+	}
+
+	now := s.now()
+	claimed, err := s.reviewRepo.Claim(ctx, loanID, analystID, now, now.Add(s.claimTTL))
+	if err != nil {
+		return nil, app_error.NewDatabaseError("tomar préstamo", err.Error())
+	}
+	if !claimed {
+		return nil, app_error.ErrReviewClaimConflict
+	}
+
+	if err := s.recordAction(ctx, loanID, analystID, models.ReviewActionClaim, ""); err != nil {
+		return nil, err
+	}
+
+	return s.GetLoanByID(ctx, loanID)
+}
+
+// ReleaseLoan libera la asignación de un préstamo tomado por el analista
+func (s *reviewService) ReleaseLoan(ctx context.Context, tenantID, loanID, analystID uint) error {
+	loan, err := s.getTenantLoan(ctx, tenantID, loanID)
+	if err != nil {
+		return err
+	}
+
+	if !loan.HasActiveReviewClaim(s.now()) || *loan.ReviewClaimedBy != analystID {
+		return app_error.ErrReviewClaimRequired
+	}
+
+	released, err := s.reviewRepo.Release(ctx, loanID, analystID)
+	if err != nil {
+		return app_error.NewDatabaseError("liberar préstamo", err.Error())
+	}
+	if !released {
+		return app_error.ErrReviewClaimRequired
+	}
+
+	return s.recordAction(ctx, loanID, analystID, models.ReviewActionRelease, "")
+}
+
+// ApproveLoan aprueba un préstamo en revisión y realiza el desembolso
+func (s *reviewService) ApproveLoan(ctx context.Context, tenantID, loanID, analystID uint, comment string) (*models.LoanResponse, error) {
+	return s.decide(ctx, tenantID, loanID, analystID, models.ReviewActionApprove, comment, func(ctx context.Context, loan *models.Loan) error {
+		requestedAmount := s.extractLoanDataFromLoan(*loan, "requested_amount")
+		loan.Observation = "Préstamo aprobado en revisión manual: " + comment
+
+		affordability := loan.GetAffordability()
+		if affordability == nil {
+			return s.approve(ctx, loan, requestedAmount, 0)
+		}
+
+		versionConfig, err := s.getDefaultVersionConfig(ctx, loan.LoanTypeID)
+		if err != nil {
+			return err
+		}
+		return s.approveOrOffer(ctx, loan, requestedAmount, *affordability, versionConfig.Offers)
+	})
+}
+
+// RejectLoan rechaza un préstamo en revisión
+func (s *reviewService) RejectLoan(ctx context.Context, tenantID, loanID, analystID uint, comment string) (*models.LoanResponse, error) {
+	return s.decide(ctx, tenantID, loanID, analystID, models.ReviewActionReject, comment, func(ctx context.Context, loan *models.Loan) error {
+		loan.Status = string(models.LoanStatusRejected)
+		loan.Observation = "Préstamo rechazado en revisión manual: " + comment
+		return nil
+	})
+}
+
+// RequestMoreInfo devuelve el préstamo al solicitante para que complete información adicional
+func (s *reviewService) RequestMoreInfo(ctx context.Context, tenantID, loanID, analystID uint, comment string) (*models.LoanResponse, error) {
+	return s.decide(ctx, tenantID, loanID, analystID, models.ReviewActionRequestInfo, comment, func(ctx context.Context, loan *models.Loan) error {
+		loan.Status = string(models.LoanStatusOnProgress)
+		loan.Observation = "Información adicional requerida: " + comment
+		return nil
+	})
+}
+
+// GetLoanActions obtiene el historial de acciones de revisión de un préstamo
+func (s *reviewService) GetLoanActions(ctx context.Context, tenantID, loanID uint) ([]models.LoanReviewAction, error) {
+	if _, err := s.getTenantLoan(ctx, tenantID, loanID); err != nil {
+		return nil, err
+	}
+
+	actions, err := s.reviewRepo.GetActionsByLoanID(ctx, loanID)
+	if err != nil {
+		return nil, app_error.NewDatabaseError("obtener historial de revisión", err.Error())
+	}
+	return actions, nil
+}
+
+// decide aplica una decisión del analista sobre un préstamo que tiene asignado
+func (s *reviewService) decide(ctx context.Context, tenantID, loanID, analystID uint, action models.ReviewAction, comment string, apply func(ctx context.Context, loan *models.Loan) error) (*models.LoanResponse, error) {
+	comment = strings.TrimSpace(comment)
+	if comment == "" {
+		return nil, app_error.ErrReviewCommentEmpty
+	}
+
+	loan, err := s.getTenantLoan(ctx, tenantID, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	if loan.Status != string(models.LoanStatusManualReview) {
+		return nil, app_error.NewBusinessError("Estado de préstamo inválido", "solo se pueden decidir préstamos en revisión manual")
+	}
+
+	// La decisión requiere que el analista tenga el préstamo asignado y vigente
+	if !loan.HasActiveReviewClaim(s.now()) || *loan.ReviewClaimedBy != analystID {
+		return nil, app_error.ErrReviewClaimRequired
+	}
+
+	// La decisión, sus contraofertas y la acción del analista se confirman juntas o no se confirma nada
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := apply(ctx, loan); err != nil {
+			return err
+		}
+
+		// La decisión cierra la asignación
+		loan.ReviewClaimedBy = nil
+		loan.ReviewClaimedAt = nil
+		loan.ReviewClaimExpiresAt = nil
+
+		if err := s.loanRepo.Update(ctx, loan); err != nil {
+			if errors.Is(err, app_error.ErrLoanConflict) {
+				return err
+			}
+			return app_error.NewDatabaseError("actualizar préstamo", err.Error())
+		}
+
+		return s.recordAction(ctx, loanID, analystID, action, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Una aprobación desembolsa con la decisión ya confirmada, sin mantener la transacción abierta
+	if err := s.disburse(ctx, loan); err != nil {
+		return nil, err
+	}
+
+	return s.GetLoanByID(ctx, loanID)
+}
+
+// getTenantLoan obtiene un préstamo validando que pertenezca al tenant
+func (s *reviewService) getTenantLoan(ctx context.Context, tenantID, loanID uint) (*models.Loan, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, app_error.ErrLoanNotFound
+		}
+		return nil, app_error.NewDatabaseError("obtener préstamo", err.Error())
+	}
+
+	if loan.LoanType.TenantID != tenantID {
+		return nil, app_error.ErrLoanNotFound
+	}
+
+	return loan, nil
+}
+
+// recordAction registra una acción en el historial de revisión
+func (s *reviewService) recordAction(ctx context.Context, loanID, analystID uint, action models.ReviewAction, comment string) error {
+	reviewAction := &models.LoanReviewAction{
+		LoanID:    loanID,
+		AnalystID: analystID,
+		Action:    action,
+		Comment:   comment,
+	}
+	if err := s.reviewRepo.CreateAction(ctx, reviewAction); err != nil {
+		return app_error.NewDatabaseError("registrar acción de revisión", err.Error())
+	}
+	return nil
+}
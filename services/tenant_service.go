@@ -1,15 +1,16 @@
 package services
 
 import (
+	"context"
 	"loan-api/models"
 	"loan-api/repositories"
 )
 
 // TenantService interface para el servicio de tenant
 type TenantService interface {
-	GetAvailableTenants() ([]models.TenantResponse, error)
-	GetTenantByCode(code string) (*models.TenantResponse, error)
-	ValidateTenantID(tenantID uint) (*models.Tenant, error)
+	GetAvailableTenants(ctx context.Context) ([]models.TenantResponse, error)
+	GetTenantByCode(ctx context.Context, code string) (*models.TenantResponse, error)
+	ValidateTenantID(ctx context.Context, tenantID uint) (*models.Tenant, error)
 }
 
 // tenantService implementación del servicio
@@ -25,8 +26,8 @@ func NewTenantService(tenantRepo repositories.TenantRepository) TenantService {
 }
 
 // GetAvailableTenants obtiene todos los tenants disponibles para pruebas
-func (s *tenantService) GetAvailableTenants() ([]models.TenantResponse, error) {
-	tenants, err := s.tenantRepo.GetAllActive()
+func (s *tenantService) GetAvailableTenants(ctx context.Context) ([]models.TenantResponse, error) {
+	tenants, err := s.tenantRepo.GetAllActive(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -46,8 +47,8 @@ func (s *tenantService) GetAvailableTenants() ([]models.TenantResponse, error) {
 }
 
 // GetTenantByCode obtiene un tenant por código
-func (s *tenantService) GetTenantByCode(code string) (*models.TenantResponse, error) {
-	tenant, err := s.tenantRepo.GetByCode(code)
+func (s *tenantService) GetTenantByCode(ctx context.Context, code string) (*models.TenantResponse, error) {
+	tenant, err := s.tenantRepo.GetByCode(ctx, code)
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +65,6 @@ func (s *tenantService) GetTenantByCode(code string) (*models.TenantResponse, er
 }
 
 // ValidateTenantID valida que un tenant ID existe y está activo
-func (s *tenantService) ValidateTenantID(tenantID uint) (*models.Tenant, error) {
-	return s.tenantRepo.GetByID(tenantID)
+func (s *tenantService) ValidateTenantID(ctx context.Context, tenantID uint) (*models.Tenant, error) {
+	return s.tenantRepo.GetByID(ctx, tenantID)
 }
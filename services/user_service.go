@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"loan-api/app_error"
 	"loan-api/config"
 	"loan-api/models"
@@ -13,8 +14,8 @@ import (
 
 // UserService define la interfaz para la lógica de negocio de usuarios
 type UserService interface {
-	RegisterUser(req *models.RegisterRequest, tenantID uint) (*models.User, error)
-	Login(req *models.LoginRequest, tenantID uint, cfg *config.Config) (*models.LoginResponse, error)
+	RegisterUser(ctx context.Context, req *models.RegisterRequest, tenantID uint) (*models.User, error)
+	Login(ctx context.Context, req *models.LoginRequest, tenantID uint, cfg *config.Config) (*models.LoginResponse, error)
 	ValidateRegister(req *models.RegisterRequest) error
 	ValidateLogin(req *models.LoginRequest) error
 }
@@ -34,14 +35,14 @@ func NewUserService(userRepo repositories.UserRepository) UserService {
 }
 
 // RegisterUser registra un nuevo usuario
-func (s *userService) RegisterUser(req *models.RegisterRequest, tenantID uint) (*models.User, error) {
+func (s *userService) RegisterUser(ctx context.Context, req *models.RegisterRequest, tenantID uint) (*models.User, error) {
 	// Validar datos de entrada
 	if err := s.ValidateRegister(req); err != nil {
 		return nil, err
 	}
 
 	// Verificar si el email ya existe
-	exists, err := s.userRepo.ExistsByEmail(req.Email, tenantID)
+	exists, err := s.userRepo.ExistsByEmail(ctx, req.Email, tenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -50,7 +51,7 @@ func (s *userService) RegisterUser(req *models.RegisterRequest, tenantID uint) (
 	}
 
 	// Verificar si el documento ya existe
-	exists, err = s.userRepo.ExistsByDocument(req.DocumentType, req.DocumentNumber)
+	exists, err = s.userRepo.ExistsByDocument(ctx, req.DocumentType, req.DocumentNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -78,10 +79,11 @@ func (s *userService) RegisterUser(req *models.RegisterRequest, tenantID uint) (
 		DocumentType:   req.DocumentType,
 		DocumentNumber: req.DocumentNumber,
 		Password:       string(hashedPassword),
+		Role:           models.UserRoleApplicant,
 	}
 
 	// Guardar en base de datos
-	if err := s.userRepo.Create(user); err != nil {
+	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
@@ -89,14 +91,14 @@ func (s *userService) RegisterUser(req *models.RegisterRequest, tenantID uint) (
 }
 
 // Login autentica un usuario
-func (s *userService) Login(req *models.LoginRequest, tenantID uint, cfg *config.Config) (*models.LoginResponse, error) {
+func (s *userService) Login(ctx context.Context, req *models.LoginRequest, tenantID uint, cfg *config.Config) (*models.LoginResponse, error) {
 	// Validar datos de entrada
 	if err := s.ValidateLogin(req); err != nil {
 		return nil, err
 	}
 
 	// Buscar usuario por email
-	user, err := s.userRepo.GetByEmail(req.Email)
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, app_error.NewValidationError("credentials", "Email o contraseña incorrectos")
 	}
@@ -0,0 +1,32 @@
+package test
+
+import (
+	"loan-api/app"
+	"loan-api/config"
+	"loan-api/database"
+)
+
+// NewApp abre la base de datos de cfg, aplica migraciones y seeders de prueba y construye
+// una instancia de la aplicación con su propia conexión. El llamador debe cerrarla con Close
+func NewApp(cfg config.Config) (*app.Container, error) {
+	db, err := database.Open(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := PrepareDatabase(db); err != nil {
+		if sqlDB, sqlErr := db.DB(); sqlErr == nil {
+			sqlDB.Close()
+		}
+		return nil, err
+	}
+
+	container, err := app.NewContainer(cfg, db)
+	if err != nil {
+		if sqlDB, sqlErr := db.DB(); sqlErr == nil {
+			sqlDB.Close()
+		}
+		return nil, err
+	}
+	return container, nil
+}
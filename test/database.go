@@ -2,15 +2,52 @@ package test
 
 import (
 	"log"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
+	"loan-api/database"
 	"loan-api/models"
 )
 
+// testDataTables son las tablas con datos generados por las pruebas, en orden de dependencia
+var testDataTables = []string{
+	"loan_review_actions",
+	"loan_offers",
+	"loan_parties",
+	"loan_exports",
+	"job_runs",
+	"job_leases",
+	"idempotency_keys",
+	"loan_data",
+	"loans",
+	"users",
+}
+
+// seedTables son las tablas pobladas por los seeders de pruebas
+var seedTables = []string{
+	"loan_type_version_form_inputs",
+	"loan_type_forms",
+	"loan_type_versions",
+	"loan_types",
+	"tenants",
+}
+
+// PrepareDatabase aplica las migraciones pendientes y los seeders del entorno de pruebas
+func PrepareDatabase(DB *gorm.DB) error {
+	migrator, err := database.NewMigrator(DB)
+	if err != nil {
+		return err
+	}
+	if _, err := migrator.Up(); err != nil {
+		return err
+	}
+	return database.Seed(DB, "test")
+}
+
 // ClearTestData limpia solo los datos de prueba, preservando datos del seed
 func ClearTestData(DB *gorm.DB) {
 	if DB == nil {
@@ -18,62 +55,48 @@ func ClearTestData(DB *gorm.DB) {
 		return
 	}
 
-	DB.Exec("SET foreign_key_checks = 0")
-
 	// Limpiar solo datos de prueba (no tocar los datos del seed)
-	DB.Exec("DELETE FROM loan_data")
-	DB.Exec("ALTER TABLE loan_data AUTO_INCREMENT = 1")
-
-	DB.Exec("DELETE FROM loans")
-	DB.Exec("ALTER TABLE loans AUTO_INCREMENT = 1")
-
-	DB.Exec("DELETE FROM users")
-	DB.Exec("ALTER TABLE users AUTO_INCREMENT = 1")
-
-	DB.Exec("SET foreign_key_checks = 1")
+	clearTables(DB, testDataTables)
 
 	log.Println("Test data cleared successfully")
 }
 
-// ClearDatabase limpia todas las tablas y resetea los AUTO_INCREMENT (para cleanup final)
+// ClearDatabase limpia todas las tablas y resetea los identificadores (para cleanup final)
 func ClearDatabase(DB *gorm.DB) {
 	if DB == nil {
 		log.Println("Warning: Database connection is nil")
 		return
 	}
 
-	DB.Exec("SET foreign_key_checks = 0")
-
 	// Limpiar TODAS las tablas (incluyendo datos del seed)
-	DB.Exec("DELETE FROM loan_data")
-	DB.Exec("ALTER TABLE loan_data AUTO_INCREMENT = 1")
-
-	DB.Exec("DELETE FROM loans")
-	DB.Exec("ALTER TABLE loans AUTO_INCREMENT = 1")
-
-	DB.Exec("DELETE FROM users")
-	DB.Exec("ALTER TABLE users AUTO_INCREMENT = 1")
-
-	DB.Exec("DELETE FROM loan_type_version_form_inputs")
-	DB.Exec("ALTER TABLE loan_type_version_form_inputs AUTO_INCREMENT = 1")
-
-	DB.Exec("DELETE FROM loan_type_forms")
-	DB.Exec("ALTER TABLE loan_type_forms AUTO_INCREMENT = 1")
-
-	DB.Exec("DELETE FROM loan_type_versions")
-	DB.Exec("ALTER TABLE loan_type_versions AUTO_INCREMENT = 1")
-
-	DB.Exec("DELETE FROM loan_types")
-	DB.Exec("ALTER TABLE loan_types AUTO_INCREMENT = 1")
-
-	DB.Exec("DELETE FROM tenants")
-	DB.Exec("ALTER TABLE tenants AUTO_INCREMENT = 1")
-
-	DB.Exec("SET foreign_key_checks = 1")
+	clearTables(DB, append(append([]string{}, testDataTables...), seedTables...))
 
 	log.Println("Database cleared successfully")
 }
 
+// clearTables vacía las tablas indicadas y reinicia sus identificadores según el motor de base de datos
+func clearTables(DB *gorm.DB, tables []string) {
+	switch DB.Dialector.Name() {
+	case "postgres":
+		// TRUNCATE reinicia las secuencias y respeta las llaves foráneas entre las tablas indicadas
+		DB.Exec("TRUNCATE TABLE " + strings.Join(tables, ", ") + " RESTART IDENTITY CASCADE")
+	case "sqlite":
+		DB.Exec("PRAGMA foreign_keys = OFF")
+		for _, table := range tables {
+			DB.Exec("DELETE FROM " + table)
+			DB.Exec("DELETE FROM sqlite_sequence WHERE name = ?", table)
+		}
+		DB.Exec("PRAGMA foreign_keys = ON")
+	default:
+		DB.Exec("SET foreign_key_checks = 0")
+		for _, table := range tables {
+			DB.Exec("DELETE FROM " + table)
+			DB.Exec("ALTER TABLE " + table + " AUTO_INCREMENT = 1")
+		}
+		DB.Exec("SET foreign_key_checks = 1")
+	}
+}
+
 // LoadUsers carga usuarios de prueba en la base de datos
 func LoadUsers(DB *gorm.DB) {
 	if DB == nil {
@@ -145,6 +168,32 @@ func LoadUsers(DB *gorm.DB) {
 			CreatedAt:      time.Now(),
 			UpdatedAt:      time.Now(),
 		},
+		{
+			TenantID:       1, // Usar el tenant creado
+			Name:           "Laura Analista",
+			Email:          "analyst@example.com",
+			Phone:          "3022222222",
+			DocumentType:   models.DocumentTypeCedula,
+			DocumentNumber: "99887766",
+			Password:       string(hashedPassword),
+			Role:           models.UserRoleAnalyst,
+			IP:             "192.168.1.6",
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		},
+		{
+			TenantID:       1, // Usar el tenant creado
+			Name:           "Andrés Administrador",
+			Email:          "admin@example.com",
+			Phone:          "3033333333",
+			DocumentType:   models.DocumentTypeCedula,
+			DocumentNumber: "99887755",
+			Password:       string(hashedPassword),
+			Role:           models.UserRoleAdmin,
+			IP:             "192.168.1.7",
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		},
 	}
 
 	for _, user := range users {
@@ -0,0 +1,203 @@
+package test
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	"loan-api/config"
+	"loan-api/database"
+)
+
+// SkipPostgresEnv es la variable que permite correr las pruebas sin PostgreSQL cuando no está instalado
+const SkipPostgresEnv = "TEST_SKIP_POSTGRES"
+
+// TestDatabase es una base de datos de pruebas abierta para un motor concreto. Si el motor se omitió
+// explícitamente, DB es nil y SkipReason indica el motivo
+type TestDatabase struct {
+	Driver     string
+	DB         *gorm.DB
+	SkipReason string
+	stop       func()
+}
+
+// Close cierra la conexión y detiene la instancia local si el harness la inició
+func (d *TestDatabase) Close() {
+	if d.DB == nil {
+		return
+	}
+	if sqlDB, err := d.DB.DB(); err == nil {
+		sqlDB.Close()
+	}
+	if d.stop != nil {
+		d.stop()
+	}
+}
+
+// OpenTestDatabases abre una base de datos de pruebas por cada motor disponible:
+//   - SQLite en memoria, siempre
+//   - PostgreSQL en TEST_POSTGRES_HOST o, si no está definido, en una instancia local iniciada con initdb y pg_ctl
+//   - MySQL solo si TEST_MYSQL_HOST está definido
+//
+// Si un motor no se puede abrir retorna error y cierra los que ya abrió. PostgreSQL solo se omite si
+// TEST_SKIP_POSTGRES=1; en ese caso se agrega sin conexión para que las pruebas lo muestren como omitido
+func OpenTestDatabases(base config.Config) ([]*TestDatabase, error) {
+	var databases []*TestDatabase
+	fail := func(err error) ([]*TestDatabase, error) {
+		for _, testDB := range databases {
+			testDB.Close()
+		}
+		return nil, err
+	}
+
+	sqliteCfg := base
+	sqliteCfg.DBDriver = config.DBDriverSQLite
+	sqliteCfg.DBName = config.SQLiteInMemory
+	sqliteDB, err := openTestDatabase(sqliteCfg, nil)
+	if err != nil {
+		return fail(err)
+	}
+	databases = append(databases, sqliteDB)
+
+	postgresCfg, stop, err := postgresTestConfig(base)
+	if err == nil {
+		var postgresDB *TestDatabase
+		if postgresDB, err = openTestDatabase(postgresCfg, stop); err == nil {
+			databases = append(databases, postgresDB)
+		}
+	}
+	if err != nil {
+		if os.Getenv(SkipPostgresEnv) != "1" {
+			return fail(fmt.Errorf("%w (defina %s=1 para omitir PostgreSQL)", err, SkipPostgresEnv))
+		}
+		databases = append(databases, &TestDatabase{Driver: config.DBDriverPostgres, SkipReason: err.Error()})
+	}
+
+	if os.Getenv("TEST_MYSQL_HOST") != "" {
+		mysqlDB, err := openTestDatabase(envTestConfig(base, config.DBDriverMySQL, "TEST_MYSQL"), nil)
+		if err != nil {
+			return fail(err)
+		}
+		databases = append(databases, mysqlDB)
+	}
+
+	return databases, nil
+}
+
+// openTestDatabase abre la base de datos y la migra; si falla detiene su instancia
+func openTestDatabase(cfg config.Config, stop func()) (*TestDatabase, error) {
+	db, err := database.Open(&cfg)
+	if err != nil {
+		if stop != nil {
+			stop()
+		}
+		return nil, fmt.Errorf("no se pudo abrir %s para las pruebas: %w", cfg.DBDriver, err)
+	}
+
+	testDB := &TestDatabase{Driver: cfg.DBDriver, DB: db, stop: stop}
+	if err := PrepareDatabase(db); err != nil {
+		testDB.Close()
+		return nil, fmt.Errorf("no se pudo migrar %s para las pruebas: %w", cfg.DBDriver, err)
+	}
+	return testDB, nil
+}
+
+// envTestConfig construye la configuración de un motor a partir de las variables <prefix>_HOST, _PORT, _USER, _PASSWORD y _NAME
+func envTestConfig(base config.Config, driver, prefix string) config.Config {
+	cfg := base
+	cfg.DBDriver = driver
+	cfg.DBHost = os.Getenv(prefix + "_HOST")
+	cfg.DBPort = os.Getenv(prefix + "_PORT")
+	cfg.DBUser = os.Getenv(prefix + "_USER")
+	cfg.DBPassword = os.Getenv(prefix + "_PASSWORD")
+	cfg.DBName = os.Getenv(prefix + "_NAME")
+	if cfg.DBPort == "" {
+		cfg.DBPort = map[string]string{config.DBDriverPostgres: "5432", config.DBDriverMySQL: "3306"}[driver]
+	}
+	return cfg
+}
+
+// postgresTestConfig retorna la configuración de PostgreSQL para las pruebas, iniciando una instancia
+// local desechable cuando no se indica un servidor externo
+func postgresTestConfig(base config.Config) (config.Config, func(), error) {
+	if os.Getenv("TEST_POSTGRES_HOST") != "" {
+		return envTestConfig(base, config.DBDriverPostgres, "TEST_POSTGRES"), nil, nil
+	}
+
+	initdb, err := findPostgresBinary("initdb")
+	if err != nil {
+		return base, nil, err
+	}
+	pgCtl, err := findPostgresBinary("pg_ctl")
+	if err != nil {
+		return base, nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "loan-api-pg-")
+	if err != nil {
+		return base, nil, err
+	}
+	dataDir := filepath.Join(dir, "data")
+
+	out, err := exec.Command(initdb, "-D", dataDir, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return base, nil, fmt.Errorf("initdb falló: %v: %s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return base, nil, err
+	}
+
+	// Solo socket Unix en el directorio temporal: no abre puertos TCP en la máquina
+	options := fmt.Sprintf("-k %s -p %d -c listen_addresses='' -F", dir, port)
+	out, err = exec.Command(pgCtl, "-D", dataDir, "-l", filepath.Join(dir, "postgres.log"), "-o", options, "-w", "start").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return base, nil, fmt.Errorf("pg_ctl start falló: %v: %s", err, out)
+	}
+
+	stop := func() {
+		exec.Command(pgCtl, "-D", dataDir, "-m", "immediate", "-w", "stop").Run()
+		os.RemoveAll(dir)
+	}
+
+	cfg := base
+	cfg.DBDriver = config.DBDriverPostgres
+	cfg.DBHost = dir
+	cfg.DBPort = strconv.Itoa(port)
+	cfg.DBUser = "postgres"
+	cfg.DBPassword = ""
+	cfg.DBName = "postgres"
+	return cfg, stop, nil
+}
+
+// findPostgresBinary busca un binario de PostgreSQL en el PATH o en las rutas de instalación habituales
+func findPostgresBinary(name string) (string, error) {
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+	for _, pattern := range []string{"/usr/lib/postgresql/*/bin/", "/usr/local/pgsql/bin/", "/opt/homebrew/bin/"} {
+		if matches, _ := filepath.Glob(pattern + name); len(matches) > 0 {
+			return matches[len(matches)-1], nil
+		}
+	}
+	return "", fmt.Errorf("no se encontró %s; defina TEST_POSTGRES_HOST para usar un servidor existente", name)
+}
+
+// freePort retorna un puerto TCP libre para la instancia local
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
@@ -8,13 +8,12 @@ import (
 	"net/http/httptest"
 
 	"loan-api/app"
-	"loan-api/config"
 )
 
 func MakeRequest(
-	Method string, CONFIG config.Config, url string, requestBody interface{}, headers map[string]string,
+	Method string, APP *app.Container, url string, requestBody interface{}, headers map[string]string,
 ) *httptest.ResponseRecorder {
-	router := app.SetupRouter(CONFIG)
+	router := app.SetupRouter(APP)
 
 	body, _ := json.Marshal(requestBody)
 	req, _ := http.NewRequest(Method, url, bytes.NewBuffer(body))
@@ -33,17 +32,17 @@ func MakeRequest(
 }
 
 func MakePostRequest(
-	CONFIG config.Config, url string, body interface{}, headers map[string]string,
+	APP *app.Container, url string, body interface{}, headers map[string]string,
 ) *httptest.ResponseRecorder {
 	return MakeRequest(
-		"POST", CONFIG, url, body, headers,
+		"POST", APP, url, body, headers,
 	)
 }
 
 func MakeGetRequest(
-	CONFIG config.Config, url string, urlParams map[string]interface{}, headers map[string]string,
+	APP *app.Container, url string, urlParams map[string]interface{}, headers map[string]string,
 ) *httptest.ResponseRecorder {
-	router := app.SetupRouter(CONFIG)
+	router := app.SetupRouter(APP)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", url, nil)
@@ -7,7 +7,7 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"loan-api/config"
@@ -77,11 +77,13 @@ func CreateToken(ttl time.Duration, payload interface{}, privateKey string) (str
 
 // GenerateAccessToken genera un token JWT para acceso
 func GenerateAccessToken(user *models.User, cfg *config.Config) (string, error) {
-	log.Printf("GenerateAccessToken - Generando token para usuario ID: %d - Email: %s", user.ID, user.Email)
+	slog.Debug("GenerateAccessToken - generando token", "user_id", user.ID)
 
 	payload := map[string]interface{}{
-		"id":    user.ID,
-		"email": user.Email,
+		"id":        user.ID,
+		"email":     user.Email,
+		"role":      user.Role,
+		"tenant_id": user.TenantID,
 	}
 
 	ttl := cfg.AccessTokenExpiresIn
@@ -0,0 +1,34 @@
+package utils
+
+import "context"
+
+// contextKey evita colisiones con valores guardados en el contexto por otros paquetes
+type contextKey string
+
+const (
+	requestIDKey   contextKey = "request_id"
+	traceParentKey contextKey = "traceparent"
+)
+
+// WithRequestID retorna una copia del contexto con el identificador de la solicitud
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext obtiene el identificador de la solicitud, o "" si no existe
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithTraceParent retorna una copia del contexto con la cabecera traceparent (W3C Trace Context)
+// para propagarla a los proveedores externos
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	return context.WithValue(ctx, traceParentKey, traceParent)
+}
+
+// TraceParentFromContext obtiene la cabecera traceparent de la solicitud, o "" si no existe
+func TraceParentFromContext(ctx context.Context) string {
+	traceParent, _ := ctx.Value(traceParentKey).(string)
+	return traceParent
+}
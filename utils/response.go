@@ -1,7 +1,12 @@
 package utils
 
 import (
+	"encoding/base64"
+	"errors"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"loan-api/app_error"
 
@@ -14,6 +19,8 @@ type APIResponse struct {
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   *ErrorInfo  `json:"error,omitempty"`
+	// RequestID identifica la solicitud en los logs; se incluye en las respuestas de error
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // ErrorInfo contiene información detallada del error
@@ -41,6 +48,21 @@ type Pagination struct {
 	HasPrev    bool  `json:"has_prev"`
 }
 
+// CursorPaginatedResponse representa una respuesta paginada por cursor
+type CursorPaginatedResponse struct {
+	Success    bool             `json:"success"`
+	Message    string           `json:"message"`
+	Data       interface{}      `json:"data"`
+	Pagination CursorPagination `json:"pagination"`
+}
+
+// CursorPagination contiene información de paginación por cursor
+type CursorPagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasNext    bool   `json:"has_next"`
+}
+
 // SuccessResponse envía una respuesta exitosa
 func SuccessResponse(c *gin.Context, code int, message string, data interface{}) {
 	response := APIResponse{
@@ -53,8 +75,16 @@ func SuccessResponse(c *gin.Context, code int, message string, data interface{})
 
 // ErrorResponse envía una respuesta de error
 func ErrorResponse(c *gin.Context, err error) {
+	// Si la solicitud venció o el cliente la canceló, el error original es consecuencia de ello
+	if ctxErr, ok := requestContextError(c, err); ok {
+		err = ctxErr
+	}
+
 	// Si es un AppError, usar su información
 	if appErr, ok := app_error.IsAppError(err); ok {
+		if appErr.Code >= http.StatusInternalServerError {
+			slog.ErrorContext(c.Request.Context(), appErr.Message, "details", appErr.Details)
+		}
 		response := APIResponse{
 			Success: false,
 			Message: "Error en la solicitud",
@@ -63,12 +93,14 @@ func ErrorResponse(c *gin.Context, err error) {
 				Message: appErr.Message,
 				Details: appErr.Details,
 			},
+			RequestID: c.GetString("request_id"),
 		}
 		c.JSON(appErr.Code, response)
 		return
 	}
 
 	// Error genérico
+	slog.ErrorContext(c.Request.Context(), "error interno del servidor", "error", err)
 	response := APIResponse{
 		Success: false,
 		Message: "Error interno del servidor",
@@ -76,10 +108,20 @@ func ErrorResponse(c *gin.Context, err error) {
 			Code:    http.StatusInternalServerError,
 			Message: err.Error(),
 		},
+		RequestID: c.GetString("request_id"),
 	}
 	c.JSON(http.StatusInternalServerError, response)
 }
 
+// requestContextError retorna el AppError de plazo vencido o cancelación si el error o el contexto
+// de la solicitud lo indican
+func requestContextError(c *gin.Context, err error) (*app_error.AppError, bool) {
+	if appErr, ok := app_error.FromContextError(err); ok {
+		return appErr, true
+	}
+	return app_error.FromContextError(c.Request.Context().Err())
+}
+
 // ValidationErrorResponse envía una respuesta de error de validación
 func ValidationErrorResponse(c *gin.Context, errors []string) {
 	response := APIResponse{
@@ -90,6 +132,7 @@ func ValidationErrorResponse(c *gin.Context, errors []string) {
 			Message: "Los datos proporcionados no son válidos",
 			Details: joinErrors(errors),
 		},
+		RequestID: c.GetString("request_id"),
 	}
 	c.JSON(http.StatusBadRequest, response)
 }
@@ -105,6 +148,17 @@ func PaginatedSuccessResponse(c *gin.Context, message string, data interface{},
 	c.JSON(http.StatusOK, response)
 }
 
+// CursorPaginatedSuccessResponse envía una respuesta exitosa con paginación por cursor
+func CursorPaginatedSuccessResponse(c *gin.Context, message string, data interface{}, pagination CursorPagination) {
+	response := CursorPaginatedResponse{
+		Success:    true,
+		Message:    message,
+		Data:       data,
+		Pagination: pagination,
+	}
+	c.JSON(http.StatusOK, response)
+}
+
 // CreatedResponse envía una respuesta de recurso creado
 func CreatedResponse(c *gin.Context, message string, data interface{}) {
 	SuccessResponse(c, http.StatusCreated, message, data)
@@ -159,6 +213,40 @@ func NewPagination(page, limit int, total int64) Pagination {
 	}
 }
 
+// NewCursorPagination crea la paginación por cursor a partir del ID desde el que continúa la siguiente página
+func NewCursorPagination(limit int, nextID uint) CursorPagination {
+	pagination := CursorPagination{Limit: limit}
+	if nextID != 0 {
+		pagination.NextCursor = EncodeCursor(nextID)
+		pagination.HasNext = true
+	}
+	return pagination
+}
+
+// EncodeCursor codifica el ID de un registro como cursor opaco
+func EncodeCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("id:" + strconv.FormatUint(uint64(id), 10)))
+}
+
+// DecodeCursor obtiene el ID de un registro a partir de su cursor
+func DecodeCursor(cursor string) (uint, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errors.New("cursor inválido")
+	}
+
+	value, found := strings.CutPrefix(string(decoded), "id:")
+	if !found {
+		return 0, errors.New("cursor inválido")
+	}
+
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || id == 0 {
+		return 0, errors.New("cursor inválido")
+	}
+	return uint(id), nil
+}
+
 // joinErrors une múltiples errores en una cadena
 func joinErrors(errors []string) string {
 	if len(errors) == 0 {